@@ -0,0 +1,260 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// SlotStatistics summarises the market outcome of one trading slot.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type SlotStatistics struct {
+	AverageDeliveryRatio float64 `json:"averageDeliveryRatio"` // mean delivered/original units over executed matches
+	BuyOrders            int64   `json:"buyOrders"`
+	ClearingPrice        float64 `json:"clearingPrice"` // volume-weighted unit price of the matched units
+	DeliveredVolume      float64 `json:"deliveredVolume"`
+	ExecutedMatches      int64   `json:"executedMatches"`
+	Matches              int64   `json:"matches"`
+	MaxPrice             int64   `json:"maxPrice"`
+	MinPrice             int64   `json:"minPrice"`
+	Participants         int64   `json:"participants"`
+	SellOrders           int64   `json:"sellOrders"`
+	SlotExecDate         int64   `json:"slotExecDate"`
+	SlotID               string  `json:"slotId"`
+	TradedVolume         float64 `json:"tradedVolume"`
+	UnmatchedBuyVolume   float64 `json:"unmatchedBuyVolume"`
+	UnmatchedSellVolume  float64 `json:"unmatchedSellVolume"`
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// queryOrdersBySlot groups every order on the ledger by slot, keeping only those
+// the filter accepts.
+func queryOrdersBySlot(stub shim.ChaincodeStubInterface, filter func(Order) bool) (map[string][]Order, error) {
+	startKey, endKey := prefixRange("Order_")
+	iterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	ordersBySlot := map[string][]Order{}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var order Order
+		err = decodeAsset(entry.Value, &order)
+		if err != nil {
+			return nil, err
+		}
+		if filter(order) {
+			ordersBySlot[order.SlotID] = append(ordersBySlot[order.SlotID], order)
+		}
+	}
+	return ordersBySlot, nil
+}
+
+// slotExecDates resolves the exec date of each slot once: the start of its
+// TradingSlot, or the exec date its orders carry when it was never put on the calendar.
+type slotExecDates struct {
+	stub  shim.ChaincodeStubInterface
+	dates map[string]int64
+}
+
+func newSlotExecDates(stub shim.ChaincodeStubInterface) *slotExecDates {
+	return &slotExecDates{stub: stub, dates: map[string]int64{}}
+}
+
+func (s *slotExecDates) of(order Order) int64 {
+	date, ok := s.dates[order.SlotID]
+	if !ok {
+		date = order.SlotExecDate
+		if slot, err := getTradingSlot(s.stub, order.SlotID); err == nil {
+			date = slot.StartTime
+		}
+		s.dates[order.SlotID] = date
+	}
+	return date
+}
+
+// computeSlotStatistics combines a slot's orders with its BidMatches.
+func computeSlotStatistics(stub shim.ChaincodeStubInterface, slotID string, orders []Order, execDates *slotExecDates) (SlotStatistics, error) {
+	stats := SlotStatistics{SlotID: slotID}
+	participants := map[int64]bool{}
+
+	for _, order := range orders {
+		participants[order.UserID] = true
+		stats.SlotExecDate = execDates.of(order)
+		if order.BidStatus == BidCancelled {
+			continue
+		}
+		remaining := math.Max(order.RemainingQuantity, 0)
+		if order.UserAction == Sell {
+			stats.SellOrders++
+			stats.UnmatchedSellVolume += remaining
+		} else {
+			stats.BuyOrders++
+			stats.UnmatchedBuyVolume += remaining
+		}
+	}
+
+	bidMatches, err := queryBidMatchesBySlot(stub, slotID)
+	if err != nil {
+		return stats, err
+	}
+	var tradedValue, deliveryRatios float64
+	for _, bidMatch := range bidMatches {
+		participants[bidMatch.BuyerUserId] = true
+		participants[bidMatch.SellerUserId] = true
+
+		units := fillContribution(bidMatch)
+		if units <= 0 {
+			continue
+		}
+		stats.Matches++
+		stats.TradedVolume += units
+		tradedValue += units * float64(bidMatch.BidUnitPrice)
+		if stats.Matches == 1 || bidMatch.BidUnitPrice < stats.MinPrice {
+			stats.MinPrice = bidMatch.BidUnitPrice
+		}
+		if bidMatch.BidUnitPrice > stats.MaxPrice {
+			stats.MaxPrice = bidMatch.BidUnitPrice
+		}
+
+		if bidMatch.BidStatus == BidExecuted {
+			stats.ExecutedMatches++
+			stats.DeliveredVolume += bidMatch.DeliveredBidUnits
+			if bidMatch.OriginalBidUnits > 0 {
+				deliveryRatios += bidMatch.DeliveredBidUnits / bidMatch.OriginalBidUnits
+			}
+		}
+	}
+
+	if stats.TradedVolume > 0 {
+		stats.ClearingPrice = roundCents(tradedValue / stats.TradedVolume)
+	}
+	if stats.ExecutedMatches > 0 {
+		stats.AverageDeliveryRatio = math.Round(deliveryRatios/float64(stats.ExecutedMatches)*10000) / 10000
+	}
+	stats.Participants = int64(len(participants))
+	return stats, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Analytics Read Methods                           */
+/* -------------------------------------------------------------------------- */
+
+// ============================================================================================================================
+// QuerySlotStatistics() - clearing price, volumes and delivery performance of one trading slot
+//
+// Inputs - Array of strings
+//    0
+//  slotID
+//  "slot1"
+// ============================================================================================================================
+func QuerySlotStatistics(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the slot ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	ordersBySlot, err := queryOrdersBySlot(stub, func(order Order) bool { return order.SlotID == args[0] })
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query Orders: "+err.Error())
+	}
+	stats, err := computeSlotStatistics(stub, args[0], ordersBySlot[args[0]], newSlotExecDates(stub))
+	if err != nil {
+		return wrapErrorResponse("Failed to compute slot statistics: ", err)
+	}
+	if slot, err := getTradingSlot(stub, args[0]); err == nil {
+		// Slots without orders still report when they execute.
+		stats.SlotExecDate = slot.StartTime
+	}
+
+	statsAsBytes, _ := json.Marshal(stats)
+	return shim.Success(statsAsBytes)
+}
+
+// ============================================================================================================================
+// QuerySlotStatisticsByRange() - SlotStatistics of every slot with orders executing in [from, to], oldest first
+//
+// A slot executes at the start of its TradingSlot, whatever exec date its orders were registered with.
+//
+// Inputs - Array of strings
+//       0      ,      1
+//  fromExecDate,  toExecDate
+//  "1700000000", "1700086400"
+// ============================================================================================================================
+func QuerySlotStatisticsByRange(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	fromExecDate, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse from date: "+err.Error())
+	}
+	toExecDate, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse to date: "+err.Error())
+	}
+	if toExecDate < fromExecDate {
+		return errorResponse(errcode.InvalidArgument, "To date must not be before from date.")
+	}
+
+	execDates := newSlotExecDates(stub)
+	ordersBySlot, err := queryOrdersBySlot(stub, func(order Order) bool {
+		execDate := execDates.of(order)
+		return execDate >= fromExecDate && execDate <= toExecDate
+	})
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query Orders: "+err.Error())
+	}
+
+	series := []SlotStatistics{}
+	for slotID, orders := range ordersBySlot {
+		stats, err := computeSlotStatistics(stub, slotID, orders, execDates)
+		if err != nil {
+			return wrapErrorResponse("Failed to compute slot statistics: ", err)
+		}
+		series = append(series, stats)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].SlotExecDate != series[j].SlotExecDate {
+			return series[i].SlotExecDate < series[j].SlotExecDate
+		}
+		return series[i].SlotID < series[j].SlotID
+	})
+
+	seriesAsBytes, _ := json.Marshal(series)
+	return shim.Success(seriesAsBytes)
+}
@@ -0,0 +1,126 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlotStatistics(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+
+	bidMatch := func(id string, slotID string, status string, price string, original string, delivered string) [][]byte {
+		return [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte(strconv.FormatInt(time.Now().Unix(), 10)), // bidMatchTms
+			[]byte(slotID),                                   // bidSlot
+			[]byte(status),                                   // bidStatus
+			[]byte(price),                                    // bidUnitPrice
+			[]byte("3"),                                      // buyerUserId
+			[]byte(delivered),                                // deliveredBidUnits
+			[]byte(id),                                       // ID
+			[]byte(original),                                 // originalBidUnits
+			[]byte("4"),                                      // sellerUserId
+			[]byte("1"),                                      // transactionBuyID
+			[]byte("2"),                                      // transactionSellID
+		}
+	}
+
+	// Executed matches need the seller's profile for its energy source.
+	sellerAsBytes, _ := json.Marshal(User{ID: 4, Category: Prosumer, Source: Battery})
+	stub.MockTransactionStart("seedSeller")
+	_ = stub.PutState("4", sellerAsBytes)
+	stub.MockTransactionEnd("seedSeller")
+
+	seedOrder(t, stub, Order{ID: 1, BidStatus: BidCreated, RemainingQuantity: 20, SlotExecDate: 1000, SlotID: "slot1", TotalQuantity: 120, UserAction: Buy, UserID: 3})
+	seedOrder(t, stub, Order{ID: 2, BidStatus: BidCreated, RemainingQuantity: 0, SlotExecDate: 1000, SlotID: "slot1", TotalQuantity: 100, UserAction: Sell, UserID: 4})
+	seedOrder(t, stub, Order{ID: 3, BidStatus: BidCancelled, RemainingQuantity: 50, SlotExecDate: 1000, SlotID: "slot1", TotalQuantity: 50, UserAction: Sell, UserID: 5})
+	seedOrder(t, stub, Order{ID: 4, BidStatus: BidCreated, RemainingQuantity: 10, SlotExecDate: 2000, SlotID: "slot2", TotalQuantity: 10, UserAction: Sell, UserID: 4})
+	seedOrder(t, stub, Order{ID: 5, BidStatus: BidCreated, RemainingQuantity: 10, SlotExecDate: 9000, SlotID: "slot3", TotalQuantity: 10, UserAction: Buy, UserID: 3})
+
+	for i, args := range [][][]byte{
+		bidMatch("1", "slot1", "3", "4", "60", "60"),
+		bidMatch("2", "slot1", "3", "6", "40", "20"),
+		bidMatch("3", "slot1", "2", "9", "30", "0"),
+	} {
+		response := stub.MockInvoke(strconv.Itoa(10+i), args)
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	}
+
+	// Test Case 1: A slot's statistics combine its orders and matches
+	t.Run("Single Slot", func(t *testing.T) {
+		response := stub.MockInvoke("20", [][]byte{[]byte("QuerySlotStatistics"), []byte("slot1")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var stats SlotStatistics
+		err := json.Unmarshal(response.GetPayload(), &stats)
+		assert.NoError(t, err, "Error unmarshalling statistics")
+		assert.Equal(t, int64(1), stats.BuyOrders, "BuyOrders mismatch")
+		assert.Equal(t, int64(1), stats.SellOrders, "Cancelled order counted")
+		assert.Equal(t, int64(2), stats.Matches, "Rejected match counted")
+		assert.Equal(t, int64(2), stats.ExecutedMatches, "ExecutedMatches mismatch")
+		assert.Equal(t, 100.0, stats.TradedVolume, "TradedVolume mismatch")
+		assert.Equal(t, 80.0, stats.DeliveredVolume, "DeliveredVolume mismatch")
+		assert.Equal(t, 4.8, stats.ClearingPrice, "ClearingPrice mismatch")
+		assert.Equal(t, int64(4), stats.MinPrice, "MinPrice mismatch")
+		assert.Equal(t, int64(6), stats.MaxPrice, "MaxPrice mismatch")
+		assert.Equal(t, 0.75, stats.AverageDeliveryRatio, "AverageDeliveryRatio mismatch")
+		assert.Equal(t, 20.0, stats.UnmatchedBuyVolume, "UnmatchedBuyVolume mismatch")
+		assert.Equal(t, 0.0, stats.UnmatchedSellVolume, "UnmatchedSellVolume mismatch")
+		assert.Equal(t, int64(3), stats.Participants, "Participants mismatch")
+	})
+
+	// Test Case 2: The range query returns one entry per slot, oldest first
+	t.Run("Time Series", func(t *testing.T) {
+		response := stub.MockInvoke("21", [][]byte{[]byte("QuerySlotStatisticsByRange"), []byte("0"), []byte("5000")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var series []SlotStatistics
+		err := json.Unmarshal(response.GetPayload(), &series)
+		assert.NoError(t, err, "Error unmarshalling series")
+		assert.Len(t, series, 2, "Slot outside the range included")
+		assert.Equal(t, "slot1", series[0].SlotID, "Series not ordered")
+		assert.Equal(t, "slot2", series[1].SlotID, "Series not ordered")
+		assert.Equal(t, int64(0), series[1].Matches, "Matches mismatch")
+		assert.Equal(t, 10.0, series[1].UnmatchedSellVolume, "UnmatchedSellVolume mismatch")
+	})
+
+	// Test Case 3: An inverted range is rejected
+	t.Run("Invalid Range", func(t *testing.T) {
+		response := stub.MockInvoke("22", [][]byte{[]byte("QuerySlotStatisticsByRange"), []byte("5000"), []byte("0")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "must not be before")
+	})
+
+	// Test Case 4: Slots on the calendar execute at their start, and matches indexed by MigrateAssets count
+	t.Run("Calendar Slot And Migrated Matches", func(t *testing.T) {
+		seedOrder(t, stub, Order{ID: 6, BidStatus: BidCreated, RemainingQuantity: 10, SlotExecDate: 50, SlotID: "slot4", TotalQuantity: 10, UserAction: Buy, UserID: 3})
+		slotAsBytes, _ := json.Marshal(TradingSlot{ID: "slot4", GateClosure: 2400, StartTime: 3000, EndTime: 3900})
+		legacyAsBytes, _ := json.Marshal(BidMatch{BidSlot: "slot4", BidStatus: BidExecuted, BidUnitPrice: 5, DeliveredBidUnits: 10, ID: 9, OriginalBidUnits: 10})
+		stub.MockTransactionStart("seedSlot4")
+		_ = stub.PutState("TradingSlot_slot4", slotAsBytes)
+		_ = stub.PutState("BidMatch_9", legacyAsBytes)
+		stub.MockTransactionEnd("seedSlot4")
+
+		response := invokeAsAdmin(t, stub, "23", [][]byte{[]byte("MigrateAssets"), []byte("BidMatch"), []byte("100"), []byte("")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("24", [][]byte{[]byte("QuerySlotStatisticsByRange"), []byte("2500"), []byte("3500")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var series []SlotStatistics
+		err := json.Unmarshal(response.GetPayload(), &series)
+		assert.NoError(t, err, "Error unmarshalling series")
+		assert.Len(t, series, 1, "Unexpected number of slots")
+		assert.Equal(t, "slot4", series[0].SlotID, "SlotID mismatch")
+		assert.Equal(t, int64(3000), series[0].SlotExecDate, "SlotExecDate mismatch")
+		assert.Equal(t, int64(1), series[0].Matches, "Migrated match not counted")
+	})
+}
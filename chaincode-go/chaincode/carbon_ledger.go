@@ -0,0 +1,341 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Lifecycle emission factors in kg CO2e per kWh, used until SetEmissionFactor
+// configures a source. Battery discharge is attributed to the energy that
+// charged it, so it carries no factor of its own.
+var defaultEmissionFactors = map[EnergySource]float64{
+	Solar:   0.041,
+	Wind:    0.011,
+	DGSet:   0.820,
+	Battery: 0,
+}
+
+// EmissionsSummary is the scope 2 report returned by QueryEmissionsSummary.
+// The layout follows the GHG Protocol scope 2 guidance, with totals per source
+// and the underlying transactions. The figures apply the lifecycle factor of each
+// seller's source and do not net certificates, so the method is lifecycle-based
+// rather than market-based.
+type EmissionsSummary struct {
+	ReportingEntity EmissionsEntity `json:"reportingEntity"`
+	ReportingPeriod EmissionsPeriod `json:"reportingPeriod"`
+	Scope2          Scope2Emissions `json:"scope2"`
+}
+
+type EmissionsEntity struct {
+	UserID   int64  `json:"userId"`
+	Category string `json:"category"`
+}
+
+type EmissionsPeriod struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+type Scope2Emissions struct {
+	Method              string                 `json:"method"`
+	Unit                string                 `json:"unit"`
+	TotalConsumptionKWh float64                `json:"totalConsumptionKwh"`
+	TotalCO2eKg         float64                `json:"totalCo2eKg"`
+	BySource            []SourceEmissions      `json:"bySource"`
+	Transactions        []TransactionEmissions `json:"transactions"`
+}
+
+type SourceEmissions struct {
+	Source         string  `json:"source"`
+	ConsumptionKWh float64 `json:"consumptionKwh"`
+	CO2eKg         float64 `json:"co2eKg"`
+}
+
+type TransactionEmissions struct {
+	BidMatchID             int64   `json:"bidMatchId"`
+	BidMatchTms            int64   `json:"bidMatchTms"`
+	BidSlot                string  `json:"bidSlot"`
+	SellerUserID           int64   `json:"sellerUserId"`
+	Source                 string  `json:"source"`
+	ConsumptionKWh         float64 `json:"consumptionKwh"`
+	EmissionFactorKgPerKWh float64 `json:"emissionFactorKgPerKwh"`
+	CO2eKg                 float64 `json:"co2eKg"`
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func getEmissionFactor(stub shim.ChaincodeStubInterface, source EnergySource) (float64, error) {
+	factorAsBytes, err := stub.GetState("EmissionFactor_" + strconv.FormatInt(int64(source), 10))
+	if err != nil {
+		return 0, errors.New("Error accessing state: " + err.Error())
+	}
+	if factorAsBytes == nil {
+		return defaultEmissionFactors[source], nil
+	}
+
+	var factor EmissionFactor
+	err = decodeAsset(factorAsBytes, &factor)
+	if err != nil {
+		return 0, errors.New("Failed to unmarshal emission factor: " + err.Error())
+	}
+	return factor.KgCO2ePerKWh, nil
+}
+
+// computeBidMatchEmissions stamps the emission factor of the match's source and
+// the CO2e of the delivered units on an executed BidMatch.
+func computeBidMatchEmissions(stub shim.ChaincodeStubInterface, bidMatch *BidMatch) error {
+	if bidMatch.EmissionSource == nil {
+		return errcode.New(errcode.StateViolation, "BidMatch "+strconv.FormatInt(bidMatch.ID, 10)+" has no energy source")
+	}
+	factor, err := getEmissionFactor(stub, *bidMatch.EmissionSource)
+	if err != nil {
+		return err
+	}
+	bidMatch.EmissionFactor = factor
+	bidMatch.CO2eKg = bidMatch.DeliveredBidUnits * factor
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            Emission Write Methods                          */
+/* -------------------------------------------------------------------------- */
+
+// parseEmissionFactorRequest reads the positional arguments of SetEmissionFactor.
+func parseEmissionFactorRequest(args []string) (EmissionFactorRequest, error) {
+	var request EmissionFactorRequest
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.Source, err = parseEnergySource(args[0])
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid energy source: "+err.Error())
+	}
+	request.KgCO2ePerKWh, err = strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse emission factor: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *EmissionFactorRequest) validate() []FieldError {
+	return nonNegative(nil, map[string]float64{"kgCo2ePerKwh": request.KgCO2ePerKWh})
+}
+
+// ============================================================================================================================
+// SetEmissionFactor() - platform admin configures the carbon intensity of an energy source
+//
+// Inputs - Array of strings
+//                        0
+//                     payload
+//  "{"source": "DG Set", "kgCo2ePerKwh": 0.82}"
+// The positional form (source, kgCO2ePerKWh) is still accepted.
+// ============================================================================================================================
+func SetEmissionFactor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request EmissionFactorRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 2 {
+		request, err = parseEmissionFactorRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 2.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	source := request.Source
+	kgPerKWh := request.KgCO2ePerKWh
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	factor := EmissionFactor{
+		KgCO2ePerKWh: kgPerKWh,
+		Source:       source,
+		UpdatedOn:    now,
+	}
+
+	factorAsBytes, err := encodeAsset(&factor)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal emission factor: "+err.Error())
+	}
+	err = stub.PutState("EmissionFactor_"+strconv.FormatInt(int64(source), 10), factorAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store emission factor: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            Emission Read Methods                           */
+/* -------------------------------------------------------------------------- */
+
+func ReadEmissionFactor(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the energy source.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	source, err := parseEnergySource(args[0])
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Invalid energy source: "+err.Error())
+	}
+	kgPerKWh, err := getEmissionFactor(stub, source)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	factorAsBytes, _ := json.Marshal(EmissionFactor{KgCO2ePerKWh: kgPerKWh, Source: source})
+	return shim.Success(factorAsBytes)
+}
+
+// ============================================================================================================================
+// QueryEmissionsSummary() - scope 2 emissions of a buyer's executed purchases over a period
+//
+// Inputs - Array of strings
+//    0    ,     1      ,    2
+//  userID , startTms   , endTms
+// ============================================================================================================================
+func QueryEmissionsSummary(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 3.")
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+	startTms, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse start time: "+err.Error())
+	}
+	endTms, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse end time: "+err.Error())
+	}
+	if endTms < startTms {
+		return errorResponse(errcode.InvalidArgument, "End time must not be before start time.")
+	}
+
+	user, err := getUser(stub, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	summary := EmissionsSummary{
+		ReportingEntity: EmissionsEntity{UserID: userID, Category: UserCategoryString(user.Category)},
+		ReportingPeriod: EmissionsPeriod{Start: startTms, End: endTms},
+		Scope2: Scope2Emissions{
+			Method:       "lifecycle-based",
+			Unit:         "kgCO2e",
+			BySource:     []SourceEmissions{},
+			Transactions: []TransactionEmissions{},
+		},
+	}
+
+	startKey, endKey := prefixRange("BidMatch_")
+	iterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query BidMatches: "+err.Error())
+	}
+	defer iterator.Close()
+
+	bySource := map[EnergySource]*SourceEmissions{}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to query BidMatches: "+err.Error())
+		}
+
+		var bidMatch BidMatch
+		err = decodeAsset(entry.Value, &bidMatch)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal BidMatch: "+err.Error())
+		}
+		if bidMatch.BuyerUserId != userID || bidMatch.BidStatus != BidExecuted {
+			continue
+		}
+		if bidMatch.BidMatchTms < startTms || bidMatch.BidMatchTms > endTms {
+			continue
+		}
+
+		// Emissions count towards the source the seller had when the match executed.
+		// Matches executed before the source was stamped fall back to the seller's current one.
+		source := "Unknown"
+		emissionSource := bidMatch.EmissionSource
+		if emissionSource == nil {
+			seller, err := getUser(stub, bidMatch.SellerUserId)
+			if err == nil {
+				emissionSource = &seller.Source
+			}
+		}
+		if emissionSource != nil {
+			source = EnergySourceString(*emissionSource)
+			if bySource[*emissionSource] == nil {
+				bySource[*emissionSource] = &SourceEmissions{Source: source}
+			}
+			bySource[*emissionSource].ConsumptionKWh += bidMatch.DeliveredBidUnits
+			bySource[*emissionSource].CO2eKg += bidMatch.CO2eKg
+		}
+
+		summary.Scope2.TotalConsumptionKWh += bidMatch.DeliveredBidUnits
+		summary.Scope2.TotalCO2eKg += bidMatch.CO2eKg
+		summary.Scope2.Transactions = append(summary.Scope2.Transactions, TransactionEmissions{
+			BidMatchID:             bidMatch.ID,
+			BidMatchTms:            bidMatch.BidMatchTms,
+			BidSlot:                bidMatch.BidSlot,
+			SellerUserID:           bidMatch.SellerUserId,
+			Source:                 source,
+			ConsumptionKWh:         bidMatch.DeliveredBidUnits,
+			EmissionFactorKgPerKWh: bidMatch.EmissionFactor,
+			CO2eKg:                 bidMatch.CO2eKg,
+		})
+	}
+
+	// Report sources in enum order so the document is stable across peers.
+	for _, source := range []EnergySource{Solar, Wind, DGSet, Battery} {
+		if bySource[source] != nil {
+			summary.Scope2.BySource = append(summary.Scope2.BySource, *bySource[source])
+		}
+	}
+
+	summaryAsBytes, _ := json.Marshal(summary)
+	return shim.Success(summaryAsBytes)
+}
@@ -0,0 +1,128 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmissionsAccounting(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org2MSP", "operator")
+
+	for _, user := range [][]string{
+		{"1", "Prosumer", "Location 1", "MeterId 1", "Solar"},
+		{"2", "Prosumer", "Location 2", "MeterId 2", "DG Set"},
+		{"3", "Consumer", "Location 3", "MeterId 3", "Battery"},
+	} {
+		registerUser(t, stub, user)
+	}
+
+	seedOrder(t, stub, Order{ID: 6, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Buy, UserID: 3})
+	// Sell orders are numbered "7" followed by the seller's ID.
+	for _, seller := range []int64{1, 2} {
+		seedOrder(t, stub, Order{ID: 70 + seller, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Sell, UserID: seller})
+	}
+
+	bidMatch := func(id string, tms string, delivered string, seller string) [][]byte {
+		return [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte(tms),          // bidMatchTms
+			[]byte("Slot1"),      // bidSlot
+			[]byte("3"),          // bidStatus
+			[]byte("5"),          // bidUnitPrice
+			[]byte("3"),          // buyerUserId
+			[]byte(delivered),    // deliveredBidUnits
+			[]byte(id),           // ID
+			[]byte(delivered),    // originalBidUnits
+			[]byte(seller),       // sellerUserId
+			[]byte("6"),          // transactionBuyID
+			[]byte("7" + seller), // transactionSellID
+		}
+	}
+
+	// Test Case 1: Configure a factor and have executed matches pick it up
+	t.Run("Executed Match Stores CO2e", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "2", [][]byte{[]byte("SetEmissionFactor"), []byte("DG Set"), []byte("0.9")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = invokeAsAdmin(t, stub, "3", bidMatch("1", "100", "10", "2"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		bidMatchAsBytes, err := stub.GetState("BidMatch_1")
+		assert.NoError(t, err, "Error getting BidMatch from ledger")
+
+		var stored BidMatch
+		err = json.Unmarshal(bidMatchAsBytes, &stored)
+		assert.NoError(t, err, "Error unmarshalling BidMatch")
+		assert.Equal(t, 0.9, stored.EmissionFactor, "EmissionFactor mismatch")
+		assert.InDelta(t, 9.0, stored.CO2eKg, 1e-9, "CO2eKg mismatch")
+	})
+
+	// Test Case 2: Summary only covers the requested period
+	t.Run("Emissions Summary Over Period", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "4", bidMatch("2", "200", "100", "1"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = invokeAsAdmin(t, stub, "5", bidMatch("3", "900", "100", "2"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("6", [][]byte{[]byte("QueryEmissionsSummary"), []byte("3"), []byte("0"), []byte("500")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var summary EmissionsSummary
+		err := json.Unmarshal(response.GetPayload(), &summary)
+		assert.NoError(t, err, "Error unmarshalling summary")
+		assert.Len(t, summary.Scope2.Transactions, 2, "Unexpected number of transactions")
+		assert.InDelta(t, 110.0, summary.Scope2.TotalConsumptionKWh, 1e-9, "Consumption mismatch")
+		assert.InDelta(t, 9.0+100*0.041, summary.Scope2.TotalCO2eKg, 1e-9, "CO2e mismatch")
+		assert.Equal(t, "Solar", summary.Scope2.BySource[0].Source, "Source ordering mismatch")
+		assert.Equal(t, "lifecycle-based", summary.Scope2.Method, "Method mismatch")
+	})
+
+	// Test Case 3: Negative factors are rejected
+	t.Run("Invalid Emission Factor", func(t *testing.T) {
+		setCreator(t, stub, "Org1MSP", "admin")
+		defer setCreator(t, stub, "Org2MSP", "operator")
+
+		response := stub.MockInvoke("7", [][]byte{[]byte("SetEmissionFactor"), []byte("Solar"), []byte("-1")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 4: Only the platform admin can change a factor
+	t.Run("Non-Admin Refused", func(t *testing.T) {
+		response := stub.MockInvoke("8", [][]byte{[]byte("SetEmissionFactor"), []byte("DG Set"), []byte("0")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "FORBIDDEN")
+	})
+
+	// Test Case 5: Emissions stay with the source the seller had when the match executed
+	t.Run("Source Change Keeps Past Emissions", func(t *testing.T) {
+		registerUser(t, stub, []string{"2", "Prosumer", "Location 2", "MeterId 2", "Wind"})
+
+		response := stub.MockInvoke("9", [][]byte{[]byte("QueryEmissionsSummary"), []byte("3"), []byte("0"), []byte("500")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var summary EmissionsSummary
+		err := json.Unmarshal(response.GetPayload(), &summary)
+		assert.NoError(t, err, "Error unmarshalling summary")
+		assert.Len(t, summary.Scope2.BySource, 2, "Unexpected number of sources")
+		assert.Equal(t, "DG Set", summary.Scope2.BySource[1].Source, "Source mismatch")
+		assert.InDelta(t, 10.0, summary.Scope2.BySource[1].ConsumptionKWh, 1e-9, "Consumption mismatch")
+		assert.Equal(t, "DG Set", summary.Scope2.Transactions[0].Source, "Transaction source mismatch")
+	})
+
+	// Test Case 6: A match is not executed without CO2e because its seller has no profile
+	t.Run("Unknown Seller Refused", func(t *testing.T) {
+		seedOrder(t, stub, Order{ID: 75, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Sell, UserID: 5})
+		response := invokeAsAdmin(t, stub, "11", bidMatch("4", "300", "10", "5"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "NOT_FOUND")
+
+		bidMatchAsBytes, _ := stub.GetState("BidMatch_4")
+		assert.Nil(t, bidMatchAsBytes, "BidMatch stored without emissions")
+	})
+}
@@ -0,0 +1,526 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+const disputeArbitratorConfigKey = "DisputeArbitratorConfig"
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func getDispute(stub shim.ChaincodeStubInterface, disputeID string) (Dispute, error) {
+	var dispute Dispute
+	disputeAsBytes, err := stub.GetState("Dispute_" + disputeID)
+	if err != nil {
+		return dispute, errors.New("Error accessing state: " + err.Error())
+	}
+	if disputeAsBytes == nil {
+		return dispute, errcode.New(errcode.NotFound, "Dispute with ID "+disputeID+" not found.")
+	}
+	err = decodeAsset(disputeAsBytes, &dispute)
+	if err != nil {
+		return dispute, errors.New("Failed to unmarshal dispute: " + err.Error())
+	}
+	return dispute, nil
+}
+
+func putDispute(stub shim.ChaincodeStubInterface, dispute Dispute) error {
+	disputeAsBytes, err := encodeAsset(&dispute)
+	if err != nil {
+		return err
+	}
+	return stub.PutState("Dispute_"+dispute.ID, disputeAsBytes)
+}
+
+// openDisputeKey marks a BidMatch as frozen by the dispute whose ID it holds.
+func openDisputeKey(bidMatchID int64) string {
+	return "BidMatchDispute_" + strconv.FormatInt(bidMatchID, 10)
+}
+
+// isDisputed reports whether a BidMatch is under an open dispute.
+func isDisputed(stub shim.ChaincodeStubInterface, bidMatchID int64) (bool, error) {
+	disputeIDAsBytes, err := stub.GetState(openDisputeKey(bidMatchID))
+	if err != nil {
+		return false, errors.New("Error accessing state: " + err.Error())
+	}
+	return disputeIDAsBytes != nil, nil
+}
+
+// assertNotDisputed refuses changes to the settlement of a BidMatch under an open dispute.
+func assertNotDisputed(stub shim.ChaincodeStubInterface, bidMatchID int64) error {
+	disputeIDAsBytes, err := stub.GetState(openDisputeKey(bidMatchID))
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	if disputeIDAsBytes != nil {
+		return errcode.New(errcode.StateViolation, "BidMatch "+strconv.FormatInt(bidMatchID, 10)+" is frozen by open Dispute "+string(disputeIDAsBytes))
+	}
+	return nil
+}
+
+// assertDisputeParty checks that the caller is the given user and that the user
+// bought or sold in the disputed match.
+func assertDisputeParty(stub shim.ChaincodeStubInterface, bidMatch BidMatch, userID int64) error {
+	if userID != bidMatch.BuyerUserId && userID != bidMatch.SellerUserId {
+		return errcode.New(errcode.Forbidden, "User "+strconv.FormatInt(userID, 10)+" is not a party to BidMatch "+strconv.FormatInt(bidMatch.ID, 10))
+	}
+	return assertUserCaller(stub, userID)
+}
+
+// assertArbitrator allows platform admins and the configured arbitrators, as long
+// as the caller is not itself the buyer or seller in the dispute.
+func assertArbitrator(stub shim.ChaincodeStubInterface, bidMatch BidMatch) (string, error) {
+	identity, err := callerIdentity(stub)
+	if err != nil {
+		return "", err
+	}
+	for _, userID := range []int64{bidMatch.BuyerUserId, bidMatch.SellerUserId} {
+		if user, err := getUser(stub, userID); err == nil && user.Identity == identity {
+			return "", errcode.New(errcode.Forbidden, "Caller "+identity+" is a party to the dispute")
+		}
+	}
+	if assertPlatformAdmin(stub) == nil {
+		return identity, nil
+	}
+
+	configAsBytes, err := stub.GetState(disputeArbitratorConfigKey)
+	if err != nil {
+		return "", errors.New("Error accessing state: " + err.Error())
+	}
+	if configAsBytes != nil {
+		var config DisputeArbitratorConfig
+		err = decodeAsset(configAsBytes, &config)
+		if err != nil {
+			return "", errors.New("Failed to unmarshal arbitrator config: " + err.Error())
+		}
+		for _, arbitrator := range config.Arbitrators {
+			if arbitrator == identity {
+				return identity, nil
+			}
+		}
+	}
+	return "", errcode.New(errcode.Forbidden, "Caller "+identity+" is not an operator or arbitrator")
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             Dispute Write Methods                          */
+/* -------------------------------------------------------------------------- */
+
+// parseDisputeRequest reads the positional arguments of OpenDispute.
+func parseDisputeRequest(args []string) (DisputeRequest, error) {
+	var request DisputeRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.ID = args[0]
+	request.BidMatchID, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse BidMatchID: "+err.Error())
+	}
+	request.UserID, err = strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse UserID: "+err.Error())
+	}
+	disputeType, ok := disputeTypeMap[args[3]]
+	if !ok {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid dispute type "+args[3])
+	}
+	request.Type = disputeType
+	request.Reason = args[4]
+	return request, validateRequest(&request)
+}
+
+func (request *DisputeRequest) validate() []FieldError {
+	return nonEmpty(nil, map[string]string{"id": request.ID, "reason": request.Reason})
+}
+
+// ============================================================================================================================
+// OpenDispute() - the buyer contests delivered units, or the seller a penalty, of a BidMatch
+//
+// Inputs - Array of strings
+//                                                  0
+//                                               payload
+//  "{"id": "D1", "bidMatchId": 1, "userId": 3, "type": "DeliveredUnits", "reason": "Meter shows 80 kWh received"}"
+// The positional form (disputeID, bidMatchID, userID, type, reason) is still accepted.
+// ============================================================================================================================
+func OpenDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request DisputeRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 5 {
+		request, err = parseDisputeRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 5.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	existingDisputeAsBytes, err := stub.GetState("Dispute_" + request.ID)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	if existingDisputeAsBytes != nil {
+		return errorResponse(errcode.Conflict, "Dispute with ID "+request.ID+" already exists.")
+	}
+
+	bidMatch, err := getBidMatch(stub, strconv.FormatInt(request.BidMatchID, 10))
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	userID := request.UserID
+	disputeType := request.Type
+
+	// Buyers contest what was delivered, sellers contest the penalty they were charged.
+	err = assertDisputeParty(stub, bidMatch, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if disputeType == DeliveredUnitsDispute && userID != bidMatch.BuyerUserId {
+		return errorResponse(errcode.Forbidden, "Only the buyer can dispute delivered units")
+	}
+	if disputeType == PenaltyDispute && userID != bidMatch.SellerUserId {
+		return errorResponse(errcode.Forbidden, "Only the seller can dispute a penalty")
+	}
+	err = assertNotDisputed(stub, bidMatch.ID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	dispute := Dispute{
+		BidMatchID: bidMatch.ID,
+		CreatedOn:  now,
+		Evidence:   []DisputeEvidence{},
+		ID:         request.ID,
+		OpenedBy:   userID,
+		Reason:     request.Reason,
+		Status:     DisputeOpen,
+		Type:       disputeType,
+	}
+	dispute.UpdatedOn = dispute.CreatedOn
+
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store dispute: "+err.Error())
+	}
+	err = stub.PutState(openDisputeKey(bidMatch.ID), []byte(dispute.ID))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not freeze BidMatch: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// parseEvidenceRequest reads the positional arguments of SubmitEvidence.
+func parseEvidenceRequest(args []string) (EvidenceRequest, error) {
+	var request EvidenceRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.DisputeID = args[0]
+	request.UserID, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse UserID: "+err.Error())
+	}
+	request.DocumentHash = args[2]
+	request.Description = args[3]
+	return request, validateRequest(&request)
+}
+
+func (request *EvidenceRequest) validate() []FieldError {
+	fieldErrors := nonEmpty(nil, map[string]string{"description": request.Description, "disputeId": request.DisputeID})
+	if decoded, err := hex.DecodeString(request.DocumentHash); err != nil || len(decoded) != sha256.Size {
+		fieldErrors = append(fieldErrors, FieldError{Field: "documentHash", Message: "must be a hex encoded SHA-256 digest"})
+	}
+	return fieldErrors
+}
+
+// ============================================================================================================================
+// SubmitEvidence() - either party attaches the hash of a supporting document to an open dispute
+//
+// Inputs - Array of strings
+//                                           0
+//                                        payload
+//  "{"disputeId": "D1", "userId": 3, "documentHash": "9f86d0...", "description": "Meter reading photo"}"
+// The positional form (disputeID, userID, documentHash, description) is still accepted.
+// ============================================================================================================================
+func SubmitEvidence(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request EvidenceRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 4 {
+		request, err = parseEvidenceRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 4.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	dispute, err := getDispute(stub, request.DisputeID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if dispute.Status != DisputeOpen {
+		return errorResponse(errcode.StateViolation, "Dispute with ID "+request.DisputeID+" is "+DisputeStatusString(dispute.Status))
+	}
+	userID := request.UserID
+	documentHash := strings.ToLower(request.DocumentHash)
+
+	bidMatch, err := getBidMatch(stub, strconv.FormatInt(dispute.BidMatchID, 10))
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = assertDisputeParty(stub, bidMatch, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	dispute.UpdatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	dispute.Evidence = append(dispute.Evidence, DisputeEvidence{
+		Description:  request.Description,
+		DocumentHash: documentHash,
+		SubmittedBy:  userID,
+		SubmittedOn:  dispute.UpdatedOn,
+	})
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store dispute: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// parseDisputeResolutionRequest reads the positional arguments of ResolveDispute.
+func parseDisputeResolutionRequest(args []string) (DisputeResolutionRequest, error) {
+	var request DisputeResolutionRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.ID = args[0]
+	outcome, ok := disputeStatusMap[args[1]]
+	if !ok {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid outcome "+args[1]+". Expecting Upheld or Rejected")
+	}
+	request.Outcome = outcome
+	request.AdjustmentAmount, err = strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid adjustment amount "+args[2])
+	}
+	request.Resolution = args[3]
+	return request, validateRequest(&request)
+}
+
+func (request *DisputeResolutionRequest) validate() []FieldError {
+	fieldErrors := nonEmpty(nil, map[string]string{"id": request.ID, "resolution": request.Resolution})
+	if request.Outcome != DisputeUpheld && request.Outcome != DisputeRejected {
+		fieldErrors = append(fieldErrors, FieldError{Field: "outcome", Message: "must be Upheld or Rejected"})
+	}
+	if request.Outcome == DisputeRejected && request.AdjustmentAmount != 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "adjustmentAmount", Message: "must be 0 for a rejected dispute"})
+	}
+	return nonNegative(fieldErrors, map[string]float64{"adjustmentAmount": request.AdjustmentAmount})
+}
+
+// ============================================================================================================================
+// ResolveDispute() - an operator or arbitrator closes a dispute; an upheld dispute with an amount
+// records an adjusting Payment from the counterparty to the user who opened it
+//
+// Inputs - Array of strings
+//                                              0
+//                                           payload
+//  "{"id": "D1", "outcome": "Upheld", "adjustmentAmount": 12.5, "resolution": "20 kWh refunded at 0.625"}"
+// The positional form (disputeID, outcome, adjustmentAmount, resolution) is still accepted.
+// ============================================================================================================================
+func ResolveDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request DisputeResolutionRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 4 {
+		request, err = parseDisputeResolutionRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 4.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	dispute, err := getDispute(stub, request.ID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if dispute.Status != DisputeOpen {
+		return errorResponse(errcode.StateViolation, "Dispute with ID "+request.ID+" is "+DisputeStatusString(dispute.Status))
+	}
+	outcome := request.Outcome
+	amount := request.AdjustmentAmount
+
+	bidMatch, err := getBidMatch(stub, strconv.FormatInt(dispute.BidMatchID, 10))
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	resolver, err := assertArbitrator(stub, bidMatch)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	if amount > 0 {
+		counterparty := bidMatch.SellerUserId
+		if dispute.OpenedBy == bidMatch.SellerUserId {
+			counterparty = bidMatch.BuyerUserId
+		}
+		pd := PaymentDetail{
+			ID:          txSequenceID(stub, 0),
+			DebitedFrom: strconv.FormatInt(counterparty, 10),
+			CreditedTo:  strconv.FormatInt(dispute.OpenedBy, 10),
+		}
+		if dispute.Type == DeliveredUnitsDispute {
+			pd.BidRefundAmount = amount
+		} else {
+			pd.TokenAmountRefund = amount
+		}
+		p := Payment{
+			BidMatchID:  bidMatch.ID,
+			CreatedOn:   now,
+			ID:          "Dispute_" + dispute.ID,
+			PaymentType: DisputeAdjustment,
+			TotalAmount: amount,
+			UserID:      dispute.OpenedBy,
+		}
+		err = putPayment(stub, p, pd)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		dispute.AdjustmentPaymentID = p.ID
+	}
+
+	dispute.AdjustmentAmount = amount
+	dispute.Resolution = request.Resolution
+	dispute.ResolvedBy = resolver
+	dispute.ResolvedOn = now
+	dispute.Status = outcome
+	dispute.UpdatedOn = dispute.ResolvedOn
+	err = putDispute(stub, dispute)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store dispute: "+err.Error())
+	}
+
+	// Settlement of the match can proceed again.
+	err = stub.DelState(openDisputeKey(bidMatch.ID))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not unfreeze BidMatch: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// ============================================================================================================================
+// SetDisputeArbitrators() - platform admin replaces the list of identities allowed to resolve disputes
+//
+// Inputs - Array of strings
+//           0..n
+//  "<MSP ID>:<common name>", ...
+// ============================================================================================================================
+func SetDisputeArbitrators(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	err := sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	config := DisputeArbitratorConfig{
+		Arbitrators: append([]string{}, args...),
+		UpdatedOn:   now,
+	}
+	configAsBytes, err := encodeAsset(&config)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal arbitrator config: "+err.Error())
+	}
+	err = stub.PutState(disputeArbitratorConfigKey, configAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store arbitrator config: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             Dispute Read Methods                           */
+/* -------------------------------------------------------------------------- */
+
+func ReadDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the dispute ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	disputeAsBytes, _ := json.Marshal(dispute)
+	return shim.Success(disputeAsBytes)
+}
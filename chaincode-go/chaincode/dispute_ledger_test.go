@@ -0,0 +1,159 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisputes(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	// User 3 buys from user 4, each with their own identity.
+	for _, user := range [][]string{
+		{"3", "Consumer", "Location 3", "MeterId 3", "Battery"},
+		{"4", "Prosumer", "Location 4", "MeterId 4", "Solar"},
+	} {
+		setCreator(t, stub, "Org2MSP", "user"+user[0])
+		registerUser(t, stub, user)
+	}
+	seedOrder(t, stub, Order{ID: 6, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Buy, UserID: 3})
+	seedOrder(t, stub, Order{ID: 7, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Sell, UserID: 4})
+
+	bidMatch := func(delivered string) [][]byte {
+		return [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte(strconv.FormatInt(time.Now().Unix(), 10)), // bidMatchTms
+			[]byte("Slot1"),                                  // bidSlot
+			[]byte("3"),                                      // bidStatus
+			[]byte("5"),                                      // bidUnitPrice
+			[]byte("3"),                                      // buyerUserId
+			[]byte(delivered),                                // deliveredBidUnits
+			[]byte("1"),                                      // ID
+			[]byte("100"),                                    // originalBidUnits
+			[]byte("4"),                                      // sellerUserId
+			[]byte("6"),                                      // transactionBuyID
+			[]byte("7"),                                      // transactionSellID
+		}
+	}
+	response := invokeAsAdmin(t, stub, "2", bidMatch("100"))
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	payment := func(paymentID string) [][]byte {
+		return [][]byte{[]byte("RecordPayment"), []byte(paymentID), []byte("Seller - Token Amount"), []byte("10"),
+			[]byte("4"), []byte("wallet-4"), []byte("platform"), []byte("0"), []byte("0"), []byte("10"), []byte("0"),
+			[]byte("0"), []byte("0"), []byte("1")}
+	}
+
+	// Test Case 1: Only the buyer can contest delivered units
+	t.Run("Open Dispute", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user4")
+		response := stub.MockInvoke("3", [][]byte{[]byte("OpenDispute"), []byte("D1"), []byte("1"), []byte("4"),
+			[]byte("DeliveredUnits"), []byte("Not mine to contest")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Only the buyer")
+
+		setCreator(t, stub, "Org2MSP", "user3")
+		response = stub.MockInvoke("4", [][]byte{
+			[]byte("OpenDispute"),
+			[]byte("D1"),                          // disputeID
+			[]byte("1"),                           // bidMatchID
+			[]byte("3"),                           // userID
+			[]byte("DeliveredUnits"),              // type
+			[]byte("Meter shows 80 kWh received"), // reason
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+
+	// Test Case 2: The match can neither be updated nor paid while disputed
+	t.Run("Settlement Frozen", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "5", bidMatch("80"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "frozen by open Dispute D1")
+
+		response = stub.MockInvoke("6", payment("P1"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "frozen by open Dispute D1")
+	})
+
+	// Test Case 3: Both parties can attach document hashes
+	t.Run("Submit Evidence", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user4")
+		response := stub.MockInvoke("7", [][]byte{[]byte("SubmitEvidence"), []byte("D1"), []byte("4"), []byte("not-a-hash"), []byte("Log")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+
+		response = stub.MockInvoke("8", [][]byte{
+			[]byte("SubmitEvidence"),
+			[]byte("D1"), // disputeID
+			[]byte("4"),  // userID
+			[]byte("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"), // documentHash
+			[]byte("Inverter export log"), // description
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+
+	// Test Case 4: Parties and unlisted identities cannot resolve; an arbitrator can
+	t.Run("Resolve Dispute", func(t *testing.T) {
+		resolve := [][]byte{
+			[]byte("ResolveDispute"),
+			[]byte("D1"),                       // disputeID
+			[]byte("Upheld"),                   // outcome
+			[]byte("12.5"),                     // adjustmentAmount
+			[]byte("20 kWh refunded at 0.625"), // resolution
+		}
+		setCreator(t, stub, "Org2MSP", "user3")
+		response := stub.MockInvoke("9", resolve)
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is a party to the dispute")
+
+		setCreator(t, stub, "Org2MSP", "arbitrator")
+		response = stub.MockInvoke("10", resolve)
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not an operator or arbitrator")
+
+		setCreator(t, stub, "Org1MSP", "admin")
+		response = stub.MockInvoke("11", [][]byte{[]byte("SetDisputeArbitrators"), []byte("Org2MSP:arbitrator")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		setCreator(t, stub, "Org2MSP", "arbitrator")
+		response = stub.MockInvoke("12", resolve)
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("13", [][]byte{[]byte("ReadDispute"), []byte("D1")})
+		var dispute Dispute
+		err := json.Unmarshal(response.GetPayload(), &dispute)
+		assert.NoError(t, err, "Error unmarshalling dispute")
+		assert.Equal(t, DisputeUpheld, dispute.Status, "Status mismatch")
+		assert.Len(t, dispute.Evidence, 1, "Unexpected number of evidence entries")
+		assert.Equal(t, "Org2MSP:arbitrator", dispute.ResolvedBy, "ResolvedBy mismatch")
+
+		// The seller refunds the buyer through an adjusting payment.
+		var adjustment Payment
+		paymentAsBytes, _ := stub.GetState("Payment_" + dispute.AdjustmentPaymentID)
+		err = json.Unmarshal(paymentAsBytes, &adjustment)
+		assert.NoError(t, err, "Error unmarshalling payment")
+		assert.Equal(t, DisputeAdjustment, adjustment.PaymentType, "PaymentType mismatch")
+		assert.Equal(t, int64(3), adjustment.UserID, "UserID mismatch")
+		var detail PaymentDetail
+		detailAsBytes, _ := stub.GetState("PaymentDetail_" + strconv.FormatInt(adjustment.PaymentDetailId, 10))
+		err = json.Unmarshal(detailAsBytes, &detail)
+		assert.NoError(t, err, "Error unmarshalling payment detail")
+		assert.Equal(t, "4", detail.DebitedFrom, "DebitedFrom mismatch")
+		assert.Equal(t, 12.5, detail.BidRefundAmount, "BidRefundAmount mismatch")
+
+		// Settlement resumes.
+		response = stub.MockInvoke("14", payment("P2"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		// Adjustment payment IDs cannot be taken through RecordPayment.
+		response = stub.MockInvoke("15", payment("Dispute_D2"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "must not start with")
+	})
+}
@@ -0,0 +1,1035 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+
+wit"License"); you may not use this file except in compliance the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// SimpleChaincode example simple Chaincode implementation
+type SimpleChaincode struct {
+	contractapi.Contract
+}
+
+// ============================================================================================================================
+// User Definitions - The ledger with user
+// ============================================================================================================================
+
+type User struct {
+	ID            int64        `json:"id"`
+	Category      UserCategory `json:"category"`
+	CreatedOn     int64        `json:"createdOn"`
+	UpdatedOn     int64        `json:"updatedOn"`
+	Location      string       `json:"location,omitempty"` // Deprecated: kept in the owning org's private collection, see UserPII
+	MeterId       string       `json:"meterId,omitempty"`  // Deprecated: kept in the owning org's private collection, see UserPII
+	Source        EnergySource `json:"source"`
+	Identity      string       `json:"identity"` // "<MSP ID>:<common name>" of the client that created the profile
+	OrgMSP        string       `json:"orgMsp"`   // org whose private collection holds the user's PII
+	PIIHash       string       `json:"piiHash"`  // hex SHA-256 of the private UserPII record
+	SchemaVersion int64        `json:"schemaVersion"`
+}
+
+// UserPII holds the personal fields of a User. It lives only in the private data
+// collection of the user's org; the public User record carries its hash.
+type UserPII struct {
+	UserID   int64  `json:"userId"`
+	Location string `json:"location"`
+	MeterId  string `json:"meterId"`
+	Salt     string `json:"salt"` // secret chosen by the caller; defeats dictionary attacks on the public hash
+}
+
+// UserRequest is the JSON payload of UpdateUserProfile. Location and meter ID are
+// personal data and travel in the transient map as a UserPII.
+type UserRequest struct {
+	Category UserCategory `json:"category" payload:"required"`
+	ID       int64        `json:"id" payload:"required"`
+	Source   EnergySource `json:"source" payload:"required"`
+}
+
+// PlatformContract records a user's acceptance of a PlatformTerms version.
+type PlatformContract struct {
+	UserID          int64  `json:"userId"`
+	CreatedOn       int64  `json:"createdOn"`
+	UpdatedOn       int64  `json:"updatedOn"`
+	AcceptedVersion string `json:"acceptedVersion"`
+	DocumentHash    string `json:"documentHash"`
+	SignedBy        string `json:"signedBy"` // "<MSP ID>:<common name>" of the signer
+	Revoked         bool   `json:"revoked"`
+	RevokedOn       int64  `json:"revokedOn"`
+	SchemaVersion   int64  `json:"schemaVersion"`
+}
+
+// PlatformTerms is a published version of the platform terms document, identified by its hash.
+// Users must accept the active version before they can place orders.
+type PlatformTerms struct {
+	CreatedOn     int64  `json:"createdOn"`
+	DocumentHash  string `json:"documentHash"`
+	DocumentURI   string `json:"documentUri"`
+	PublishedBy   string `json:"publishedBy"`
+	SchemaVersion int64  `json:"schemaVersion"`
+	Version       string `json:"version"`
+}
+
+// PlatformTermsRequest is the JSON payload of PublishPlatformTerms.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type PlatformTermsRequest struct {
+	DocumentHash string `json:"documentHash" payload:"required"`
+	DocumentURI  string `json:"documentUri" payload:"required"`
+	Version      string `json:"version" payload:"required"`
+}
+
+// ============================================================================================================================
+// Trading Definitions - The ledger with user
+// ============================================================================================================================
+
+// Order captures the details of an energy buy or sell bid.
+// It includes attributes like total quantity, unit cost, and the total order cost.
+// An order can be filled by several BidMatches; FilledQuantity and RemainingQuantity track
+// how much of TotalQuantity they cover.
+// Struct fields are arranged alphabetically to ensure determinism across languages.
+// Note: While Golang maintains field order when marshaling to JSON, it doesn't auto-sort them.
+type Order struct {
+	BidMatchID        int64           `json:"bidMatchId"`
+	BidStatus         EnergyBidStatus `json:"bidStatus"`
+	CreatedOn         int64           `json:"createdOn"`
+	FilledQuantity    float64         `json:"filledQuantity"`
+	ID                int64           `json:"id"`
+	OnMarketPrice     string          `json:"onMarketPrice"`
+	OrderCost         float64         `json:"orderCost"`
+	PaymentID         int64           `json:"paymentId"`
+	RemainingQuantity float64         `json:"remainingQuantity"`
+	Revision          int64           `json:"revision"`
+	SchemaVersion     int64           `json:"schemaVersion"`
+	SlotID            string          `json:"slotId"`
+	SlotExecDate      int64           `json:"slotExecDate"`
+	TotalQuantity     int64           `json:"totalQuantity"`
+	UnitCost          float64         `json:"unitCost"`
+	UpdatedOn         int64           `json:"updatedOn"`
+	UserAction        Action          `json:"action"`
+	UserID            int64           `json:"userId"`
+}
+
+// OrderRequest is an order as submitted by a user: RegisterOrder takes one as its
+// JSON payload or builds one from its positional arguments, and RegisterOrders
+// takes an array of them. An omitted bidStatus means BidCreated, and an omitted
+// orderCost means totalQuantity times unitCost, the only cost accepted.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type OrderRequest struct {
+	BidMatchID    int64           `json:"bidMatchId"`
+	BidStatus     EnergyBidStatus `json:"bidStatus"`
+	ID            int64           `json:"id" payload:"required"`
+	OrderCost     float64         `json:"orderCost"`
+	PaymentID     int64           `json:"paymentId"`
+	SlotID        string          `json:"slotId" payload:"required"`
+	TotalQuantity int64           `json:"totalQuantity" payload:"required"`
+	UnitCost      float64         `json:"unitCost" payload:"required"`
+	UserAction    Action          `json:"action" payload:"required"`
+	UserID        int64           `json:"userId" payload:"required"`
+}
+
+// AmendOrderRequest is the JSON payload of AmendOrder.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type AmendOrderRequest struct {
+	ID            int64   `json:"id" payload:"required"`
+	TotalQuantity int64   `json:"totalQuantity" payload:"required"`
+	UnitCost      float64 `json:"unitCost" payload:"required"`
+}
+
+// SealedOrder commits a user to an order whose price and quantity stay hidden until
+// the slot's gate closure. RevealOrder checks the cleartext against Commitment and
+// only then registers the Order under the same ID.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type SealedOrder struct {
+	Commitment    string `json:"commitment"` // hex SHA-256 of "<unitCost>:<totalQuantity>:<salt>"
+	CreatedOn     int64  `json:"createdOn"`
+	ID            int64  `json:"id"`
+	Revealed      bool   `json:"revealed"`
+	RevealedOn    int64  `json:"revealedOn"`
+	SchemaVersion int64  `json:"schemaVersion"`
+	SlotID        string `json:"slotId"`
+	UserAction    Action `json:"action"`
+	UserID        int64  `json:"userId"`
+}
+
+// SealedOrderRequest is the JSON payload of CommitOrder.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type SealedOrderRequest struct {
+	Commitment string `json:"commitment" payload:"required"`
+	ID         int64  `json:"id" payload:"required"`
+	SlotID     string `json:"slotId" payload:"required"`
+	UserAction Action `json:"action" payload:"required"`
+	UserID     int64  `json:"userId" payload:"required"`
+}
+
+// SealedBid is the cleartext behind a SealedOrder commitment. It travels in the
+// transient map and, when given at commit time, is kept in the user's org collection.
+type SealedBid struct {
+	Salt          string `json:"salt"`
+	TotalQuantity string `json:"totalQuantity"`
+	UnitCost      string `json:"unitCost"`
+}
+
+// BidMatch records the details of a matched bid in the energy market.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type BidMatch struct {
+	BidMatchTms       int64           `json:"bidMatchTms"`
+	BidSlot           string          `json:"bidSlot"`
+	BidStatus         EnergyBidStatus `json:"bidStatus"`
+	BidUnitPrice      int64           `json:"bidUnitPrice"`
+	BuyerUserId       int64           `json:"buyerUserId"`
+	CO2eKg            float64         `json:"co2eKg"`
+	DeliveredBidUnits float64         `json:"deliveredBidUnits"`
+	EmissionFactor    float64         `json:"emissionFactor"`
+	EmissionSource    *EnergySource   `json:"emissionSource,omitempty"` // seller's source when the match was recorded
+	ID                int64           `json:"id"`
+	OriginalBidUnits  float64         `json:"originalBidUnits"`
+	SchemaVersion     int64           `json:"schemaVersion"`
+	SellerUserId      int64           `json:"sellerUserId"`
+	TransactionBuyID  int64           `json:"transactionBuyId"`
+	TransactionSellID int64           `json:"transactionSellId"`
+}
+
+// BidMatchRequest is the JSON payload of ProcessBidMatch. An omitted bidMatchTms
+// keeps the match's current timestamp.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type BidMatchRequest struct {
+	BidMatchTms       int64           `json:"bidMatchTms"`
+	BidSlot           string          `json:"bidSlot" payload:"required"`
+	BidStatus         EnergyBidStatus `json:"bidStatus" payload:"required"`
+	BidUnitPrice      int64           `json:"bidUnitPrice" payload:"required"`
+	BuyerUserId       int64           `json:"buyerUserId" payload:"required"`
+	DeliveredBidUnits float64         `json:"deliveredBidUnits"`
+	ID                int64           `json:"id" payload:"required"`
+	OriginalBidUnits  float64         `json:"originalBidUnits" payload:"required"`
+	SellerUserId      int64           `json:"sellerUserId" payload:"required"`
+	TransactionBuyID  int64           `json:"transactionBuyId"`
+	TransactionSellID int64           `json:"transactionSellId"`
+}
+
+// Payment logs transaction details for energy market payments.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type Payment struct {
+	BidMatchID      int64       `json:"bidMatchId,omitempty"` // set when the payment settles a BidMatch
+	CreatedOn       int64       `json:"createdOn"`
+	ID              string      `json:"id"`
+	PaymentDetailId int64       `json:"paymentDetail"`
+	PaymentType     PaymentType `json:"paymentType"`
+	SchemaVersion   int64       `json:"schemaVersion"`
+	TotalAmount     float64     `json:"totalAmount"`
+	UserID          int64       `json:"userId"`
+}
+
+// PaymentRequest is the JSON payload of RecordPayment, covering the Payment and
+// its PaymentDetail. The platform fee is not part of it: it is computed from the
+// active FeeSchedule, so payments with a TotalUnitCost need an approved schedule.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type PaymentRequest struct {
+	BidMatchID              int64       `json:"bidMatchId"` // set when the payment settles a BidMatch
+	BidRefundAmount         float64     `json:"bidRefundAmount"`
+	CreditedTo              string      `json:"creditedTo" payload:"required"`
+	DebitedFrom             string      `json:"debitedFrom" payload:"required"`
+	ID                      string      `json:"id" payload:"required"`
+	PaymentType             PaymentType `json:"paymentType" payload:"required"`
+	PenaltyFromSeller       float64     `json:"penaltyFromSeller"`
+	PlatformFeeRefundAmount float64     `json:"platformFeeRefundAmount"`
+	TokenAmount             float64     `json:"tokenAmount"`
+	TotalAmount             float64     `json:"totalAmount" payload:"required"`
+	TotalUnitCost           float64     `json:"totalUnitCost"`
+	UserID                  int64       `json:"userId" payload:"required"`
+}
+
+// PaymentDetail captures more granular transaction information.
+// It includes attributes like the amount refunded, fees applied, and transaction parties.
+// Struct fields are arranged alphabetically for consistent representation.
+type PaymentDetail struct {
+	ID                      int64   `json:"id"`
+	DebitedFrom             string  `json:"debitedFrom"`
+	CreditedTo              string  `json:"creditedTo"`
+	TotalUnitCost           float64 `json:"totalUnitCost"`
+	PlatformFee             float64 `json:"platformFee"`
+	TokenAmount             float64 `json:"tokenAmount"`
+	BidRefundAmount         float64 `json:"bidRefundAmount"`
+	PlatformFeeRefundAmount float64 `json:"platformFeeRefundAmount"`
+	TokenAmountRefund       float64 `json:"tokenAmountRefund"`
+	PenaltyFromSeller       float64 `json:"penaltyFromSeller"`
+	FeeScheduleVersion      int64   `json:"feeScheduleVersion"`
+	SchemaVersion           int64   `json:"schemaVersion"`
+}
+
+// ============================================================================================================================
+// Certificate Definitions - Renewable energy certificates issued against executed trades
+// ============================================================================================================================
+
+// REC is a renewable energy certificate token representing one MWh of energy
+// delivered from a Solar or Wind seller. It is issued to the buyer of the BidMatch
+// that completed the MWh and can be transferred or retired by its holder.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type REC struct {
+	BidMatchID     int64        `json:"bidMatchId"`
+	GeneratorID    int64        `json:"generatorId"`
+	ID             string       `json:"id"`
+	IssuedOn       int64        `json:"issuedOn"`
+	OwnerID        int64        `json:"ownerId"`
+	RetiredOn      int64        `json:"retiredOn"`
+	RetirementNote string       `json:"retirementNote"`
+	SchemaVersion  int64        `json:"schemaVersion"`
+	Source         EnergySource `json:"source"`
+	Status         RECStatus    `json:"status"`
+	UpdatedOn      int64        `json:"updatedOn"`
+	VintageSlot    string       `json:"vintageSlot"`
+}
+
+// RECTransferRequest is the JSON payload of TransferREC.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type RECTransferRequest struct {
+	FromUserID int64  `json:"fromUserId" payload:"required"`
+	ID         string `json:"id" payload:"required"`
+	ToUserID   int64  `json:"toUserId" payload:"required"`
+}
+
+// RECRetireRequest is the JSON payload of RetireREC.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type RECRetireRequest struct {
+	ID     string `json:"id" payload:"required"`
+	Note   string `json:"note" payload:"required"`
+	UserID int64  `json:"userId" payload:"required"`
+}
+
+// RECAccrual carries the renewable kWh a buyer has received from a given source
+// that have not yet added up to a whole MWh certificate.
+type RECAccrual struct {
+	BuyerID       int64        `json:"buyerId"`
+	PendingUnits  float64      `json:"pendingUnits"`
+	SchemaVersion int64        `json:"schemaVersion"`
+	Source        EnergySource `json:"source"`
+	UpdatedOn     int64        `json:"updatedOn"`
+}
+
+// RECIssuance is the renewable kWh of a BidMatch credited to its buyer's accrual and
+// the certificates minted for it, so a later delivery is credited only for the difference.
+type RECIssuance struct {
+	BidMatchID    int64        `json:"bidMatchId"`
+	BuyerID       int64        `json:"buyerId"`
+	Certificates  int64        `json:"certificates"`
+	SchemaVersion int64        `json:"schemaVersion"`
+	Source        EnergySource `json:"source"`
+	Units         float64      `json:"units"`
+}
+
+// ============================================================================================================================
+// Emission Definitions - Carbon intensity of each energy source
+// ============================================================================================================================
+
+// EmissionFactor is the configured carbon intensity of an EnergySource in kg CO2e per kWh.
+// Executed BidMatches record the factor of the seller's source alongside the computed CO2e.
+type EmissionFactor struct {
+	KgCO2ePerKWh  float64      `json:"kgCo2ePerKwh"`
+	SchemaVersion int64        `json:"schemaVersion"`
+	Source        EnergySource `json:"source"`
+	UpdatedOn     int64        `json:"updatedOn"`
+}
+
+// EmissionFactorRequest is the JSON payload of SetEmissionFactor.
+type EmissionFactorRequest struct {
+	KgCO2ePerKWh float64      `json:"kgCo2ePerKwh" payload:"required"`
+	Source       EnergySource `json:"source" payload:"required"`
+}
+
+// ============================================================================================================================
+// Market Price Definitions - Reference prices published by the market oracle
+// ============================================================================================================================
+
+// MarketPrice is the reference unit price of a trading slot as published by an
+// authorised oracle. RegisterOrder takes the order's OnMarketPrice from here.
+type MarketPrice struct {
+	Price         float64 `json:"price"`
+	PublishedBy   string  `json:"publishedBy"`
+	SchemaVersion int64   `json:"schemaVersion"`
+	SlotID        string  `json:"slotId"`
+	Source        string  `json:"source"`
+	Timestamp     int64   `json:"timestamp"`
+	UpdatedOn     int64   `json:"updatedOn"`
+}
+
+// MarketPriceRequest is the JSON payload of PublishMarketPrice.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type MarketPriceRequest struct {
+	Price     float64 `json:"price" payload:"required"`
+	SlotID    string  `json:"slotId" payload:"required"`
+	Source    string  `json:"source" payload:"required"`
+	Timestamp int64   `json:"timestamp" payload:"required"`
+}
+
+// MarketOracleConfig lists the identities allowed to publish MarketPrices, as
+// "<MSP ID>:<certificate common name>", and the band in percent around the
+// published price that order unit costs must fall within.
+type MarketOracleConfig struct {
+	Oracles       []string `json:"oracles"`
+	PriceBandPct  float64  `json:"priceBandPct"`
+	SchemaVersion int64    `json:"schemaVersion"`
+	UpdatedOn     int64    `json:"updatedOn"`
+}
+
+// MarketOracleConfigRequest is the JSON payload of SetMarketOracleConfig.
+type MarketOracleConfigRequest struct {
+	Oracles      []string `json:"oracles" payload:"required"`
+	PriceBandPct float64  `json:"priceBandPct" payload:"required"`
+}
+
+// ============================================================================================================================
+// Slot Definitions - The trading calendar orders are placed against
+// ============================================================================================================================
+
+// TradingSlot is a delivery interval of the market. Orders can only be placed
+// while the slot is Open and the transaction timestamp is before GateClosure.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type TradingSlot struct {
+	CreatedOn     int64      `json:"createdOn"`
+	EndTime       int64      `json:"endTime"`
+	GateClosure   int64      `json:"gateClosure"`
+	ID            string     `json:"id"`
+	SchemaVersion int64      `json:"schemaVersion"`
+	StartTime     int64      `json:"startTime"`
+	Status        SlotStatus `json:"status"`
+	UpdatedOn     int64      `json:"updatedOn"`
+}
+
+// TradingSlotRequest is the JSON payload of CreateTradingSlot.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type TradingSlotRequest struct {
+	EndTime     int64  `json:"endTime" payload:"required"`
+	GateClosure int64  `json:"gateClosure" payload:"required"`
+	ID          string `json:"id" payload:"required"`
+	StartTime   int64  `json:"startTime" payload:"required"`
+}
+
+// TradingSlotsRequest is the JSON payload of GenerateTradingSlots.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type TradingSlotsRequest struct {
+	Count              int64 `json:"count" payload:"required"`
+	DurationSec        int64 `json:"durationSec" payload:"required"`
+	FirstStart         int64 `json:"firstStart" payload:"required"`
+	GateClosureLeadSec int64 `json:"gateClosureLeadSec"`
+}
+
+// TradingSlotStatusRequest is the JSON payload of UpdateTradingSlotStatus.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type TradingSlotStatusRequest struct {
+	ID     string     `json:"id" payload:"required"`
+	Status SlotStatus `json:"status" payload:"required"`
+}
+
+// ============================================================================================================================
+// Fee Definitions - Platform fee schedule applied by RecordPayment
+// ============================================================================================================================
+
+// FeeSchedule is a versioned set of platform fee rules. A proposed version only
+// takes effect once the platform admin org approves it.
+// The percentage applied is the highest volume tier the user has reached this
+// month, otherwise the user's category rate, otherwise PercentFee. The fee is
+// that percentage of the payment's unit cost plus FlatFee, and at least MinimumFee.
+type FeeSchedule struct {
+	ApprovedBy    string            `json:"approvedBy"`
+	ApprovedOn    int64             `json:"approvedOn"`
+	CategoryRates []FeeCategoryRate `json:"categoryRates"`
+	FlatFee       float64           `json:"flatFee"`
+	MinimumFee    float64           `json:"minimumFee"`
+	PercentFee    float64           `json:"percentFee"`
+	ProposedBy    string            `json:"proposedBy"`
+	ProposedOn    int64             `json:"proposedOn"`
+	SchemaVersion int64             `json:"schemaVersion"`
+	Status        FeeScheduleStatus `json:"status"`
+	Tiers         []FeeTier         `json:"tiers"`
+	Version       int64             `json:"version"`
+}
+
+// FeeScheduleRequest is the JSON payload of ProposeFeeSchedule.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type FeeScheduleRequest struct {
+	CategoryRates []FeeCategoryRate `json:"categoryRates"`
+	FlatFee       float64           `json:"flatFee"`
+	MinimumFee    float64           `json:"minimumFee"`
+	PercentFee    float64           `json:"percentFee"`
+	Tiers         []FeeTier         `json:"tiers"`
+}
+
+// FeeCategoryRate is the percentage fee for one UserCategory.
+type FeeCategoryRate struct {
+	Category   UserCategory `json:"category"`
+	PercentFee float64      `json:"percentFee"`
+}
+
+// FeeTier is the percentage fee once a user has traded MinMonthlyVolume kWh in the month.
+type FeeTier struct {
+	MinMonthlyVolume float64 `json:"minMonthlyVolume"`
+	PercentFee       float64 `json:"percentFee"`
+}
+
+// MonthlyVolume is the executed kWh a user bought or sold in a calendar month (YYYYMM, UTC).
+type MonthlyVolume struct {
+	Month         string  `json:"month"`
+	SchemaVersion int64   `json:"schemaVersion"`
+	UserID        int64   `json:"userId"`
+	Volume        float64 `json:"volume"`
+}
+
+// VolumeContribution is what an executed BidMatch adds to its parties' MonthlyVolume,
+// kept so that the match is counted once and can be taken back.
+type VolumeContribution struct {
+	BidMatchID    int64   `json:"bidMatchId"`
+	Month         string  `json:"month"`
+	SchemaVersion int64   `json:"schemaVersion"`
+	UserIDs       []int64 `json:"userIds"`
+	Volume        float64 `json:"volume"`
+}
+
+// ============================================================================================================================
+// Settlement Definitions - Batch settlement of a slot's executed BidMatches
+// ============================================================================================================================
+
+// SettlementConfig controls how SettleSlot turns executed BidMatches into payments.
+type SettlementConfig struct {
+	NetPerUser    bool    `json:"netPerUser"` // one payment per user instead of one per side of each match
+	PenaltyPct    float64 `json:"penaltyPct"` // share of the undelivered value charged to the seller
+	SchemaVersion int64   `json:"schemaVersion"`
+	UpdatedOn     int64   `json:"updatedOn"`
+}
+
+// SettlementConfigRequest is the JSON payload of SetSettlementConfig.
+type SettlementConfigRequest struct {
+	NetPerUser bool    `json:"netPerUser" payload:"required"`
+	PenaltyPct float64 `json:"penaltyPct" payload:"required"`
+}
+
+// SlotSettlement records what SettleSlot wrote for a slot, over every round. Matches
+// under an open dispute are left pending for a later round.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type SlotSettlement struct {
+	BidMatchIDs        []int64  `json:"bidMatchIds"`
+	NetPerUser         bool     `json:"netPerUser"`
+	PaymentIDs         []string `json:"paymentIds"`
+	PendingBidMatchIDs []int64  `json:"pendingBidMatchIds"`
+	Rounds             int64    `json:"rounds"`
+	SchemaVersion      int64    `json:"schemaVersion"`
+	SettledBy          string   `json:"settledBy"`
+	SettledOn          int64    `json:"settledOn"`
+	SlotID             string   `json:"slotId"`
+	TotalFees          float64  `json:"totalFees"`
+	TotalPenalties     float64  `json:"totalPenalties"`
+	TotalValue         float64  `json:"totalValue"`
+}
+
+// ============================================================================================================================
+// Dispute Definitions - Contested deliveries and penalties on a BidMatch
+// ============================================================================================================================
+
+// Dispute is raised by the buyer or seller of a BidMatch. While it is open the
+// match cannot be updated or paid; resolving it may record an adjusting Payment.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type Dispute struct {
+	AdjustmentAmount    float64           `json:"adjustmentAmount"`
+	AdjustmentPaymentID string            `json:"adjustmentPaymentId,omitempty"`
+	BidMatchID          int64             `json:"bidMatchId"`
+	CreatedOn           int64             `json:"createdOn"`
+	Evidence            []DisputeEvidence `json:"evidence"`
+	ID                  string            `json:"id"`
+	OpenedBy            int64             `json:"openedBy"`
+	Reason              string            `json:"reason"`
+	Resolution          string            `json:"resolution"`
+	ResolvedBy          string            `json:"resolvedBy"`
+	ResolvedOn          int64             `json:"resolvedOn"`
+	SchemaVersion       int64             `json:"schemaVersion"`
+	Status              DisputeStatus     `json:"status"`
+	Type                DisputeType       `json:"type"`
+	UpdatedOn           int64             `json:"updatedOn"`
+}
+
+// DisputeRequest is the JSON payload of OpenDispute.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type DisputeRequest struct {
+	BidMatchID int64       `json:"bidMatchId" payload:"required"`
+	ID         string      `json:"id" payload:"required"`
+	Reason     string      `json:"reason" payload:"required"`
+	Type       DisputeType `json:"type" payload:"required"`
+	UserID     int64       `json:"userId" payload:"required"`
+}
+
+// EvidenceRequest is the JSON payload of SubmitEvidence.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type EvidenceRequest struct {
+	Description  string `json:"description" payload:"required"`
+	DisputeID    string `json:"disputeId" payload:"required"`
+	DocumentHash string `json:"documentHash" payload:"required"`
+	UserID       int64  `json:"userId" payload:"required"`
+}
+
+// DisputeResolutionRequest is the JSON payload of ResolveDispute. Outcome is
+// Upheld or Rejected; only an upheld dispute can carry an adjustment.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type DisputeResolutionRequest struct {
+	AdjustmentAmount float64       `json:"adjustmentAmount"`
+	ID               string        `json:"id" payload:"required"`
+	Outcome          DisputeStatus `json:"outcome" payload:"required"`
+	Resolution       string        `json:"resolution" payload:"required"`
+}
+
+// DisputeEvidence references a supporting document by its hash; the document itself stays off-chain.
+type DisputeEvidence struct {
+	Description  string `json:"description"`
+	DocumentHash string `json:"documentHash"`
+	SubmittedBy  int64  `json:"submittedBy"`
+	SubmittedOn  int64  `json:"submittedOn"`
+}
+
+// DisputeArbitratorConfig lists the identities, besides platform admins, allowed to resolve disputes.
+type DisputeArbitratorConfig struct {
+	Arbitrators   []string `json:"arbitrators"` // "<MSP ID>:<common name>"
+	SchemaVersion int64    `json:"schemaVersion"`
+	UpdatedOn     int64    `json:"updatedOn"`
+}
+
+// OrderAggregatorConfig lists the identities allowed to batch orders on behalf of other users.
+type OrderAggregatorConfig struct {
+	Aggregators   []string `json:"aggregators"` // "<MSP ID>:<common name>"
+	SchemaVersion int64    `json:"schemaVersion"`
+	UpdatedOn     int64    `json:"updatedOn"`
+}
+
+// ============================================================================================================================
+// Prefix Definitions - For creating composite keys and avoid id overlap (for future use)
+// ============================================================================================================================
+
+const BuyBidPrefix = "BuyBid"
+const SellBidPrefix = "SellBid"
+
+// Index prefixes for looking up certificates by holder and by vintage slot
+const RECOwnerIndex = "REC~owner~id"
+const RECVintageIndex = "REC~vintage~id"
+
+// Index prefix listing the BidMatches that fill an order
+const OrderFillIndex = "Order~fill~bidMatch"
+
+// Index prefix listing the BidMatches of a slot
+const BidMatchSlotIndex = "BidMatch~slot~id"
+
+// ============================================================================================================================
+// Enum Definitions - Absolute states of allowed status for different assets (WIP)
+// ============================================================================================================================
+
+type EnergyBidStatus int64
+type EnergySource int64
+type Action int64
+type UserCategory int64
+type PaymentType int64
+type RECStatus int64
+type SlotStatus int64
+type FeeScheduleStatus int64
+type DisputeStatus int64
+type DisputeType int64
+
+const (
+	BidCreated         EnergyBidStatus = iota // = 0
+	BidAccepted                               // = 1
+	BidRejected                               // = 2
+	BidExecuted                               // = 3
+	BidTerminated                             // = 4
+	BidCancelled                              // = 5
+	BidPartiallyFilled                        // = 6
+	BidFilled                                 // = 7
+)
+
+var (
+	energyBidMap = map[string]EnergyBidStatus{
+		"BidCreated":         BidCreated,
+		"BidAccepted":        BidAccepted,
+		"BidRejected":        BidRejected,
+		"BidExecuted":        BidExecuted,
+		"BidTerminated":      BidTerminated,
+		"BidCancelled":       BidCancelled,
+		"BidPartiallyFilled": BidPartiallyFilled,
+		"BidFilled":          BidFilled,
+	}
+	energyBidStatusNames = []string{"BidCreated", "BidAccepted", "BidRejected", "BidExecuted", "BidTerminated", "BidCancelled", "BidPartiallyFilled", "BidFilled"}
+)
+
+func EnergyBidStatusString(status EnergyBidStatus) string {
+	return status.String()
+}
+
+const (
+	Solar   EnergySource = iota // = 0
+	Wind                        // = 1
+	DGSet                       // = 2
+	Battery                     // = 3
+)
+
+var (
+	energySourceMap = map[string]EnergySource{
+		"Solar":   Solar,
+		"Wind":    Wind,
+		"DG Set":  DGSet,
+		"Battery": Battery,
+	}
+	energySourceNames = []string{"Solar", "Wind", "DG Set", "Battery"}
+)
+
+func EnergySourceString(status EnergySource) string {
+	return status.String()
+}
+
+const (
+	Buy  Action = iota // = 0
+	Sell               // = 1
+)
+
+var (
+	actionMap = map[string]Action{
+		"Buy":  Buy,
+		"Sell": Sell,
+	}
+	actionNames = []string{"Buy", "Sell"}
+)
+
+func ActionString(status Action) string {
+	return status.String()
+}
+
+const (
+	Prosumer UserCategory = iota // = 0
+	Consumer                     // = 1
+)
+
+var (
+	userCategoryMap = map[string]UserCategory{
+		"Prosumer": Prosumer,
+		"Consumer": Consumer,
+	}
+	userCategoryNames = []string{"Prosumer", "Consumer"}
+)
+
+func UserCategoryString(status UserCategory) string {
+	return status.String()
+}
+
+const (
+	WalletRecharge              PaymentType = iota // = 0
+	SellerTokenAmount                              // = 1
+	BuyerEnergyPurchased                           // = 2
+	BuyerSellerIncentive                           // = 3
+	SellerEnergySoldTokenRefund                    // = 4
+	DisputeAdjustment                              // = 5
+)
+
+var (
+	paymentTypeMap = map[string]PaymentType{
+		"WalletRecharge":                         WalletRecharge,
+		"Seller - Token Amount":                  SellerTokenAmount,
+		"Buyer - Energy Purchased":               BuyerEnergyPurchased,
+		"Buyer/Seller - Incentive":               BuyerSellerIncentive,
+		"Seller - Energy Sold plus Token Refund": SellerEnergySoldTokenRefund,
+		"Dispute - Adjustment":                   DisputeAdjustment,
+	}
+	paymentTypeNames = []string{"WalletRecharge", "Seller - Token Amount", "Buyer - Energy Purchased", "Buyer/Seller - Incentive", "Seller - Energy Sold plus Token Refund", "Dispute - Adjustment"}
+)
+
+func PaymentTypeString(status PaymentType) string {
+	return status.String()
+}
+
+const (
+	RECActive  RECStatus = iota // = 0
+	RECRetired                  // = 1
+)
+
+var (
+	recStatusMap = map[string]RECStatus{
+		"Active":  RECActive,
+		"Retired": RECRetired,
+	}
+	recStatusNames = []string{"Active", "Retired"}
+)
+
+func RECStatusString(status RECStatus) string {
+	return status.String()
+}
+
+const (
+	SlotOpen    SlotStatus = iota // = 0
+	SlotClosed                    // = 1
+	SlotMatched                   // = 2
+	SlotSettled                   // = 3
+)
+
+var (
+	slotStatusMap = map[string]SlotStatus{
+		"Open":    SlotOpen,
+		"Closed":  SlotClosed,
+		"Matched": SlotMatched,
+		"Settled": SlotSettled,
+	}
+	slotStatusNames = []string{"Open", "Closed", "Matched", "Settled"}
+)
+
+func SlotStatusString(status SlotStatus) string {
+	return status.String()
+}
+
+const (
+	FeeScheduleProposed   FeeScheduleStatus = iota // = 0
+	FeeScheduleActive                              // = 1
+	FeeScheduleSuperseded                          // = 2
+)
+
+var (
+	feeScheduleStatusMap = map[string]FeeScheduleStatus{
+		"Proposed":   FeeScheduleProposed,
+		"Active":     FeeScheduleActive,
+		"Superseded": FeeScheduleSuperseded,
+	}
+	feeScheduleStatusNames = []string{"Proposed", "Active", "Superseded"}
+)
+
+func FeeScheduleStatusString(status FeeScheduleStatus) string {
+	return status.String()
+}
+
+const (
+	DisputeOpen     DisputeStatus = iota // = 0
+	DisputeUpheld                        // = 1
+	DisputeRejected                      // = 2
+)
+
+var (
+	disputeStatusMap = map[string]DisputeStatus{
+		"Open":     DisputeOpen,
+		"Upheld":   DisputeUpheld,
+		"Rejected": DisputeRejected,
+	}
+	disputeStatusNames = []string{"Open", "Upheld", "Rejected"}
+)
+
+func DisputeStatusString(status DisputeStatus) string {
+	return status.String()
+}
+
+const (
+	DeliveredUnitsDispute DisputeType = iota // = 0, raised by the buyer
+	PenaltyDispute                           // = 1, raised by the seller
+)
+
+var (
+	disputeTypeMap = map[string]DisputeType{
+		"DeliveredUnits": DeliveredUnitsDispute,
+		"Penalty":        PenaltyDispute,
+	}
+	disputeTypeNames = []string{"DeliveredUnits", "Penalty"}
+)
+
+func DisputeTypeString(status DisputeType) string {
+	return status.String()
+}
+
+// ============================================================================================================================
+// Start - runs the chaincode: as an external service when CHAINCODE_SERVER_ADDRESS is set, otherwise by dialling the peer
+// ============================================================================================================================
+func Start() error {
+	config, external, err := serverConfigFromEnv()
+	if err == nil {
+		if external {
+			err = runChaincodeServer(config, new(SimpleChaincode))
+		} else {
+			err = shim.Start(new(SimpleChaincode))
+		}
+	}
+	if err != nil {
+		writeLogEntry(levelError, "Error starting Simple chaincode", logFields{"error": err.Error()})
+	}
+	return err
+}
+
+// ============================================================================================================================
+// Init - initialize the chaincode (needed for interface) - returning empty success response
+// ============================================================================================================================
+func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+// ============================================================================================================================
+// Invoke - Our entry point for Invocations
+// ============================================================================================================================
+func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	logger := newTxLogger(stub)
+	logger.Debug("starting invoke", nil)
+
+	start := time.Now()
+	events := newEventStub(stub)
+	response := invokeFunction(events)
+	if response.Status < shim.ERRORTHRESHOLD {
+		err := events.emit()
+		if err != nil {
+			response = errorResponse(errcode.Internal, "Could not set asset event: "+err.Error())
+		}
+	}
+	logger.Response(response, time.Since(start))
+	return response
+}
+
+// invokeFunction dispatches the transaction to the function it names.
+func invokeFunction(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+
+	// Handle different functions
+	if function == "UpdateUserProfile" {
+		return UpdateUserProfile(stub, args)
+	} else if function == "BindUserIdentity" {
+		return BindUserIdentity(stub, args)
+	} else if function == "SignPlatformContract" {
+		return SignPlatformContract(stub, args)
+	} else if function == "RecordPayment" {
+		return RecordPayment(stub, args)
+	} else if function == "RegisterOrder" {
+		return RegisterOrder(stub, args)
+	} else if function == "RegisterOrders" {
+		return RegisterOrders(stub, args)
+	} else if function == "SetOrderAggregators" {
+		return SetOrderAggregators(stub, args)
+	} else if function == "CommitOrder" {
+		return CommitOrder(stub, args)
+	} else if function == "RevealOrder" {
+		return RevealOrder(stub, args)
+	} else if function == "ReadSealedOrder" {
+		return ReadSealedOrder(stub, args)
+	} else if function == "SettleSlot" {
+		return SettleSlot(stub, args)
+	} else if function == "SetSettlementConfig" {
+		return SetSettlementConfig(stub, args)
+	} else if function == "ReadSlotSettlement" {
+		return ReadSlotSettlement(stub, args)
+	} else if function == "GenerateStatement" {
+		return GenerateStatement(stub, args)
+	} else if function == "QuerySlotStatistics" {
+		return QuerySlotStatistics(stub, args)
+	} else if function == "QuerySlotStatisticsByRange" {
+		return QuerySlotStatisticsByRange(stub, args)
+	} else if function == "MigrateAssets" {
+		return MigrateAssets(stub, args)
+	} else if function == "ReadAsset" {
+		return ReadAsset(stub, args)
+	} else if function == "ReadAssetHistory" {
+		return ReadAssetHistory(stub, args)
+	} else if function == "QueryAssets" {
+		return QueryAssets(stub, args)
+	} else if function == "OpenDispute" {
+		return OpenDispute(stub, args)
+	} else if function == "SubmitEvidence" {
+		return SubmitEvidence(stub, args)
+	} else if function == "ResolveDispute" {
+		return ResolveDispute(stub, args)
+	} else if function == "SetDisputeArbitrators" {
+		return SetDisputeArbitrators(stub, args)
+	} else if function == "ReadDispute" {
+		return ReadDispute(stub, args)
+	} else if function == "CancelOrder" {
+		return CancelOrder(stub, args)
+	} else if function == "AmendOrder" {
+		return AmendOrder(stub, args)
+	} else if function == "ProcessBidMatch" {
+		return ProcessBidMatch(stub, args)
+	} else if function == "ReadUserProfile" {
+		return ReadUserProfile(stub, args)
+	} else if function == "ReadUserPII" {
+		return ReadUserPII(stub, args)
+	} else if function == "ReadPlatformContract" {
+		return ReadPlatformContract(stub, args)
+	} else if function == "RevokePlatformContract" {
+		return RevokePlatformContract(stub, args)
+	} else if function == "PublishPlatformTerms" {
+		return PublishPlatformTerms(stub, args)
+	} else if function == "ReadPlatformTerms" {
+		return ReadPlatformTerms(stub, args)
+	} else if function == "ReadPayment" {
+		return ReadPayment(stub, args)
+	} else if function == "ReadPaymentDetail" {
+		return ReadPaymentDetail(stub, args)
+	} else if function == "ReadOrder" {
+		return ReadOrder(stub, args)
+	} else if function == "ReadBidMatch" {
+		return ReadBidMatch(stub, args)
+	} else if function == "ReadOrderFills" {
+		return ReadOrderFills(stub, args)
+	} else if function == "TransferREC" {
+		return TransferREC(stub, args)
+	} else if function == "RetireREC" {
+		return RetireREC(stub, args)
+	} else if function == "ReadREC" {
+		return ReadREC(stub, args)
+	} else if function == "QueryRECsByOwner" {
+		return QueryRECsByOwner(stub, args)
+	} else if function == "QueryRECsByVintage" {
+		return QueryRECsByVintage(stub, args)
+	} else if function == "SetEmissionFactor" {
+		return SetEmissionFactor(stub, args)
+	} else if function == "ReadEmissionFactor" {
+		return ReadEmissionFactor(stub, args)
+	} else if function == "QueryEmissionsSummary" {
+		return QueryEmissionsSummary(stub, args)
+	} else if function == "SetMarketOracleConfig" {
+		return SetMarketOracleConfig(stub, args)
+	} else if function == "ReadMarketOracleConfig" {
+		return ReadMarketOracleConfig(stub, args)
+	} else if function == "PublishMarketPrice" {
+		return PublishMarketPrice(stub, args)
+	} else if function == "ReadMarketPrice" {
+		return ReadMarketPrice(stub, args)
+	} else if function == "ProposeFeeSchedule" {
+		return ProposeFeeSchedule(stub, args)
+	} else if function == "ApproveFeeSchedule" {
+		return ApproveFeeSchedule(stub, args)
+	} else if function == "ReadFeeSchedule" {
+		return ReadFeeSchedule(stub, args)
+	} else if function == "CreateTradingSlot" {
+		return CreateTradingSlot(stub, args)
+	} else if function == "GenerateTradingSlots" {
+		return GenerateTradingSlots(stub, args)
+	} else if function == "UpdateTradingSlotStatus" {
+		return UpdateTradingSlotStatus(stub, args)
+	} else if function == "ReadTradingSlot" {
+		return ReadTradingSlot(stub, args)
+	} else if function == "QueryTradingSlots" {
+		return QueryTradingSlots(stub, args)
+	}
+
+	// error out
+	return errorResponse(errcode.InvalidArgument, "Received unknown invoke function name - '"+function+"'")
+}
+
+// ============================================================================================================================
+// Query - legacy function (needed for interface)
+// ============================================================================================================================
+func (t *SimpleChaincode) Query(stub shim.ChaincodeStubInterface) pb.Response {
+	return errorResponse(errcode.InvalidArgument, "Unknown supported call - Query()")
+}
@@ -0,0 +1,1001 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// setCreator makes the stub's transactions come from an identity with the given MSP and common name.
+func setCreator(t *testing.T, stub *shimtest.MockStub, mspID string, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err.Error())
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal identity: %s", err.Error())
+	}
+	stub.Creator = creator
+}
+
+// seedTradingSlot stores an Open slot whose gate closes an hour from now.
+func seedTradingSlot(t *testing.T, stub *shimtest.MockStub, slotID string) {
+	gateClosure := time.Now().Add(time.Hour).Unix()
+	slotAsBytes, _ := json.Marshal(TradingSlot{ID: slotID, GateClosure: gateClosure, StartTime: gateClosure, EndTime: gateClosure + 900})
+	stub.MockTransactionStart("seedTradingSlot")
+	defer stub.MockTransactionEnd("seedTradingSlot")
+	err := stub.PutState("TradingSlot_"+slotID, slotAsBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the trading slot into the stub: %s", err.Error())
+	}
+}
+
+// seedUser stores a user bound to the given client identity without going through UpdateUserProfile.
+func seedUser(t *testing.T, stub *shimtest.MockStub, userID int64, identity string) {
+	userAsBytes, _ := json.Marshal(User{ID: userID, Identity: identity})
+	stub.MockTransactionStart("seedUser")
+	defer stub.MockTransactionEnd("seedUser")
+	err := stub.PutState(strconv.FormatInt(userID, 10), userAsBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the user into the stub: %s", err.Error())
+	}
+}
+
+// registerUser creates a user through UpdateUserProfile from (userID, category, location, meterId, source),
+// passing the PII and a salt in the transient map.
+func registerUser(t *testing.T, stub *shimtest.MockStub, user []string) {
+	piiAsBytes, _ := json.Marshal(UserPII{Location: user[2], MeterId: user[3], Salt: "secret-salt-of-user-" + user[0]})
+	stub.TransientMap = map[string][]byte{userPIITransientKey: piiAsBytes}
+	defer func() { stub.TransientMap = nil }()
+	response := stub.MockInvoke("registerUser", [][]byte{[]byte("UpdateUserProfile"), []byte(user[0]), []byte(user[1]), []byte(user[4])})
+	if response.GetStatus() != shim.OK {
+		t.Fatalf("Failed to register user %s: %s", user[0], response.GetMessage())
+	}
+}
+
+// seedMarketPrice stores an oracle price for a slot without going through PublishMarketPrice.
+func seedMarketPrice(t *testing.T, stub *shimtest.MockStub, slotID string, price float64) {
+	marketPriceAsBytes, _ := json.Marshal(MarketPrice{Price: price, SlotID: slotID, Source: "test"})
+	stub.MockTransactionStart("seedMarketPrice")
+	defer stub.MockTransactionEnd("seedMarketPrice")
+	err := stub.PutState("MarketPrice_"+slotID, marketPriceAsBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the market price into the stub: %s", err.Error())
+	}
+}
+
+// seedOrder stores an order without going through RegisterOrder, so matches have orders to fill.
+func seedOrder(t *testing.T, stub *shimtest.MockStub, order Order) {
+	orderAsBytes, _ := json.Marshal(order)
+	stub.MockTransactionStart("seedOrder")
+	defer stub.MockTransactionEnd("seedOrder")
+	err := stub.PutState("Order_"+strconv.FormatInt(order.ID, 10), orderAsBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the order into the stub: %s", err.Error())
+	}
+}
+
+// invokeAsAdmin runs a transaction as the platform admin, then restores the previous caller.
+func invokeAsAdmin(t *testing.T, stub *shimtest.MockStub, uuid string, args [][]byte) pb.Response {
+	creator := stub.Creator
+	defer func() { stub.Creator = creator }()
+	setCreator(t, stub, "Org1MSP", "admin")
+	return stub.MockInvoke(uuid, args)
+}
+
+func TestWriteRefused(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+
+	// Raw key writes would bypass every check of the asset functions, even for the platform admin.
+	response := stub.MockInvoke("1", [][]byte{[]byte("Write"), []byte("FeeScheduleActiveVersion"), []byte("7")})
+	assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+	assert.Contains(t, response.GetMessage(), "unknown invoke function")
+
+	value, err := stub.GetState("FeeScheduleActiveVersion")
+	assert.NoError(t, err, "Error getting value from ledger")
+	assert.Nil(t, value, "Key written through Write")
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org2MSP", "user1")
+	t.Setenv("CORE_PEER_LOCALMSPID", "Org2MSP")
+
+	// Test Case 1: Successfully Update User Profile
+	t.Run("Successfully Update User Profile", func(t *testing.T) {
+		registerUser(t, stub, []string{"1", "Prosumer", "Location 1", "MeterId 1", "Solar"})
+
+		// Check if the user is stored in the ledger
+		userAsBytes, err := stub.GetState("1")
+		assert.NoError(t, err, "Error getting value from ledger")
+
+		var user User
+		err = json.Unmarshal(userAsBytes, &user)
+		assert.NoError(t, err, "Error unmarshalling user")
+		assert.Empty(t, user.Location, "Location leaked to the public state")
+		assert.Equal(t, "Org2MSP", user.OrgMSP, "OrgMSP mismatch")
+
+		// The PII sits in the org's collection, salted with the caller's secret, and matches the public hash
+		var pii UserPII
+		err = json.Unmarshal(stub.PvtState["Org2MSPPrivateCollection"]["1"], &pii)
+		assert.NoError(t, err, "Error unmarshalling user PII")
+		assert.Equal(t, "Location 1", pii.Location, "Incorrect value retrieved from private data")
+		assert.Equal(t, "secret-salt-of-user-1", pii.Salt, "Salt mismatch")
+		assert.Equal(t, hashUserPII(pii), user.PIIHash, "PIIHash mismatch")
+	})
+
+	// Test Case 2: Incorrect Number of Arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("UpdateUserProfile"),
+			[]byte("1"),
+			[]byte("Prosumer"),
+		})
+
+		// Assert the function did not complete successfully
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 3: Invalid User ID
+	t.Run("Invalid User ID", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{
+			[]byte("UpdateUserProfile"),
+			[]byte("InvalidID"),
+			[]byte("Prosumer"),
+			[]byte("Solar"),
+		})
+
+		// Assert the function did not complete successfully
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 4: Invalid User Category
+	t.Run("Invalid User Category", func(t *testing.T) {
+		response := stub.MockInvoke("4", [][]byte{
+			[]byte("UpdateUserProfile"),
+			[]byte("2"),
+			[]byte("InvalidCategory"),
+			[]byte("Solar"),
+		})
+
+		// Assert the function did not complete successfully
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 5: Invalid Energy Source
+	t.Run("Invalid Energy Source", func(t *testing.T) {
+		response := stub.MockInvoke("5", [][]byte{
+			[]byte("UpdateUserProfile"),
+			[]byte("3"),
+			[]byte("Prosumer"),
+			[]byte("InvalidSource"),
+		})
+
+		// Assert the function did not complete successfully
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 6: PII is read back through the owning org's peer
+	t.Run("Read User PII", func(t *testing.T) {
+		registerUser(t, stub, []string{"9", "Consumer", "Location 9", "MeterId 9", "Battery"})
+
+		response := stub.MockInvoke("6", [][]byte{[]byte("ReadUserPII"), []byte("9")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var pii UserPII
+		err := json.Unmarshal(response.GetPayload(), &pii)
+		assert.NoError(t, err, "Error unmarshalling user PII")
+		assert.Equal(t, "MeterId 9", pii.MeterId, "MeterId mismatch")
+	})
+
+	// Test Case 7: Other orgs can neither read nor overwrite the PII
+	t.Run("Collection Membership Enforced", func(t *testing.T) {
+		setCreator(t, stub, "Org1MSP", "admin")
+		defer setCreator(t, stub, "Org2MSP", "user1")
+
+		response := stub.MockInvoke("8", [][]byte{[]byte("ReadUserPII"), []byte("9")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+
+		t.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+		response = stub.MockInvoke("9", [][]byte{[]byte("ReadUserPII"), []byte("9")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not a member of Org2MSPPrivateCollection")
+
+		stub.TransientMap = map[string][]byte{userPIITransientKey: []byte(`{"location":"Location X","meterId":"MeterId X","salt":"secret-salt-of-user-X"}`)}
+		defer func() { stub.TransientMap = nil }()
+		response = stub.MockInvoke("10", [][]byte{[]byte("UpdateUserProfile"), []byte("9"), []byte("Consumer"), []byte("Battery")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "owned by Org2MSP")
+	})
+
+	// Test Case 8: PII is never taken from the public arguments, and needs a secret salt
+	t.Run("PII Only In Transient With Salt", func(t *testing.T) {
+		response := stub.MockInvoke("11", [][]byte{[]byte("UpdateUserProfile"), []byte("10"), []byte("Consumer"),
+			[]byte("Location 10"), []byte("MeterId 10"), []byte("Battery")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Incorrect number of arguments")
+
+		stub.TransientMap = map[string][]byte{userPIITransientKey: []byte(`{"location":"Location 10","meterId":"MeterId 10"}`)}
+		defer func() { stub.TransientMap = nil }()
+		response = stub.MockInvoke("12", [][]byte{[]byte("UpdateUserProfile"), []byte("10"), []byte("Consumer"), []byte("Battery")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "secret salt")
+	})
+
+	// Test Case 9: Only the platform admin adopts a profile written before PII moved off-chain
+	t.Run("Legacy Profile Adopted By Admin", func(t *testing.T) {
+		legacyAsBytes, _ := json.Marshal(User{ID: 11, Category: Consumer, Location: "Location 11", MeterId: "MeterId 11", Source: Battery})
+		stub.MockTransactionStart("seedLegacyUser")
+		_ = stub.PutState("11", legacyAsBytes)
+		stub.MockTransactionEnd("seedLegacyUser")
+
+		registerLegacy := func() string {
+			stub.TransientMap = map[string][]byte{userPIITransientKey: []byte(`{"location":"Location 11","meterId":"MeterId 11","salt":"secret-salt-of-user-11"}`)}
+			defer func() { stub.TransientMap = nil }()
+			response := stub.MockInvoke("13", [][]byte{[]byte("UpdateUserProfile"), []byte("11"), []byte("Consumer"), []byte("Battery")})
+			return response.GetMessage()
+		}
+		assert.Contains(t, registerLegacy(), "FORBIDDEN")
+
+		setCreator(t, stub, "Org1MSP", "admin")
+		defer setCreator(t, stub, "Org2MSP", "user1")
+		response := stub.MockInvoke("14", [][]byte{[]byte("UpdateUserProfile"), []byte("11"), []byte("Consumer"), []byte("Battery")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is required to move the Location and MeterId")
+
+		assert.Empty(t, registerLegacy(), "Unexpected error")
+		user, err := getUser(stub, 11)
+		assert.NoError(t, err, "Error reading user")
+		assert.Equal(t, "Org1MSP", user.OrgMSP, "OrgMSP mismatch")
+		assert.Empty(t, user.Location, "Location left in the public state")
+	})
+
+	// Test Case 10: Another identity of the same org cannot rewrite a bound profile
+	t.Run("Bound Profile Owner Only", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user2")
+		defer setCreator(t, stub, "Org2MSP", "user1")
+		response := stub.MockInvoke("15", [][]byte{[]byte("UpdateUserProfile"), []byte("1"), []byte("Prosumer"), []byte("Wind")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not the owner of User 1")
+
+		user, err := getUser(stub, 1)
+		assert.NoError(t, err, "Error reading user")
+		assert.Equal(t, Solar, user.Source, "Source rewritten by another identity")
+
+		setCreator(t, stub, "Org2MSP", "user1")
+		response = stub.MockInvoke("16", [][]byte{[]byte("UpdateUserProfile"), []byte("1"), []byte("Prosumer"), []byte("Wind")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+
+	// Test Case 11: A profile is not created without an identity to bind it to
+	t.Run("Creation Needs Identity", func(t *testing.T) {
+		creator := stub.Creator
+		defer func() { stub.Creator = creator }()
+		stub.Creator, _ = proto.Marshal(&msp.SerializedIdentity{Mspid: "Org2MSP"})
+		stub.TransientMap = map[string][]byte{userPIITransientKey: []byte(`{"location":"Location 12","meterId":"MeterId 12","salt":"secret-salt-of-user-12"}`)}
+		defer func() { stub.TransientMap = nil }()
+		response := stub.MockInvoke("17", [][]byte{[]byte("UpdateUserProfile"), []byte("12"), []byte("Consumer"), []byte("Battery")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+
+		userAsBytes, _ := stub.GetState("12")
+		assert.Nil(t, userAsBytes, "User created without an identity")
+	})
+}
+
+func TestSignPlatformContract(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	key := "12345"
+	id, err := strconv.ParseInt(key, 10, 64)
+
+	// Creating a dummy user, bound to Org2MSP:user12345, to be used in the tests.
+	user := User{ID: int64(id), Identity: "Org2MSP:user12345"}
+	userBytes, _ := json.Marshal(user)
+	// Start a transaction
+	stub.MockTransactionStart("1")
+	defer stub.MockTransactionEnd("1")
+
+	err = stub.PutState(key, userBytes)
+	if err != nil {
+		t.Fatalf("Failed to put the user into the stub: %s", err.Error())
+	}
+
+	// Publish the terms the user signs.
+	setCreator(t, stub, "Org1MSP", "admin")
+	response := stub.MockInvoke("1", [][]byte{[]byte("PublishPlatformTerms"), []byte("1.0"), []byte("9f86d081"), []byte("https://example.org/terms-1.0.pdf")})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	setCreator(t, stub, "Org2MSP", "user12345")
+
+	// Test Case 1: Successfully Sign Platform Contract
+	t.Run("Successfully Sign Platform Contract", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("SignPlatformContract"), []byte("12345")})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		contractAsBytes, err := stub.GetState("PlatformContract_12345")
+		assert.NoError(t, err, "Error getting value from ledger")
+
+		var contract PlatformContract
+		err = json.Unmarshal(contractAsBytes, &contract)
+		assert.NoError(t, err, "Error unmarshalling contract")
+		assert.Equal(t, int64(12345), contract.UserID, "Incorrect UserID in contract")
+		assert.Equal(t, "1.0", contract.AcceptedVersion, "Incorrect AcceptedVersion in contract")
+		assert.Equal(t, "Org2MSP:user12345", contract.SignedBy, "Incorrect SignedBy in contract")
+	})
+
+	// Test Case 2: Incorrect Number of Arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("SignPlatformContract"), []byte("12345"), []byte("ExtraArg")})
+
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 3: Non-existing User
+	t.Run("Non-existing User", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("SignPlatformContract"), []byte("54321")})
+
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 4: Invalid User ID
+	t.Run("Invalid User ID", func(t *testing.T) {
+		response := stub.MockInvoke("4", [][]byte{[]byte("SignPlatformContract"), []byte("InvalidUserID")})
+
+		assert.NotEqual(t, shim.OK, response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 5: Orders need the active terms, a new version or a revocation blocks them
+	t.Run("Orders Require Accepted Terms", func(t *testing.T) {
+		seedTradingSlot(t, stub, "slot1234")
+		seedMarketPrice(t, stub, "slot1234", 3.5)
+		order := func(orderID string) [][]byte {
+			return [][]byte{[]byte("RegisterOrder"), []byte("0"), []byte("0"), []byte(orderID), []byte("0"), []byte("35"), []byte("0"),
+				[]byte("slot1234"), []byte("10"), []byte("3.5"), []byte("12345"), []byte("50"), []byte("0")}
+		}
+
+		response := stub.MockInvoke("5", order("4"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		setCreator(t, stub, "Org1MSP", "admin")
+		response = stub.MockInvoke("6", [][]byte{[]byte("PublishPlatformTerms"), []byte("2.0"), []byte("60303ae2"), []byte("https://example.org/terms-2.0.pdf")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		setCreator(t, stub, "Org2MSP", "user12345")
+		response = stub.MockInvoke("7", order("40"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "has not accepted platform terms version 2.0")
+
+		response = stub.MockInvoke("8", [][]byte{[]byte("SignPlatformContract"), []byte("12345"), []byte("2.0")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("9", order("40"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("10", [][]byte{[]byte("RevokePlatformContract"), []byte("12345")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("11", order("41"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "has revoked the platform terms")
+	})
+
+	// Test Case 6: Another identity cannot sign for the user
+	t.Run("Other Identity Cannot Sign", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user7")
+		response := stub.MockInvoke("12", [][]byte{[]byte("SignPlatformContract"), []byte("12345")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not the owner")
+	})
+}
+
+func TestBindUserIdentity(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	// A user written before profiles carried an identity.
+	seedUser(t, stub, 3, "")
+	seedUser(t, stub, 4, "Org2MSP:user4")
+
+	// Test Case 1: Only the platform admin binds identities
+	t.Run("Non-Admin Refused", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user3")
+		response := stub.MockInvoke("1", [][]byte{[]byte("BindUserIdentity"), []byte("3"), []byte("Org2MSP:user3")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "FORBIDDEN")
+	})
+
+	// Test Case 2: A bound legacy user can sign the terms
+	t.Run("Legacy User Bound", func(t *testing.T) {
+		setCreator(t, stub, "Org1MSP", "admin")
+		response := stub.MockInvoke("2", [][]byte{[]byte("BindUserIdentity"), []byte("3"), []byte("Org2MSP:user3")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		userAsBytes, _ := stub.GetState("3")
+		var user User
+		err := json.Unmarshal(userAsBytes, &user)
+		assert.NoError(t, err, "Error unmarshalling user")
+		assert.Equal(t, "Org2MSP:user3", user.Identity, "Identity mismatch")
+
+		response = stub.MockInvoke("3", [][]byte{[]byte("PublishPlatformTerms"), []byte("1.0"), []byte("9f86d081"), []byte("https://example.org/terms-1.0.pdf")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		setCreator(t, stub, "Org2MSP", "user3")
+		response = stub.MockInvoke("4", [][]byte{[]byte("SignPlatformContract"), []byte("3")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+
+	// Test Case 3: Bound users, unknown users and malformed identities are refused
+	t.Run("Invalid Binding", func(t *testing.T) {
+		setCreator(t, stub, "Org1MSP", "admin")
+		response := stub.MockInvoke("5", [][]byte{[]byte("BindUserIdentity"), []byte("4"), []byte("Org2MSP:intruder")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "CONFLICT")
+
+		response = stub.MockInvoke("6", [][]byte{[]byte("BindUserIdentity"), []byte("5"), []byte("Org2MSP:user5")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "NOT_FOUND")
+
+		response = stub.MockInvoke("7", [][]byte{[]byte("BindUserIdentity"), []byte("3"), []byte("user3")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "INVALID_ARGUMENT")
+	})
+}
+
+func TestRegisterOrder(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	seedTradingSlot(t, stub, "slot1234")
+	seedMarketPrice(t, stub, "slot1234", 3.5)
+	seedUser(t, stub, 6, "Org2MSP:user6")
+	setCreator(t, stub, "Org2MSP", "user6")
+
+	// Test Case 1: Successfully register a new order
+	t.Run("Successfully Register a New Order", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),        // bidMatchID
+			[]byte("0"),        // bidStatus
+			[]byte("4"),        // orderID
+			[]byte("0"),        // onMarketPrice
+			[]byte("200"),      // orderCost
+			[]byte("5"),        // paymentID
+			[]byte("slot1234"), // slotID
+			[]byte("300"),      // totalQuantity
+			[]byte("3.5"),      // unitCost
+			[]byte("6"),        // userID
+			[]byte("50"),       // slotExecDate
+			[]byte("0"),        // action
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		orderAsBytes, err := stub.GetState("Order_4")
+		assert.NoError(t, err, "Error getting order from ledger")
+
+		var order Order
+		err = json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+
+		assert.Equal(t, int64(4), order.ID, "Order ID mismatch")
+		assert.Equal(t, int64(1), order.BidMatchID, "BidMatchID mismatch")
+		assert.Equal(t, 1050.0, order.OrderCost, "OrderCost not derived from quantity and unit cost")
+	})
+
+	// Test Case 2: Provide incorrect number of arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),
+		})
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Incorrect number of arguments")
+	})
+
+	// Test Case 3: An existing order cannot be overwritten through RegisterOrder
+	t.Run("Existing Order Not Updated", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),        // bidMatchID
+			[]byte("1"),        // bidStatus
+			[]byte("4"),        // orderID
+			[]byte("2.5"),      // onMarketPrice
+			[]byte("200"),      // orderCost
+			[]byte("5"),        // paymentID
+			[]byte("slot1234"), // slotID
+			[]byte("100"),      // totalQuantity
+			[]byte("3.5"),      // unitCost
+			[]byte("6"),        // userID
+			[]byte("50"),       // slotExecDate
+			[]byte("0"),        // action
+		})
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "CONFLICT")
+		assert.Contains(t, response.GetMessage(), "AmendOrder")
+
+		orderAsBytes, err := stub.GetState("Order_4")
+		assert.NoError(t, err, "Error getting order from ledger")
+
+		var order Order
+		err = json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+
+		assert.Equal(t, BidCreated, order.BidStatus, "BidStatus changed")
+		assert.Equal(t, int64(300), order.TotalQuantity, "TotalQuantity changed")
+	})
+
+	// Test Case 4: Enum arguments can be given by name
+	t.Run("Register Order With Enum Names", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),          // bidMatchID
+			[]byte("BidCreated"), // bidStatus
+			[]byte("5"),          // orderID
+			[]byte("0"),          // onMarketPrice
+			[]byte("200"),        // orderCost
+			[]byte("5"),          // paymentID
+			[]byte("slot1234"),   // slotID
+			[]byte("300"),        // totalQuantity
+			[]byte("3.5"),        // unitCost
+			[]byte("6"),          // userID
+			[]byte("50"),         // slotExecDate
+			[]byte("Sell"),       // action
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		orderAsBytes, _ := stub.GetState("Order_5")
+		assert.Contains(t, string(orderAsBytes), `"action":"Sell"`, "Action not stored by name")
+		assert.Contains(t, string(orderAsBytes), `"bidStatus":"BidCreated"`, "BidStatus not stored by name")
+	})
+
+	// Test Case 5: Unknown enum values are rejected
+	t.Run("Unknown Action", func(t *testing.T) {
+		response := stub.MockInvoke("4", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),        // bidMatchID
+			[]byte("0"),        // bidStatus
+			[]byte("6"),        // orderID
+			[]byte("0"),        // onMarketPrice
+			[]byte("200"),      // orderCost
+			[]byte("5"),        // paymentID
+			[]byte("slot1234"), // slotID
+			[]byte("300"),      // totalQuantity
+			[]byte("3.5"),      // unitCost
+			[]byte("6"),        // userID
+			[]byte("50"),       // slotExecDate
+			[]byte("7"),        // action
+		})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "unknown Action")
+	})
+
+	// Test Case 6: Orders are placed only by the user's own identity
+	t.Run("Other Identity Cannot Order", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user7")
+		defer setCreator(t, stub, "Org2MSP", "user6")
+		response := stub.MockInvoke("5", [][]byte{[]byte("RegisterOrder"), []byte("0"), []byte("0"), []byte("7"), []byte("0"), []byte("0"),
+			[]byte("0"), []byte("slot1234"), []byte("300"), []byte("3.5"), []byte("6"), []byte("50"), []byte("0")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not the owner")
+	})
+
+	// Test Case 7: A supplied order cost must restate quantity times unit cost
+	t.Run("Order Cost Is Derived", func(t *testing.T) {
+		response := stub.MockInvoke("6", [][]byte{[]byte("RegisterOrder"), []byte(`{"id": 8, "slotId": "slot1234", "action": "Buy", "totalQuantity": 300, "unitCost": 3.5, "orderCost": 1, "userId": 6}`)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "INVALID_ARGUMENT")
+		assert.Contains(t, response.GetMessage(), "must equal totalQuantity times unitCost (1050), got 1")
+
+		response = stub.MockInvoke("7", [][]byte{[]byte("RegisterOrder"), []byte(`{"id": 8, "slotId": "slot1234", "action": "Buy", "totalQuantity": 300, "unitCost": 3.5, "orderCost": 1050, "userId": 6}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	})
+}
+
+func TestCancelAndAmendOrder(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	seedTradingSlot(t, stub, "slot1234")
+	seedMarketPrice(t, stub, "slot1234", 3.5)
+
+	// User 6 is created by, and bound to, Org2MSP:user6
+	setCreator(t, stub, "Org2MSP", "user6")
+	registerUser(t, stub, []string{"6", "Consumer", "Location 6", "MeterId 6", "Battery"})
+
+	response := stub.MockInvoke("2", [][]byte{
+		[]byte("RegisterOrder"),
+		[]byte("1"),        // bidMatchID
+		[]byte("0"),        // bidStatus
+		[]byte("4"),        // orderID
+		[]byte("0"),        // onMarketPrice
+		[]byte("1050"),     // orderCost
+		[]byte("5"),        // paymentID
+		[]byte("slot1234"), // slotID
+		[]byte("300"),      // totalQuantity
+		[]byte("3.5"),      // unitCost
+		[]byte("6"),        // userID
+		[]byte("50"),       // slotExecDate
+		[]byte("0"),        // action
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Test Case 1: Owner amends price and quantity
+	t.Run("Owner Amends Order", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("AmendOrder"), []byte("4"), []byte("3.6"), []byte("250")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		orderAsBytes, err := stub.GetState("Order_4")
+		assert.NoError(t, err, "Error getting order from ledger")
+		var order Order
+		err = json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, int64(250), order.TotalQuantity, "TotalQuantity mismatch")
+		assert.Equal(t, 3.6, order.UnitCost, "UnitCost mismatch")
+		assert.Equal(t, int64(1), order.Revision, "Revision mismatch")
+	})
+
+	// Test Case 2: Other identities can neither amend nor cancel
+	t.Run("Other Identity Is Refused", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user7")
+		response := stub.MockInvoke("4", [][]byte{[]byte("AmendOrder"), []byte("4"), []byte("3.6"), []byte("100")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not the owner")
+
+		response = stub.MockInvoke("5", [][]byte{[]byte("CancelOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 3: Re-registering the order, even under another user, is refused
+	t.Run("Order Owner Cannot Change", func(t *testing.T) {
+		response := stub.MockInvoke("6", [][]byte{
+			[]byte("RegisterOrder"), []byte("1"), []byte("0"), []byte("4"), []byte("0"), []byte("1050"), []byte("5"),
+			[]byte("slot1234"), []byte("300"), []byte("3.5"), []byte("7"), []byte("50"), []byte("0"),
+		})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "CONFLICT")
+	})
+
+	// Test Case 4: Owner cancels, after which the order cannot be amended
+	t.Run("Owner Cancels Order", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user6")
+		response := stub.MockInvoke("7", [][]byte{[]byte("CancelOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("8", [][]byte{[]byte("AmendOrder"), []byte("4"), []byte("3.6"), []byte("100")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "BidCancelled")
+	})
+}
+
+func TestProcessBidMatch(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+	seedOrder(t, stub, Order{ID: 6, BidStatus: BidCreated, RemainingQuantity: 10, SlotID: "Slot1", TotalQuantity: 10, UserAction: Buy, UserID: 4})
+	seedOrder(t, stub, Order{ID: 7, BidStatus: BidCreated, RemainingQuantity: 10, SlotID: "Slot1", TotalQuantity: 10, UserAction: Sell, UserID: 5})
+	// The seller's profile gives the source stamped on the match.
+	seedUser(t, stub, 5, "Org2MSP:user5")
+	seedOrder(t, stub, Order{ID: 8, BidStatus: BidCreated, RemainingQuantity: 10, SlotID: "Slot2", TotalQuantity: 10, UserAction: Sell, UserID: 5})
+
+	// Test Case 1: Successfully process a new BidMatch
+	t.Run("Successfully Process a New BidMatch", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte("1"),
+			[]byte("Slot1"),
+			[]byte("1"),
+			[]byte("100"),
+			[]byte("4"),
+			[]byte("2.5"),
+			[]byte("1"),
+			[]byte("3.5"),
+			[]byte("5"),
+			[]byte("6"),
+			[]byte("7"),
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), "Unexpected error: "+response.GetMessage())
+
+		bidMatchAsBytes, err := stub.GetState("BidMatch_1")
+		assert.NoError(t, err, "Error getting BidMatch from ledger")
+
+		var bidMatch BidMatch
+		err = json.Unmarshal(bidMatchAsBytes, &bidMatch)
+		assert.NoError(t, err, "Error unmarshalling BidMatch")
+
+		assert.Equal(t, int64(1), bidMatch.ID, "BidMatch ID mismatch")
+		assert.Equal(t, int64(4), bidMatch.BuyerUserId, "BuyerUserId mismatch")
+	})
+
+	// Test Case 2: Provide incorrect number of arguments
+	t.Run("Incorrect Number of Arguments", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte("1"),
+		})
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Incorrect number of arguments")
+	})
+
+	bidMatch := func(buyer string, seller string, buyOrder string, sellOrder string) [][]byte {
+		return [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte("1"),       // bidMatchTms
+			[]byte("Slot1"),   // bidSlot
+			[]byte("1"),       // bidStatus
+			[]byte("100"),     // bidUnitPrice
+			[]byte(buyer),     // buyerUserId
+			[]byte("0"),       // deliveredBidUnits
+			[]byte("2"),       // ID
+			[]byte("1"),       // originalBidUnits
+			[]byte(seller),    // sellerUserId
+			[]byte(buyOrder),  // transactionBuyID
+			[]byte(sellOrder), // transactionSellID
+		}
+	}
+
+	// Test Case 3: Only the platform admin records matches
+	t.Run("Non-Admin Refused", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user4")
+		defer setCreator(t, stub, "Org1MSP", "admin")
+
+		response := stub.MockInvoke("3", bidMatch("4", "5", "6", "7"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "FORBIDDEN")
+	})
+
+	// Test Case 4: Each order must be on its side of the match, belong to that side's user and be in the match's slot
+	t.Run("Mismatched Orders Refused", func(t *testing.T) {
+		for i, args := range [][][]byte{
+			bidMatch("9", "5", "6", "7"),  // buy order of another user
+			bidMatch("4", "5", "7", "7"),  // sell order as the buy order
+			bidMatch("4", "5", "6", "8"),  // sell order in another slot
+			bidMatch("4", "5", "6", "99"), // unknown sell order
+		} {
+			response := stub.MockInvoke(strconv.Itoa(4+i), args)
+			assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		}
+
+		bidMatchAsBytes, err := stub.GetState("BidMatch_2")
+		assert.NoError(t, err, "Error getting BidMatch from ledger")
+		assert.Nil(t, bidMatchAsBytes, "Refused BidMatch stored")
+	})
+}
+
+func TestOrderPartialFills(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	seedTradingSlot(t, stub, "slot1234")
+	seedMarketPrice(t, stub, "slot1234", 3.5)
+	seedUser(t, stub, 6, "Org2MSP:user6")
+	setCreator(t, stub, "Org2MSP", "user6")
+
+	// One 10 kWh sell order and two buy orders of 6 and 4 kWh
+	for _, order := range [][]string{{"10", "10", "1"}, {"11", "6", "0"}, {"12", "4", "0"}} {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("0"),        // bidMatchID
+			[]byte("0"),        // bidStatus
+			[]byte(order[0]),   // orderID
+			[]byte("0"),        // onMarketPrice
+			[]byte("35"),       // orderCost
+			[]byte("0"),        // paymentID
+			[]byte("slot1234"), // slotID
+			[]byte(order[1]),   // totalQuantity
+			[]byte("3.5"),      // unitCost
+			[]byte("6"),        // userID
+			[]byte("50"),       // slotExecDate
+			[]byte(order[2]),   // action
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	}
+
+	bidMatch := func(id string, status string, units string, buyOrder string) [][]byte {
+		return [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte("1"),        // bidMatchTms
+			[]byte("slot1234"), // bidSlot
+			[]byte(status),     // bidStatus
+			[]byte("3"),        // bidUnitPrice
+			[]byte("6"),        // buyerUserId
+			[]byte("0"),        // deliveredBidUnits
+			[]byte(id),         // ID
+			[]byte(units),      // originalBidUnits
+			[]byte("6"),        // sellerUserId
+			[]byte(buyOrder),   // transactionBuyID
+			[]byte("10"),       // transactionSellID
+		}
+	}
+	readOrder := func(id string) Order {
+		var order Order
+		orderAsBytes, err := stub.GetState("Order_" + id)
+		assert.NoError(t, err, "Error getting order from ledger")
+		err = json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		return order
+	}
+
+	// Test Case 1: First match partially fills the sell order
+	t.Run("Partial Fill", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "2", bidMatch("1", "1", "6", "11"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		sell := readOrder("10")
+		assert.Equal(t, BidPartiallyFilled, sell.BidStatus, "Sell BidStatus mismatch")
+		assert.Equal(t, 6.0, sell.FilledQuantity, "FilledQuantity mismatch")
+		assert.Equal(t, 4.0, sell.RemainingQuantity, "RemainingQuantity mismatch")
+		assert.Equal(t, BidFilled, readOrder("11").BidStatus, "Buy BidStatus mismatch")
+	})
+
+	// Test Case 2: Second match fills the rest, both matches are listed
+	t.Run("Complete Fill", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "3", bidMatch("2", "1", "4", "12"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Equal(t, BidFilled, readOrder("10").BidStatus, "Sell BidStatus mismatch")
+
+		response = stub.MockInvoke("4", [][]byte{[]byte("ReadOrderFills"), []byte("10")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var fills []BidMatch
+		err := json.Unmarshal(response.GetPayload(), &fills)
+		assert.NoError(t, err, "Error unmarshalling fills")
+		assert.Len(t, fills, 2, "Unexpected number of fills")
+	})
+
+	// Test Case 3: Orders cannot be filled beyond their quantity
+	t.Run("Over Fill Is Rejected", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "5", bidMatch("3", "1", "1", "12"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "beyond its total quantity")
+	})
+
+	// Test Case 4: Rejecting a match releases its fill
+	t.Run("Rejected Match Releases Fill", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "6", bidMatch("2", "2", "4", "12"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		sell := readOrder("10")
+		assert.Equal(t, BidPartiallyFilled, sell.BidStatus, "Sell BidStatus mismatch")
+		assert.Equal(t, 4.0, sell.RemainingQuantity, "RemainingQuantity mismatch")
+		assert.Equal(t, BidAccepted, readOrder("12").BidStatus, "Buy BidStatus mismatch")
+
+		response = stub.MockInvoke("7", [][]byte{[]byte("ReadOrderFills"), []byte("10")})
+		var fills []BidMatch
+		err := json.Unmarshal(response.GetPayload(), &fills)
+		assert.NoError(t, err, "Error unmarshalling fills")
+		assert.Len(t, fills, 1, "Unexpected number of fills")
+	})
+}
+
+func TestReadOrder(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	seedTradingSlot(t, stub, "slot1234")
+	seedMarketPrice(t, stub, "slot1234", 3.5)
+	seedUser(t, stub, 6, "Org2MSP:user6")
+	setCreator(t, stub, "Org2MSP", "user6")
+
+	// Registering a new order
+	response := stub.MockInvoke("1", [][]byte{
+		[]byte("RegisterOrder"),
+		[]byte("1"),        // bidMatchID
+		[]byte("0"),        // bidStatus
+		[]byte("4"),        // orderID
+		[]byte("2.5"),      // onMarketPrice
+		[]byte("200"),      // orderCost
+		[]byte("5"),        // paymentID
+		[]byte("slot1234"), // slotID
+		[]byte("300"),      // totalQuantity
+		[]byte("3.5"),      // unitCost
+		[]byte("6"),        // userID
+		[]byte("50"),       // slotExecDate
+		[]byte("0"),        // action
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Test Case: Successfully read an order
+	t.Run("Successfully Read an Order", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("ReadOrder"),
+			[]byte("4"), // orderID
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var order Order
+		err := json.Unmarshal(response.GetPayload(), &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+
+		assert.Equal(t, int64(4), order.ID, "Order ID mismatch")
+		assert.Equal(t, int64(1), order.BidMatchID, "BidMatchID mismatch")
+	})
+
+	// Test Case: Try to read an order that doesn't exist
+	t.Run("Try to Read Nonexistent Order", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("ReadOrder"),
+			[]byte("99"), // orderID
+		})
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+}
+
+func TestReadBidMatch(t *testing.T) {
+	// Mock stub creation
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+	seedOrder(t, stub, Order{ID: 6, BidStatus: BidCreated, RemainingQuantity: 10, SlotID: "Slot1", TotalQuantity: 10, UserAction: Buy, UserID: 4})
+	seedOrder(t, stub, Order{ID: 7, BidStatus: BidCreated, RemainingQuantity: 10, SlotID: "Slot1", TotalQuantity: 10, UserAction: Sell, UserID: 5})
+	// The seller's profile gives the source stamped on the match.
+	seedUser(t, stub, 5, "Org2MSP:user5")
+
+	// Registering a new BidMatch
+	response := stub.MockInvoke("1", [][]byte{
+		[]byte("ProcessBidMatch"),
+		[]byte("1"),     // ID
+		[]byte("Slot1"), // other args...
+		[]byte("1"),
+		[]byte("100"),
+		[]byte("4"),
+		[]byte("2.5"),
+		[]byte("1"),
+		[]byte("3.5"),
+		[]byte("5"),
+		[]byte("6"),
+		[]byte("7"),
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Test Case 1: Successfully read a BidMatch
+	t.Run("Successfully Read a BidMatch", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("ReadBidMatch"),
+			[]byte("1"), // bidMatchID
+		})
+
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var bidMatch BidMatch
+		err := json.Unmarshal(response.GetPayload(), &bidMatch)
+		assert.NoError(t, err, "Error unmarshalling BidMatch")
+
+		assert.Equal(t, int64(1), bidMatch.ID, "BidMatch ID mismatch")
+		assert.Equal(t, int64(4), bidMatch.BuyerUserId, "BuyerUserId mismatch")
+	})
+
+	// Test Case 2: Try to read a BidMatch that doesn't exist
+	t.Run("Try to Read Nonexistent BidMatch", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("ReadBidMatch"),
+			[]byte("99"), // bidMatchID
+		})
+
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+}
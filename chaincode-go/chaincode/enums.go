@@ -0,0 +1,457 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// Every enum is written to JSON and text as its name and read back from its name
+// or, for ledger data written before names were used, its integer value. Values
+// outside the defined range are rejected instead of being stored or indexed.
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func enumString(typeName string, names []string, value int64) string {
+	if value < 0 || value >= int64(len(names)) {
+		return typeName + "(" + strconv.FormatInt(value, 10) + ")"
+	}
+	return names[value]
+}
+
+func marshalEnumText(typeName string, names []string, value int64) ([]byte, error) {
+	if value < 0 || value >= int64(len(names)) {
+		return nil, errors.New("invalid " + typeName + " " + strconv.FormatInt(value, 10))
+	}
+	return []byte(names[value]), nil
+}
+
+// parseEnumText accepts an enum name or its decimal value.
+func parseEnumText(typeName string, names []string, text []byte) (int64, error) {
+	for i, name := range names {
+		if name == string(text) {
+			return int64(i), nil
+		}
+	}
+	value, err := strconv.ParseInt(string(text), 10, 64)
+	if err != nil || value < 0 || value >= int64(len(names)) {
+		return 0, errors.New("unknown " + typeName + " " + strconv.Quote(string(text)))
+	}
+	return value, nil
+}
+
+// parseEnumJSON accepts a JSON string holding an enum name, or a legacy JSON integer.
+func parseEnumJSON(typeName string, names []string, data []byte) (int64, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		err := json.Unmarshal(data, &text)
+		if err != nil {
+			return 0, err
+		}
+		return parseEnumText(typeName, names, []byte(text))
+	}
+	var value int64
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return 0, errors.New("invalid " + typeName + " " + string(data))
+	}
+	if value < 0 || value >= int64(len(names)) {
+		return 0, errors.New("unknown " + typeName + " " + strconv.FormatInt(value, 10))
+	}
+	return value, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Enum Marshalling Methods                         */
+/* -------------------------------------------------------------------------- */
+
+func (e EnergyBidStatus) String() string {
+	return enumString("EnergyBidStatus", energyBidStatusNames, int64(e))
+}
+
+func (e EnergyBidStatus) MarshalText() ([]byte, error) {
+	return marshalEnumText("EnergyBidStatus", energyBidStatusNames, int64(e))
+}
+
+func (e *EnergyBidStatus) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("EnergyBidStatus", energyBidStatusNames, text)
+	if err != nil {
+		return err
+	}
+	*e = EnergyBidStatus(value)
+	return nil
+}
+
+func (e EnergyBidStatus) MarshalJSON() ([]byte, error) {
+	text, err := e.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (e *EnergyBidStatus) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("EnergyBidStatus", energyBidStatusNames, data)
+	if err != nil {
+		return err
+	}
+	*e = EnergyBidStatus(value)
+	return nil
+}
+
+func (e EnergySource) String() string {
+	return enumString("EnergySource", energySourceNames, int64(e))
+}
+
+func (e EnergySource) MarshalText() ([]byte, error) {
+	return marshalEnumText("EnergySource", energySourceNames, int64(e))
+}
+
+func (e *EnergySource) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("EnergySource", energySourceNames, text)
+	if err != nil {
+		return err
+	}
+	*e = EnergySource(value)
+	return nil
+}
+
+func (e EnergySource) MarshalJSON() ([]byte, error) {
+	text, err := e.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (e *EnergySource) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("EnergySource", energySourceNames, data)
+	if err != nil {
+		return err
+	}
+	*e = EnergySource(value)
+	return nil
+}
+
+func (a Action) String() string {
+	return enumString("Action", actionNames, int64(a))
+}
+
+func (a Action) MarshalText() ([]byte, error) {
+	return marshalEnumText("Action", actionNames, int64(a))
+}
+
+func (a *Action) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("Action", actionNames, text)
+	if err != nil {
+		return err
+	}
+	*a = Action(value)
+	return nil
+}
+
+func (a Action) MarshalJSON() ([]byte, error) {
+	text, err := a.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (a *Action) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("Action", actionNames, data)
+	if err != nil {
+		return err
+	}
+	*a = Action(value)
+	return nil
+}
+
+func (u UserCategory) String() string {
+	return enumString("UserCategory", userCategoryNames, int64(u))
+}
+
+func (u UserCategory) MarshalText() ([]byte, error) {
+	return marshalEnumText("UserCategory", userCategoryNames, int64(u))
+}
+
+func (u *UserCategory) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("UserCategory", userCategoryNames, text)
+	if err != nil {
+		return err
+	}
+	*u = UserCategory(value)
+	return nil
+}
+
+func (u UserCategory) MarshalJSON() ([]byte, error) {
+	text, err := u.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (u *UserCategory) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("UserCategory", userCategoryNames, data)
+	if err != nil {
+		return err
+	}
+	*u = UserCategory(value)
+	return nil
+}
+
+func (p PaymentType) String() string {
+	return enumString("PaymentType", paymentTypeNames, int64(p))
+}
+
+func (p PaymentType) MarshalText() ([]byte, error) {
+	return marshalEnumText("PaymentType", paymentTypeNames, int64(p))
+}
+
+func (p *PaymentType) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("PaymentType", paymentTypeNames, text)
+	if err != nil {
+		return err
+	}
+	*p = PaymentType(value)
+	return nil
+}
+
+func (p PaymentType) MarshalJSON() ([]byte, error) {
+	text, err := p.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (p *PaymentType) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("PaymentType", paymentTypeNames, data)
+	if err != nil {
+		return err
+	}
+	*p = PaymentType(value)
+	return nil
+}
+
+func (r RECStatus) String() string {
+	return enumString("RECStatus", recStatusNames, int64(r))
+}
+
+func (r RECStatus) MarshalText() ([]byte, error) {
+	return marshalEnumText("RECStatus", recStatusNames, int64(r))
+}
+
+func (r *RECStatus) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("RECStatus", recStatusNames, text)
+	if err != nil {
+		return err
+	}
+	*r = RECStatus(value)
+	return nil
+}
+
+func (r RECStatus) MarshalJSON() ([]byte, error) {
+	text, err := r.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (r *RECStatus) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("RECStatus", recStatusNames, data)
+	if err != nil {
+		return err
+	}
+	*r = RECStatus(value)
+	return nil
+}
+
+func (s SlotStatus) String() string {
+	return enumString("SlotStatus", slotStatusNames, int64(s))
+}
+
+func (s SlotStatus) MarshalText() ([]byte, error) {
+	return marshalEnumText("SlotStatus", slotStatusNames, int64(s))
+}
+
+func (s *SlotStatus) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("SlotStatus", slotStatusNames, text)
+	if err != nil {
+		return err
+	}
+	*s = SlotStatus(value)
+	return nil
+}
+
+func (s SlotStatus) MarshalJSON() ([]byte, error) {
+	text, err := s.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (s *SlotStatus) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("SlotStatus", slotStatusNames, data)
+	if err != nil {
+		return err
+	}
+	*s = SlotStatus(value)
+	return nil
+}
+
+func (f FeeScheduleStatus) String() string {
+	return enumString("FeeScheduleStatus", feeScheduleStatusNames, int64(f))
+}
+
+func (f FeeScheduleStatus) MarshalText() ([]byte, error) {
+	return marshalEnumText("FeeScheduleStatus", feeScheduleStatusNames, int64(f))
+}
+
+func (f *FeeScheduleStatus) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("FeeScheduleStatus", feeScheduleStatusNames, text)
+	if err != nil {
+		return err
+	}
+	*f = FeeScheduleStatus(value)
+	return nil
+}
+
+func (f FeeScheduleStatus) MarshalJSON() ([]byte, error) {
+	text, err := f.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (f *FeeScheduleStatus) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("FeeScheduleStatus", feeScheduleStatusNames, data)
+	if err != nil {
+		return err
+	}
+	*f = FeeScheduleStatus(value)
+	return nil
+}
+
+func (d DisputeStatus) String() string {
+	return enumString("DisputeStatus", disputeStatusNames, int64(d))
+}
+
+func (d DisputeStatus) MarshalText() ([]byte, error) {
+	return marshalEnumText("DisputeStatus", disputeStatusNames, int64(d))
+}
+
+func (d *DisputeStatus) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("DisputeStatus", disputeStatusNames, text)
+	if err != nil {
+		return err
+	}
+	*d = DisputeStatus(value)
+	return nil
+}
+
+func (d DisputeStatus) MarshalJSON() ([]byte, error) {
+	text, err := d.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (d *DisputeStatus) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("DisputeStatus", disputeStatusNames, data)
+	if err != nil {
+		return err
+	}
+	*d = DisputeStatus(value)
+	return nil
+}
+
+func (d DisputeType) String() string {
+	return enumString("DisputeType", disputeTypeNames, int64(d))
+}
+
+func (d DisputeType) MarshalText() ([]byte, error) {
+	return marshalEnumText("DisputeType", disputeTypeNames, int64(d))
+}
+
+func (d *DisputeType) UnmarshalText(text []byte) error {
+	value, err := parseEnumText("DisputeType", disputeTypeNames, text)
+	if err != nil {
+		return err
+	}
+	*d = DisputeType(value)
+	return nil
+}
+
+func (d DisputeType) MarshalJSON() ([]byte, error) {
+	text, err := d.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+func (d *DisputeType) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := parseEnumJSON("DisputeType", disputeTypeNames, data)
+	if err != nil {
+		return err
+	}
+	*d = DisputeType(value)
+	return nil
+}
@@ -0,0 +1,61 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnumMarshalling(t *testing.T) {
+	// Test Case 1: Enums are written by name
+	t.Run("Marshal Names", func(t *testing.T) {
+		bidMatchAsBytes, err := json.Marshal(BidMatch{BidStatus: BidExecuted})
+		assert.NoError(t, err, "Error marshalling BidMatch")
+		assert.Contains(t, string(bidMatchAsBytes), `"bidStatus":"BidExecuted"`)
+
+		userAsBytes, err := json.Marshal(User{Category: Consumer, Source: DGSet})
+		assert.NoError(t, err, "Error marshalling User")
+		assert.Contains(t, string(userAsBytes), `"category":"Consumer"`)
+		assert.Contains(t, string(userAsBytes), `"source":"DG Set"`)
+
+		text, err := BuyerEnergyPurchased.MarshalText()
+		assert.NoError(t, err, "Error marshalling PaymentType")
+		assert.Equal(t, "Buyer - Energy Purchased", string(text))
+	})
+
+	// Test Case 2: Ledger data written with integers still decodes
+	t.Run("Unmarshal Legacy Integers", func(t *testing.T) {
+		var order Order
+		err := json.Unmarshal([]byte(`{"action":1,"bidStatus":7}`), &order)
+		assert.NoError(t, err, "Error unmarshalling legacy order")
+		assert.Equal(t, Sell, order.UserAction, "Action mismatch")
+		assert.Equal(t, BidFilled, order.BidStatus, "BidStatus mismatch")
+
+		var payment Payment
+		err = json.Unmarshal([]byte(`{"paymentType":"Dispute - Adjustment"}`), &payment)
+		assert.NoError(t, err, "Error unmarshalling payment")
+		assert.Equal(t, DisputeAdjustment, payment.PaymentType, "PaymentType mismatch")
+	})
+
+	// Test Case 3: Unknown values are rejected in both directions
+	t.Run("Reject Unknown Values", func(t *testing.T) {
+		var order Order
+		assert.Error(t, json.Unmarshal([]byte(`{"action":2}`), &order))
+		assert.Error(t, json.Unmarshal([]byte(`{"action":"Hold"}`), &order))
+		assert.Error(t, json.Unmarshal([]byte(`{"bidStatus":-1}`), &order))
+
+		_, err := json.Marshal(Order{UserAction: Action(5)})
+		assert.Error(t, err, "Out of range Action marshalled")
+
+		var source EnergySource
+		assert.Error(t, source.UnmarshalText([]byte("Coal")))
+	})
+
+	// Test Case 4: String helpers no longer panic on out of range values
+	t.Run("String Out Of Range", func(t *testing.T) {
+		assert.Equal(t, "EnergyBidStatus(42)", EnergyBidStatusString(EnergyBidStatus(42)))
+		assert.Equal(t, "EnergySource(-1)", EnergySourceString(EnergySource(-1)))
+		assert.Equal(t, "Wind", EnergySourceString(Wind))
+	})
+}
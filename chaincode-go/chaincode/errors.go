@@ -0,0 +1,66 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Failed transactions return an errcode.Error as JSON in the response message,
+// with the usual shim.ERROR status. Helpers that fail for a reason the client
+// can act on return an *errcode.Error; any other error reaching a response is
+// reported as errcode.Internal.
+
+// errorResponse fails the transaction with the given code and message.
+func errorResponse(code errcode.Code, message string) pb.Response {
+	return shim.Error(errcode.New(code, message).JSON())
+}
+
+// errorResponseFrom fails the transaction with err, keeping its code and
+// details. A rejected payload is an invalid argument with one detail per field.
+func errorResponseFrom(err error) pb.Response {
+	var chaincodeErr *errcode.Error
+	if errors.As(err, &chaincodeErr) {
+		return shim.Error(chaincodeErr.JSON())
+	}
+	var payloadErr *PayloadError
+	if errors.As(err, &payloadErr) {
+		invalid := errcode.New(errcode.InvalidArgument, "Invalid payload")
+		invalid.Details = payloadErr.Fields
+		return shim.Error(invalid.JSON())
+	}
+	return errorResponse(errcode.Internal, err.Error())
+}
+
+// wrapErrorResponse fails the transaction with message prefixed to err, keeping
+// the code err carries, if any.
+func wrapErrorResponse(message string, err error) pb.Response {
+	code := errcode.Internal
+	var chaincodeErr *errcode.Error
+	if errors.As(err, &chaincodeErr) {
+		code = chaincodeErr.Code
+	}
+	return errorResponse(code, message+err.Error())
+}
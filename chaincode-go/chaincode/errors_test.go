@@ -0,0 +1,69 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+func TestErrorCodes(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+	seedTradingSlot(t, stub, "slot1")
+
+	assertCode := func(t *testing.T, response pb.Response, code errcode.Code) *errcode.Error {
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		chaincodeErr, ok := errcode.Parse(response.GetMessage())
+		assert.True(t, ok, "Response carries no structured error: "+response.GetMessage())
+		if ok {
+			assert.Equal(t, code, chaincodeErr.Code, "Code mismatch: "+chaincodeErr.Message)
+		}
+		return chaincodeErr
+	}
+
+	// Test Case 1: Malformed arguments are invalid
+	t.Run("Invalid Argument", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("ReadOrder")})
+		chaincodeErr := assertCode(t, response, errcode.InvalidArgument)
+		assert.Equal(t, "Incorrect number of arguments. Expecting 1.", chaincodeErr.Message, "Message mismatch")
+
+		response = stub.MockInvoke("2", [][]byte{[]byte("RegisterOrder"), []byte(`{"id":4,"unitCost":-1}`)})
+		chaincodeErr = assertCode(t, response, errcode.InvalidArgument)
+		assert.NotEmpty(t, chaincodeErr.Details, "Missing field details")
+	})
+
+	// Test Case 2: Missing assets are not found
+	t.Run("Not Found", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("ReadOrder"), []byte("404")})
+		assertCode(t, response, errcode.NotFound)
+	})
+
+	// Test Case 3: Creating an existing asset conflicts
+	t.Run("Conflict", func(t *testing.T) {
+		response := stub.MockInvoke("4", [][]byte{[]byte("CreateTradingSlot"), []byte("slot1"), []byte("2000"), []byte("2900"), []byte("1000")})
+		assertCode(t, response, errcode.Conflict)
+	})
+
+	// Test Case 4: Admin functions are forbidden to other orgs
+	t.Run("Forbidden", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user1")
+		defer setCreator(t, stub, "Org1MSP", "admin")
+
+		response := stub.MockInvoke("5", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte("slot1"), []byte("Closed")})
+		assertCode(t, response, errcode.Forbidden)
+	})
+
+	// Test Case 5: Transactions the asset's state does not allow are state violations
+	t.Run("State Violation", func(t *testing.T) {
+		response := stub.MockInvoke("6", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte("slot1"), []byte("Closed")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), response.GetMessage())
+
+		response = stub.MockInvoke("7", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte("slot1"), []byte("Open")})
+		assertCode(t, response, errcode.StateViolation)
+	})
+}
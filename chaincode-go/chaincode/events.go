@@ -0,0 +1,105 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// AssetEventName is the chaincode event of every transaction that writes assets.
+// Its payload is the JSON array of the transaction's AssetChanges, so off-chain
+// readers can follow the ledger without decoding blocks.
+const AssetEventName = "AssetsChanged"
+
+// AssetChange is the final write of a transaction to one asset. Kind is the
+// asset's type name, as accepted by ReadAsset; ID is empty for singletons.
+type AssetChange struct {
+	Deleted bool            `json:"deleted,omitempty"`
+	ID      string          `json:"id"`
+	Kind    string          `json:"kind"`
+	Value   json.RawMessage `json:"value,omitempty"`
+}
+
+// eventStub records the asset writes of a transaction for its AssetsChanged event.
+// Indexes, private data and unversioned records are not reported.
+type eventStub struct {
+	shim.ChaincodeStubInterface
+	changes []AssetChange
+	index   map[string]int // position in changes of each written key
+}
+
+func newEventStub(stub shim.ChaincodeStubInterface) *eventStub {
+	return &eventStub{ChaincodeStubInterface: stub, index: map[string]int{}}
+}
+
+func (stub *eventStub) PutState(key string, value []byte) error {
+	err := stub.ChaincodeStubInterface.PutState(key, value)
+	if err == nil {
+		stub.record(key, AssetChange{Value: json.RawMessage(value)})
+	}
+	return err
+}
+
+func (stub *eventStub) DelState(key string) error {
+	err := stub.ChaincodeStubInterface.DelState(key)
+	if err == nil {
+		stub.record(key, AssetChange{Deleted: true})
+	}
+	return err
+}
+
+func (stub *eventStub) record(key string, change AssetChange) {
+	kind, id, ok := assetKindOfKey(key)
+	if !ok {
+		return
+	}
+	change.Kind, change.ID = kind, id
+	if position, seen := stub.index[key]; seen {
+		stub.changes[position] = change
+		return
+	}
+	stub.index[key] = len(stub.changes)
+	stub.changes = append(stub.changes, change)
+}
+
+// emit sets the AssetsChanged event, unless the transaction wrote no assets.
+func (stub *eventStub) emit() error {
+	if len(stub.changes) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(stub.changes)
+	if err != nil {
+		return err
+	}
+	return stub.ChaincodeStubInterface.SetEvent(AssetEventName, payload)
+}
+
+// assetKindOfKey finds the asset kind whose key range holds key, and the asset ID within it.
+func assetKindOfKey(key string) (string, string, bool) {
+	for kind, schema := range assetSchemas {
+		if key < schema.StartKey || key >= schema.EndKey {
+			continue
+		}
+		return kind, schema.id(key), true
+	}
+	return "", "", false
+}
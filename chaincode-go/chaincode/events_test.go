@@ -0,0 +1,86 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetEvents(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+	seedTradingSlot(t, stub, "slot1")
+	seedMarketPrice(t, stub, "slot1", 3.5)
+	seedUser(t, stub, 6, "Org1MSP:admin")
+
+	nextEvent := func(t *testing.T) []AssetChange {
+		select {
+		case event := <-stub.ChaincodeEventsChannel:
+			assert.Equal(t, AssetEventName, event.EventName)
+			var changes []AssetChange
+			require.NoError(t, json.Unmarshal(event.Payload, &changes), "Event payload is not JSON")
+			return changes
+		default:
+			t.Fatal("No event was set")
+			return nil
+		}
+	}
+
+	// Test Case 1: A write reports the stored record
+	t.Run("Order Written", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("RegisterOrder"), []byte(`{"id": 4, "slotId": "slot1", "action": "Buy", "totalQuantity": 300, "unitCost": 3.5, "userId": 6}`)})
+		require.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		changes := nextEvent(t)
+		require.Len(t, changes, 1, "Only the order is an asset")
+		assert.Equal(t, "Order", changes[0].Kind)
+		assert.Equal(t, "4", changes[0].ID)
+		stored, _ := stub.GetState("Order_4")
+		assert.JSONEq(t, string(stored), string(changes[0].Value))
+	})
+
+	// Test Case 2: Singletons are reported without an ID
+	t.Run("Singleton Written", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("SetMarketOracleConfig"), []byte("20"), []byte("Org1MSP:admin")})
+		require.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		changes := nextEvent(t)
+		require.Len(t, changes, 1)
+		assert.Equal(t, AssetChange{Kind: "MarketOracleConfig", Value: changes[0].Value}, changes[0])
+	})
+
+	// Test Case 3: Failed and read-only transactions set no event
+	t.Run("No Event", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("CancelOrder"), []byte("99")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		response = stub.MockInvoke("4", [][]byte{[]byte("ReadOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Empty(t, stub.ChaincodeEventsChannel, "Unexpected event")
+	})
+}
+
+func TestAssetKindOfKey(t *testing.T) {
+	for key, expected := range map[string][2]string{
+		"17":                 {"User", "17"},
+		"Order_4":            {"Order", "4"},
+		"Payment_Settlement": {"Payment", "Settlement"},
+		"PaymentDetail_5":    {"PaymentDetail", "5"},
+		settlementConfigKey:  {"SettlementConfig", ""},
+	} {
+		kind, id, ok := assetKindOfKey(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, expected, [2]string{kind, id}, key)
+	}
+
+	indexKey, err := shim.CreateCompositeKey(OrderFillIndex, []string{"4", "1"})
+	require.NoError(t, err)
+	for _, key := range []string{indexKey, "SealedBid_4", "Unknown"} {
+		_, _, ok := assetKindOfKey(key)
+		assert.False(t, ok, key)
+	}
+}
@@ -0,0 +1,423 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+const feeScheduleLatestVersionKey = "FeeScheduleLatestVersion"
+const feeScheduleActiveVersionKey = "FeeScheduleActiveVersion"
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func getVersionCounter(stub shim.ChaincodeStubInterface, key string) (int64, error) {
+	versionAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return 0, errors.New("Error accessing state: " + err.Error())
+	}
+	if versionAsBytes == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(versionAsBytes), 10, 64)
+}
+
+func getFeeSchedule(stub shim.ChaincodeStubInterface, version int64) (FeeSchedule, error) {
+	var schedule FeeSchedule
+	scheduleAsBytes, err := stub.GetState("FeeSchedule_" + strconv.FormatInt(version, 10))
+	if err != nil {
+		return schedule, errors.New("Error accessing state: " + err.Error())
+	}
+	if scheduleAsBytes == nil {
+		return schedule, errcode.New(errcode.NotFound, "FeeSchedule version "+strconv.FormatInt(version, 10)+" not found.")
+	}
+	err = decodeAsset(scheduleAsBytes, &schedule)
+	if err != nil {
+		return schedule, errors.New("Failed to unmarshal fee schedule: " + err.Error())
+	}
+	return schedule, nil
+}
+
+func putFeeSchedule(stub shim.ChaincodeStubInterface, schedule FeeSchedule) error {
+	scheduleAsBytes, err := encodeAsset(&schedule)
+	if err != nil {
+		return err
+	}
+	return stub.PutState("FeeSchedule_"+strconv.FormatInt(schedule.Version, 10), scheduleAsBytes)
+}
+
+// getActiveFeeSchedule returns the approved schedule in force, or false when none has been approved yet.
+func getActiveFeeSchedule(stub shim.ChaincodeStubInterface) (FeeSchedule, bool, error) {
+	version, err := getVersionCounter(stub, feeScheduleActiveVersionKey)
+	if err != nil || version == 0 {
+		return FeeSchedule{}, false, err
+	}
+	schedule, err := getFeeSchedule(stub, version)
+	return schedule, err == nil, err
+}
+
+func (request *FeeScheduleRequest) validate() []FieldError {
+	var fieldErrors []FieldError
+	isPercent := func(pct float64) bool { return pct >= 0 && pct <= 100 }
+	if !isPercent(request.PercentFee) {
+		fieldErrors = append(fieldErrors, FieldError{Field: "percentFee", Message: "must be between 0 and 100"})
+	}
+	fieldErrors = nonNegative(fieldErrors, map[string]float64{
+		"flatFee":    request.FlatFee,
+		"minimumFee": request.MinimumFee,
+	})
+	for _, rate := range request.CategoryRates {
+		if rate.Category != Prosumer && rate.Category != Consumer {
+			fieldErrors = append(fieldErrors, FieldError{Field: "categoryRates", Message: "unknown user category"})
+			break
+		}
+		if !isPercent(rate.PercentFee) {
+			fieldErrors = append(fieldErrors, FieldError{Field: "categoryRates", Message: "percentFee must be between 0 and 100"})
+			break
+		}
+	}
+	for _, tier := range request.Tiers {
+		if tier.MinMonthlyVolume < 0 || !isPercent(tier.PercentFee) {
+			fieldErrors = append(fieldErrors, FieldError{Field: "tiers", Message: "need a non-negative minMonthlyVolume and a percentFee between 0 and 100"})
+			break
+		}
+	}
+	return fieldErrors
+}
+
+func monthOf(tms int64) string {
+	return time.Unix(tms, 0).UTC().Format("200601")
+}
+
+func getMonthlyVolume(stub shim.ChaincodeStubInterface, userID int64, month string) (MonthlyVolume, error) {
+	volume := MonthlyVolume{Month: month, UserID: userID}
+	volumeAsBytes, err := stub.GetState("MonthlyVolume_" + strconv.FormatInt(userID, 10) + "_" + month)
+	if err != nil {
+		return volume, errors.New("Error accessing state: " + err.Error())
+	}
+	if volumeAsBytes == nil {
+		return volume, nil
+	}
+	err = decodeAsset(volumeAsBytes, &volume)
+	if err != nil {
+		return volume, errors.New("Failed to unmarshal monthly volume: " + err.Error())
+	}
+	return volume, nil
+}
+
+func putMonthlyVolume(stub shim.ChaincodeStubInterface, volume MonthlyVolume) error {
+	volumeAsBytes, err := encodeAsset(&volume)
+	if err != nil {
+		return err
+	}
+	return stub.PutState("MonthlyVolume_"+strconv.FormatInt(volume.UserID, 10)+"_"+volume.Month, volumeAsBytes)
+}
+
+// recordMonthlyVolume keeps the buyer's and seller's monthly volume in step with a BidMatch.
+// An executed match counts its delivered units once, in the month of the transaction that
+// executed it, the same clock computePlatformFee reads tiers by; a match that leaves
+// BidExecuted takes them back. Deltas are summed per user and month first because a
+// transaction does not read its own writes.
+func recordMonthlyVolume(stub shim.ChaincodeStubInterface, bidMatch BidMatch) error {
+	contributionKey := "VolumeContribution_" + strconv.FormatInt(bidMatch.ID, 10)
+	contributionAsBytes, err := stub.GetState(contributionKey)
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	var previous VolumeContribution
+	if contributionAsBytes != nil {
+		err = decodeAsset(contributionAsBytes, &previous)
+		if err != nil {
+			return errors.New("Failed to unmarshal volume contribution: " + err.Error())
+		}
+	}
+
+	var contribution VolumeContribution
+	if bidMatch.BidStatus == BidExecuted {
+		contribution = VolumeContribution{BidMatchID: bidMatch.ID, Month: previous.Month, Volume: bidMatch.DeliveredBidUnits}
+		if contribution.Month == "" {
+			now, err := txTimestamp(stub)
+			if err != nil {
+				return err
+			}
+			contribution.Month = monthOf(now)
+		}
+		contribution.UserIDs = []int64{bidMatch.BuyerUserId}
+		if bidMatch.SellerUserId != bidMatch.BuyerUserId {
+			contribution.UserIDs = append(contribution.UserIDs, bidMatch.SellerUserId)
+		}
+	}
+
+	type userMonth struct {
+		userID int64
+		month  string
+	}
+	deltas := map[userMonth]float64{}
+	userMonths := []userMonth{}
+	addDelta := func(userIDs []int64, month string, delta float64) {
+		for _, userID := range userIDs {
+			key := userMonth{userID, month}
+			if _, ok := deltas[key]; !ok {
+				userMonths = append(userMonths, key)
+			}
+			deltas[key] += delta
+		}
+	}
+	addDelta(previous.UserIDs, previous.Month, -previous.Volume)
+	addDelta(contribution.UserIDs, contribution.Month, contribution.Volume)
+	for _, key := range userMonths {
+		if deltas[key] == 0 {
+			continue
+		}
+		volume, err := getMonthlyVolume(stub, key.userID, key.month)
+		if err != nil {
+			return err
+		}
+		volume.Volume = math.Max(volume.Volume+deltas[key], 0)
+		err = putMonthlyVolume(stub, volume)
+		if err != nil {
+			return err
+		}
+	}
+
+	if contribution.UserIDs == nil {
+		if contributionAsBytes == nil {
+			return nil
+		}
+		return stub.DelState(contributionKey)
+	}
+	contributionAsBytes, err = encodeAsset(&contribution)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(contributionKey, contributionAsBytes)
+}
+
+// computePlatformFee applies a schedule to the unit cost of a user's payment.
+func computePlatformFee(stub shim.ChaincodeStubInterface, schedule FeeSchedule, userID int64, unitCost float64) (float64, error) {
+	percent := schedule.PercentFee
+
+	// A payer without a profile has no category and pays the base rate; any
+	// other failure to read the profile fails the fee.
+	user, err := getUser(stub, userID)
+	if err != nil && !errcode.Is(err, errcode.NotFound) {
+		return 0, err
+	}
+	if err == nil {
+		for _, rate := range schedule.CategoryRates {
+			if rate.Category == user.Category {
+				percent = rate.PercentFee
+			}
+		}
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return 0, err
+	}
+	volume, err := getMonthlyVolume(stub, userID, monthOf(now))
+	if err != nil {
+		return 0, err
+	}
+	for _, tier := range schedule.Tiers {
+		if volume.Volume >= tier.MinMonthlyVolume {
+			percent = tier.PercentFee
+		}
+	}
+
+	fee := unitCost*percent/100 + schedule.FlatFee
+	fee = math.Max(fee, schedule.MinimumFee)
+	return math.Round(fee*100) / 100, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Fee Write Methods                            */
+/* -------------------------------------------------------------------------- */
+
+// ============================================================================================================================
+// ProposeFeeSchedule() - propose the next version of the fee schedule
+//
+// Inputs - Array of strings
+//                 0
+//              payload
+// {"percentFee":2,"flatFee":0.5,"minimumFee":1,"tiers":[{"minMonthlyVolume":1000,"percentFee":1.5}],
+//  "categoryRates":[{"category":"Prosumer","percentFee":1.8}]}
+// ============================================================================================================================
+func ProposeFeeSchedule(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if !isJSONPayload(args) {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload).")
+	}
+
+	var proposal FeeScheduleRequest
+	err := decodePayload(args[0], &proposal)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	identity, err := callerIdentity(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	latestVersion, err := getVersionCounter(stub, feeScheduleLatestVersionKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to read fee schedule version: "+err.Error())
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	sort.SliceStable(proposal.Tiers, func(i, j int) bool {
+		return proposal.Tiers[i].MinMonthlyVolume < proposal.Tiers[j].MinMonthlyVolume
+	})
+	schedule := FeeSchedule{
+		CategoryRates: proposal.CategoryRates,
+		FlatFee:       proposal.FlatFee,
+		MinimumFee:    proposal.MinimumFee,
+		PercentFee:    proposal.PercentFee,
+		ProposedBy:    identity,
+		ProposedOn:    now,
+		Status:        FeeScheduleProposed,
+		Tiers:         proposal.Tiers,
+		Version:       latestVersion + 1,
+	}
+
+	err = putFeeSchedule(stub, schedule)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store fee schedule: "+err.Error())
+	}
+	err = stub.PutState(feeScheduleLatestVersionKey, []byte(strconv.FormatInt(schedule.Version, 10)))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store fee schedule version: "+err.Error())
+	}
+
+	return shim.Success([]byte(strconv.FormatInt(schedule.Version, 10)))
+}
+
+// ============================================================================================================================
+// ApproveFeeSchedule() - platform admin puts a proposed version into force, superseding the active one
+//
+// Inputs - Array of strings
+//     0
+//  version
+// ============================================================================================================================
+func ApproveFeeSchedule(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	err := assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	identity, err := callerIdentity(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	version, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse fee schedule version: "+err.Error())
+	}
+	schedule, err := getFeeSchedule(stub, version)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if schedule.Status != FeeScheduleProposed {
+		return errorResponse(errcode.StateViolation, "FeeSchedule version "+args[0]+" is "+FeeScheduleStatusString(schedule.Status)+" and cannot be approved")
+	}
+
+	active, found, err := getActiveFeeSchedule(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if found {
+		active.Status = FeeScheduleSuperseded
+		err = putFeeSchedule(stub, active)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Could not store fee schedule: "+err.Error())
+		}
+	}
+
+	schedule.Status = FeeScheduleActive
+	schedule.ApprovedBy = identity
+	schedule.ApprovedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = putFeeSchedule(stub, schedule)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store fee schedule: "+err.Error())
+	}
+	err = stub.PutState(feeScheduleActiveVersionKey, []byte(strconv.FormatInt(schedule.Version, 10)))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store active fee schedule version: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Fee Read Methods                             */
+/* -------------------------------------------------------------------------- */
+
+func ReadFeeSchedule(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the version, or "active" for the schedule in force.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	var schedule FeeSchedule
+	if args[0] == "active" {
+		active, found, err := getActiveFeeSchedule(stub)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		if !found {
+			return errorResponse(errcode.NotFound, "No active FeeSchedule found.")
+		}
+		schedule = active
+	} else {
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return errorResponse(errcode.InvalidArgument, "Failed to parse fee schedule version: "+err.Error())
+		}
+		schedule, err = getFeeSchedule(stub, version)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+	}
+
+	scheduleAsBytes, _ := json.Marshal(schedule)
+	return shim.Success(scheduleAsBytes)
+}
@@ -0,0 +1,199 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeSchedule(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org2MSP", "operator")
+
+	registerUser(t, stub, []string{"3", "Consumer", "Location 3", "MeterId 3", "Battery"})
+	seedOrder(t, stub, Order{ID: 6, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Buy, UserID: 3})
+	seedOrder(t, stub, Order{ID: 7, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Sell, UserID: 4})
+
+	// The seller of the tiered match below.
+	sellerAsBytes, _ := json.Marshal(User{ID: 4, Category: Prosumer, Source: Battery})
+	stub.MockTransactionStart("seedSeller")
+	_ = stub.PutState("4", sellerAsBytes)
+	stub.MockTransactionEnd("seedSeller")
+
+	recordPayment := func(paymentID string, unitCost string) PaymentDetail {
+		response := stub.MockInvoke(paymentID, [][]byte{
+			[]byte("RecordPayment"),
+			[]byte(paymentID),                  // paymentID
+			[]byte("Buyer - Energy Purchased"), // paymentType
+			[]byte(unitCost),                   // totalAmount
+			[]byte("3"),                        // userID
+			[]byte("wallet-3"),                 // debitedFrom
+			[]byte("platform"),                 // creditedTo
+			[]byte(unitCost),                   // totalUnitCost
+			[]byte("999"),                      // platformFee, ignored
+			[]byte("0"),                        // tokenAmount
+			[]byte("0"),                        // bidRefundAmount
+			[]byte("0"),                        // platformFeeRefundAmount
+			[]byte("0"),                        // penaltyFromSeller
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var payment Payment
+		paymentAsBytes, _ := stub.GetState("Payment_" + paymentID)
+		err := json.Unmarshal(paymentAsBytes, &payment)
+		assert.NoError(t, err, "Error unmarshalling payment")
+		var detail PaymentDetail
+		detailAsBytes, _ := stub.GetState("PaymentDetail_" + strconv.FormatInt(payment.PaymentDetailId, 10))
+		err = json.Unmarshal(detailAsBytes, &detail)
+		assert.NoError(t, err, "Error unmarshalling payment detail")
+		return detail
+	}
+
+	// Test Case 1: Energy payments are refused until a schedule is approved
+	t.Run("No Active Schedule", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("RecordPayment"), []byte("P0"), []byte("Buyer - Energy Purchased"),
+			[]byte("100"), []byte("3"), []byte("wallet-3"), []byte("platform"), []byte("100"), []byte("0"), []byte("0"),
+			[]byte("0"), []byte("0"), []byte("0")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "No active fee schedule")
+	})
+
+	// Test Case 2: A proposal only takes effect after the admin org approves it
+	t.Run("Propose And Approve", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "finance")
+		schedule := `{"percentFee":2,"flatFee":0.5,"minimumFee":1,` +
+			`"tiers":[{"minMonthlyVolume":100,"percentFee":1}],"categoryRates":[{"category":1,"percentFee":3}]}`
+		response := stub.MockInvoke("3", [][]byte{[]byte("ProposeFeeSchedule"), []byte(schedule)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Equal(t, "1", string(response.GetPayload()), "Version mismatch")
+
+		response = stub.MockInvoke("4", [][]byte{[]byte("ApproveFeeSchedule"), []byte("1")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not a platform admin")
+
+		setCreator(t, stub, "Org1MSP", "admin")
+		response = stub.MockInvoke("5", [][]byte{[]byte("ApproveFeeSchedule"), []byte("1")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("6", [][]byte{[]byte("ReadFeeSchedule"), []byte("active")})
+		var active FeeSchedule
+		err := json.Unmarshal(response.GetPayload(), &active)
+		assert.NoError(t, err, "Error unmarshalling fee schedule")
+		assert.Equal(t, int64(1), active.Version, "Version mismatch")
+		assert.Equal(t, "Org1MSP:admin", active.ApprovedBy, "ApprovedBy mismatch")
+	})
+
+	// Test Case 3: The chaincode computes the fee with the category rate
+	t.Run("Category Rate Applied", func(t *testing.T) {
+		detail := recordPayment("P1", "100")
+		assert.Equal(t, 3.5, detail.PlatformFee, "PlatformFee mismatch")
+		assert.Equal(t, int64(1), detail.FeeScheduleVersion, "FeeScheduleVersion mismatch")
+	})
+
+	// Test Case 4: Once monthly volume reaches a tier its rate applies, subject to the minimum.
+	// Volume is counted in the month of the transaction, whatever bidMatchTms the caller gives.
+	t.Run("Volume Tier And Minimum Applied", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "7", [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte("1"),     // bidMatchTms
+			[]byte("Slot1"), // bidSlot
+			[]byte("3"),     // bidStatus
+			[]byte("5"),     // bidUnitPrice
+			[]byte("3"),     // buyerUserId
+			[]byte("150"),   // deliveredBidUnits
+			[]byte("1"),     // ID
+			[]byte("150"),   // originalBidUnits
+			[]byte("4"),     // sellerUserId
+			[]byte("6"),     // transactionBuyID
+			[]byte("7"),     // transactionSellID
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		assert.Equal(t, 1.5, recordPayment("P2", "100").PlatformFee, "PlatformFee mismatch")
+		assert.Equal(t, 1.0, recordPayment("P3", "10").PlatformFee, "PlatformFee mismatch")
+	})
+
+	// Test Case 5: Proposals are decoded strictly and checked field by field
+	t.Run("Invalid Proposal", func(t *testing.T) {
+		response := stub.MockInvoke("8", [][]byte{[]byte("ProposeFeeSchedule"), []byte(`{"percentFee":2,"status":"Active"}`)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), `"field":"status","message":"unknown field"`)
+
+		response = stub.MockInvoke("9", [][]byte{[]byte("ProposeFeeSchedule"), []byte(`{"percentFee":120,"flatFee":-1}`)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), `"field":"flatFee"`)
+		assert.Contains(t, response.GetMessage(), `"field":"percentFee"`)
+	})
+
+	// Test Case 6: A match counts towards the tier once however often it is executed,
+	// and a rejected match no longer counts
+	t.Run("Volume Follows Match Status", func(t *testing.T) {
+		bidMatch := func(status string) [][]byte {
+			return [][]byte{
+				[]byte("ProcessBidMatch"), []byte("1"), []byte("Slot1"), []byte(status), []byte("5"), []byte("3"),
+				[]byte("150"), []byte("1"), []byte("150"), []byte("4"), []byte("6"), []byte("7"),
+			}
+		}
+		readVolume := func() float64 {
+			now, err := stub.GetTxTimestamp()
+			assert.NoError(t, err, "Error reading timestamp")
+			volume, err := getMonthlyVolume(stub, 3, monthOf(now.GetSeconds()))
+			assert.NoError(t, err, "Error reading monthly volume")
+			return volume.Volume
+		}
+
+		for i, status := range []string{"1", "3"} {
+			response := invokeAsAdmin(t, stub, strconv.Itoa(10+i), bidMatch(status))
+			assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		}
+		assert.Equal(t, 150.0, readVolume(), "Re-executed match counted twice")
+
+		response := invokeAsAdmin(t, stub, "12", bidMatch("2"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Equal(t, 0.0, readVolume(), "Rejected match still counted")
+		assert.Equal(t, 3.5, recordPayment("P4", "100").PlatformFee, "PlatformFee mismatch")
+	})
+
+	// Test Case 7: A payer without a profile pays the base rate, but an unreadable profile fails the payment
+	t.Run("Payer Profile", func(t *testing.T) {
+		paymentArgs := func(paymentID string, userID string) [][]byte {
+			return [][]byte{[]byte("RecordPayment"), []byte(paymentID), []byte("Buyer - Energy Purchased"),
+				[]byte("100"), []byte(userID), []byte("wallet"), []byte("platform"), []byte("100"), []byte("0"), []byte("0"),
+				[]byte("0"), []byte("0"), []byte("0")}
+		}
+		response := stub.MockInvoke("13", paymentArgs("P5", "9"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var payment Payment
+		paymentAsBytes, _ := stub.GetState("Payment_P5")
+		err := json.Unmarshal(paymentAsBytes, &payment)
+		assert.NoError(t, err, "Error unmarshalling payment")
+		var detail PaymentDetail
+		detailAsBytes, _ := stub.GetState("PaymentDetail_" + strconv.FormatInt(payment.PaymentDetailId, 10))
+		err = json.Unmarshal(detailAsBytes, &detail)
+		assert.NoError(t, err, "Error unmarshalling payment detail")
+		assert.Equal(t, 2.5, detail.PlatformFee, "PlatformFee mismatch")
+
+		stub.MockTransactionStart("corruptUser")
+		_ = stub.PutState("8", []byte("not a user"))
+		stub.MockTransactionEnd("corruptUser")
+		response = stub.MockInvoke("14", paymentArgs("P6", "8"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Failed to unmarshal user")
+		paymentAsBytes, _ = stub.GetState("Payment_P6")
+		assert.Nil(t, paymentAsBytes, "Payment stored without a fee")
+	})
+
+	// Test Case 8: A payment ID is recorded once
+	t.Run("Payment Not Overwritten", func(t *testing.T) {
+		response := stub.MockInvoke("15", [][]byte{[]byte("RecordPayment"), []byte("P1"), []byte("Buyer - Energy Purchased"),
+			[]byte("1"), []byte("3"), []byte("wallet-3"), []byte("platform"), []byte("1"), []byte("0"), []byte("0"),
+			[]byte("0"), []byte("0"), []byte("0")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "CONFLICT")
+	})
+}
@@ -0,0 +1,211 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// AssetHistoryEntry is one write of a record, as returned by ReadAssetHistory.
+// Value is the record as it was stored, at the schema version of the time, and
+// is empty for deletions.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type AssetHistoryEntry struct {
+	IsDelete  bool            `json:"isDelete"`
+	Timestamp int64           `json:"timestamp"`
+	TxID      string          `json:"txId"`
+	Value     json.RawMessage `json:"value,omitempty"`
+}
+
+// AssetPage is one page of QueryAssets. Each asset is reported as an AssetChange
+// holding its current value. An empty Bookmark means there are no more records.
+type AssetPage struct {
+	Assets   []AssetChange `json:"assets"`
+	Bookmark string        `json:"bookmark"`
+	Kind     string        `json:"kind"`
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// assetKeyFromArgs resolves the (kind[, id]) arguments of the generic asset reads
+// to the kind and its state key. The ID is required unless the kind is a singleton.
+func assetKeyFromArgs(args []string) (string, string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return "", "", errcode.New(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 or 2.")
+	}
+	kind := args[0]
+	schema, ok := assetSchemas[kind]
+	if !ok {
+		return "", "", errcode.New(errcode.InvalidArgument, "Unknown asset kind "+kind+".")
+	}
+	id := ""
+	if len(args) == 2 {
+		id = args[1]
+	}
+	key := schema.key(id)
+	if key == schema.Prefix && id == "" {
+		return "", "", errcode.New(errcode.InvalidArgument, "An ID is required to read a "+kind+".")
+	}
+	return kind, key, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            Asset Read Methods                              */
+/* -------------------------------------------------------------------------- */
+
+// ============================================================================================================================
+// ReadAsset() - any stored record by its kind and ID, upgraded to the current schema
+//
+// Inputs - Array of strings
+//     0   ,  1
+//    kind ,  id
+//  "Order", "4"
+// The ID is omitted for singleton kinds such as "SettlementConfig".
+// ============================================================================================================================
+func ReadAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	kind, key, err := assetKeyFromArgs(args)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	assetAsBytes, err := stub.GetState(key)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to fetch "+key+" from the ledger: "+err.Error())
+	}
+	if assetAsBytes == nil {
+		return errorResponse(errcode.NotFound, kind+" with key "+key+" not found.")
+	}
+
+	assetAsBytes, err = upgradeAsset(kind, assetAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(assetAsBytes)
+}
+
+// ============================================================================================================================
+// QueryAssets() - up to pageSize records of one asset kind in key order, upgraded to the current schema
+//
+// Pass the returned bookmark back in to continue with the next page; an empty bookmark starts from the first record.
+// Off-chain indexes use it to load the records written before they started following AssetsChanged events.
+//
+// Inputs - Array of strings
+//     0   ,     1     ,     2
+//    kind ,  pageSize ,  bookmark
+//  "Order",   "100"   ,   ""
+// ============================================================================================================================
+func QueryAssets(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 3.")
+	}
+
+	kind := args[0]
+	schema, ok := assetSchemas[kind]
+	if !ok {
+		return errorResponse(errcode.InvalidArgument, "Unknown asset kind "+kind+".")
+	}
+	pageSize, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse page size: "+err.Error())
+	}
+	if pageSize <= 0 {
+		return errorResponse(errcode.InvalidArgument, "Page size must be positive.")
+	}
+	startKey, err := schema.rangeStart(kind, args[2])
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	iterator, err := stub.GetStateByRange(startKey, schema.EndKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query "+kind+" records: "+err.Error())
+	}
+	defer iterator.Close()
+
+	page := AssetPage{Assets: []AssetChange{}, Kind: kind}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to read "+kind+" records: "+err.Error())
+		}
+		if int64(len(page.Assets)) == pageSize {
+			page.Bookmark = entry.Key
+			break
+		}
+		value, err := upgradeAsset(kind, entry.Value)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		page.Assets = append(page.Assets, AssetChange{ID: schema.id(entry.Key), Kind: kind, Value: value})
+	}
+
+	pageAsBytes, _ := json.Marshal(page)
+	return shim.Success(pageAsBytes)
+}
+
+// ============================================================================================================================
+// ReadAssetHistory() - every committed write of a record, oldest first
+//
+// Inputs - Array of strings
+//     0   ,  1
+//    kind ,  id
+//  "Order", "4"
+// The ID is omitted for singleton kinds such as "SettlementConfig".
+// ============================================================================================================================
+func ReadAssetHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	_, key, err := assetKeyFromArgs(args)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	iterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query the history of "+key+": "+err.Error())
+	}
+	defer iterator.Close()
+
+	history := []AssetHistoryEntry{}
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to read the history of "+key+": "+err.Error())
+		}
+		entry := AssetHistoryEntry{IsDelete: modification.IsDelete, TxID: modification.TxId}
+		if modification.Timestamp != nil {
+			entry.Timestamp = modification.Timestamp.Seconds
+		}
+		if !modification.IsDelete && json.Valid(modification.Value) {
+			entry.Value = modification.Value
+		}
+		history = append(history, entry)
+	}
+
+	historyAsBytes, _ := json.Marshal(history)
+	return shim.Success(historyAsBytes)
+}
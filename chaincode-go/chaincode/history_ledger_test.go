@@ -0,0 +1,176 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// historyStub serves a fixed key history, which shimtest.MockStub does not implement.
+type historyStub struct {
+	*shimtest.MockStub
+	history map[string][]*queryresult.KeyModification
+}
+
+func (stub *historyStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{modifications: stub.history[key]}, nil
+}
+
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+}
+
+func (iterator *historyIterator) HasNext() bool {
+	return len(iterator.modifications) > 0
+}
+
+func (iterator *historyIterator) Next() (*queryresult.KeyModification, error) {
+	modification := iterator.modifications[0]
+	iterator.modifications = iterator.modifications[1:]
+	return modification, nil
+}
+
+func (iterator *historyIterator) Close() error {
+	return nil
+}
+
+func TestReadAsset(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	stub.MockTransactionStart("seedAssets")
+	_ = stub.PutState("Order_1", []byte(`{"id":1,"status":500,"totalQuantity":100,"unitCost":5,"userId":3}`))
+	_ = stub.PutState("3", []byte(`{"id":3,"identity":"Org1MSP:alice","schemaVersion":1}`))
+	_ = stub.PutState(settlementConfigKey, []byte(`{"netPerUser":true,"penaltyPct":10,"schemaVersion":1}`))
+	stub.MockTransactionEnd("seedAssets")
+
+	// Test Case 1: Records are read by kind and ID, upgraded to the current schema
+	t.Run("Read Order", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("ReadAsset"), []byte("Order"), []byte("1")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var order Order
+		err := json.Unmarshal(response.GetPayload(), &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, 500.0, order.OrderCost, "OrderCost not migrated")
+	})
+
+	// Test Case 2: Users are keyed by their bare ID, singletons by their kind alone
+	t.Run("Read User And Singleton", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("ReadAsset"), []byte("User"), []byte("3")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Contains(t, string(response.GetPayload()), "Org1MSP:alice")
+
+		response = stub.MockInvoke("3", [][]byte{[]byte("ReadAsset"), []byte("SettlementConfig")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Contains(t, string(response.GetPayload()), `"penaltyPct":10`)
+	})
+
+	// Test Case 3: Unknown kinds, missing IDs and missing records are refused with their codes
+	t.Run("Refused Reads", func(t *testing.T) {
+		for _, test := range []struct {
+			args []string
+			code errcode.Code
+		}{
+			{[]string{"Widget", "1"}, errcode.InvalidArgument},
+			{[]string{"Order"}, errcode.InvalidArgument},
+			{[]string{"User"}, errcode.InvalidArgument},
+			{[]string{"Order", "2"}, errcode.NotFound},
+		} {
+			args := [][]byte{[]byte("ReadAsset")}
+			for _, arg := range test.args {
+				args = append(args, []byte(arg))
+			}
+			response := stub.MockInvoke("4", args)
+			assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded for %v", test.args)
+			parsed, ok := errcode.Parse(response.GetMessage())
+			assert.True(t, ok, "Response is not a structured error: %s", response.GetMessage())
+			assert.Equal(t, test.code, parsed.Code, "Code mismatch for %v", test.args)
+		}
+	})
+}
+
+func TestReadAssetHistory(t *testing.T) {
+	stub := &historyStub{
+		MockStub: shimtest.NewMockStub("testingStub", new(SimpleChaincode)),
+		history: map[string][]*queryresult.KeyModification{
+			"Order_1": {
+				{TxId: "tx1", Timestamp: &timestamppb.Timestamp{Seconds: 1700000000}, Value: []byte(`{"id":1,"unitCost":5}`)},
+				{TxId: "tx2", Timestamp: &timestamppb.Timestamp{Seconds: 1700000100}, Value: []byte(`{"id":1,"unitCost":6}`)},
+				{TxId: "tx3", Timestamp: &timestamppb.Timestamp{Seconds: 1700000200}, IsDelete: true},
+			},
+		},
+	}
+
+	response := ReadAssetHistory(stub, []string{"Order", "1"})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	var history []AssetHistoryEntry
+	err := json.Unmarshal(response.GetPayload(), &history)
+	assert.NoError(t, err, "Error unmarshalling history")
+	assert.Equal(t, []AssetHistoryEntry{
+		{Timestamp: 1700000000, TxID: "tx1", Value: json.RawMessage(`{"id":1,"unitCost":5}`)},
+		{Timestamp: 1700000100, TxID: "tx2", Value: json.RawMessage(`{"id":1,"unitCost":6}`)},
+		{IsDelete: true, Timestamp: 1700000200, TxID: "tx3"},
+	}, history)
+
+	response = ReadAssetHistory(stub, []string{"Order", "2"})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	assert.Equal(t, "[]", string(response.GetPayload()))
+}
+
+func TestQueryAssets(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+
+	stub.MockTransactionStart("seedAssets")
+	_ = stub.PutState("Order_1", []byte(`{"id":1,"status":500,"totalQuantity":100,"unitCost":5,"userId":3}`))
+	_ = stub.PutState("Order_2", []byte(`{"id":2,"totalQuantity":50,"unitCost":4,"userId":3,"schemaVersion":1}`))
+	_ = stub.PutState("Order_3", []byte(`{"id":3,"totalQuantity":20,"unitCost":6,"userId":4,"schemaVersion":1}`))
+	_ = stub.PutState("3", []byte(`{"id":3,"identity":"Org1MSP:alice","schemaVersion":1}`))
+	stub.MockTransactionEnd("seedAssets")
+
+	queryPage := func(kind string, pageSize string, bookmark string) AssetPage {
+		response := stub.MockInvoke("1", [][]byte{[]byte("QueryAssets"), []byte(kind), []byte(pageSize), []byte(bookmark)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var page AssetPage
+		err := json.Unmarshal(response.GetPayload(), &page)
+		assert.NoError(t, err, "Error unmarshalling page")
+		return page
+	}
+
+	// Test Case 1: Records are paged in key order, upgraded to the current schema
+	t.Run("Paged Orders", func(t *testing.T) {
+		page := queryPage("Order", "2", "")
+		assert.Len(t, page.Assets, 2, "Page size mismatch")
+		assert.Equal(t, "1", page.Assets[0].ID, "ID mismatch")
+		assert.Equal(t, "Order", page.Assets[0].Kind, "Kind mismatch")
+		assert.Contains(t, string(page.Assets[0].Value), `"orderCost":500`, "Order not upgraded")
+		assert.Equal(t, "Order_3", page.Bookmark, "Bookmark mismatch")
+
+		page = queryPage("Order", "2", page.Bookmark)
+		assert.Len(t, page.Assets, 1, "Last page size mismatch")
+		assert.Equal(t, "3", page.Assets[0].ID, "ID mismatch")
+		assert.Empty(t, page.Bookmark, "Last page has a bookmark")
+	})
+
+	// Test Case 2: Users are found under their bare ID
+	t.Run("Users", func(t *testing.T) {
+		page := queryPage("User", "10", "")
+		assert.Len(t, page.Assets, 1, "Page size mismatch")
+		assert.Equal(t, "3", page.Assets[0].ID, "ID mismatch")
+	})
+
+	// Test Case 3: Bookmarks outside the kind's keys are refused
+	t.Run("Foreign Bookmark", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("QueryAssets"), []byte("Order"), []byte("10"), []byte("Payment_1")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		parsed, ok := errcode.Parse(response.GetMessage())
+		assert.True(t, ok, "Response is not a structured error: %s", response.GetMessage())
+		assert.Equal(t, errcode.InvalidArgument, parsed.Code, "Code mismatch")
+	})
+}
@@ -0,0 +1,180 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// ==============================================================
+// Input Sanitation - dumb input checking, look for empty strings
+// ==============================================================
+func sanitize_arguments(strs []string) error {
+	for i, val := range strs {
+		if len(val) <= 0 {
+			return errors.New("Argument " + strconv.Itoa(i) + " must be a non-empty string")
+		}
+		if len(val) > 256 {
+			errMsg := "Argument " + strconv.Itoa(i) + " must be <= 256 characters"
+			return errors.New(errMsg)
+		}
+	}
+	return nil
+}
+
+// ==============================================================
+// Client Identity - who submitted the transaction
+// ==============================================================
+
+// MSP whose members administer the marketplace, overridable per deployment
+const defaultPlatformAdminMSP = "Org1MSP"
+
+func platformAdminMSP() string {
+	if mspID := os.Getenv("PLATFORM_ADMIN_MSP"); mspID != "" {
+		return mspID
+	}
+	return defaultPlatformAdminMSP
+}
+
+// callerIdentity returns the submitter as "<MSP ID>:<certificate common name>"
+func callerIdentity(stub shim.ChaincodeStubInterface) (string, error) {
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return "", errors.New("Failed to read caller MSP: " + err.Error())
+	}
+	cert, err := cid.GetX509Certificate(stub)
+	if err != nil {
+		return "", errors.New("Failed to read caller certificate: " + err.Error())
+	}
+	if cert == nil {
+		return "", errcode.New(errcode.Forbidden, "Caller has no X.509 certificate")
+	}
+	return mspID + ":" + cert.Subject.CommonName, nil
+}
+
+func assertPlatformAdmin(stub shim.ChaincodeStubInterface) error {
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return errors.New("Failed to read caller MSP: " + err.Error())
+	}
+	if mspID != platformAdminMSP() {
+		return errcode.New(errcode.Forbidden, "Caller from "+mspID+" is not a platform admin")
+	}
+	return nil
+}
+
+// ==============================================================
+// Transaction time - the proposal timestamp, identical on every endorser
+// ==============================================================
+func txTimestamp(stub shim.ChaincodeStubInterface) (int64, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, errors.New("Failed to read transaction timestamp: " + err.Error())
+	}
+	return timestamp.GetSeconds(), nil
+}
+
+// ==============================================================
+// Transaction IDs - numeric IDs derived from the transaction ID, identical on every endorser
+// ==============================================================
+// txSequenceID gives the seq-th ID a transaction assigns. Unlike the transaction time, the
+// transaction ID is a hash the client cannot steer onto the IDs of another transaction.
+func txSequenceID(stub shim.ChaincodeStubInterface, seq int) int64 {
+	sum := sha256.Sum256([]byte(stub.GetTxID() + "_" + strconv.Itoa(seq)))
+	return int64(binary.BigEndian.Uint64(sum[:8]) >> 1)
+}
+
+// ==============================================================
+// Key ranges - start and end keys covering every key with a prefix
+// ==============================================================
+func prefixRange(prefix string) (string, string) {
+	return prefix, prefix + string(utf8.MaxRune)
+}
+
+// ==============================================================
+// Arithmetic functions to check for overflow and underflow
+// ==============================================================
+
+// add two number checking for overflow
+func add(b int, q int) (int, error) {
+
+	// Check overflow
+	var sum int
+	sum = q + b
+
+	if (sum < q) == (b >= 0 && q >= 0) {
+		return 0, fmt.Errorf("Math: addition overflow occurred %d + %d", b, q)
+	}
+
+	return sum, nil
+}
+
+// sub two number checking for overflow
+func sub(b int, q int) (int, error) {
+
+	// Check overflow
+	var diff int
+	diff = b - q
+
+	if (diff > b) == (b >= 0 && q >= 0) {
+		return 0, fmt.Errorf("Math: Subtraction overflow occurred  %d - %d", b, q)
+	}
+
+	return diff, nil
+}
+
+// add two float numbers checking for overflow
+func addFloat(b float32, q float32) (float32, error) {
+
+	// Check overflow
+	var sum float32
+	sum = q + b
+
+	if (sum < q) == (b >= 0 && q >= 0) {
+		return 0, fmt.Errorf("Math: addition overflow occurred %f + %f", b, q)
+	}
+
+	return sum, nil
+}
+
+// sub two float numbers checking for overflow
+func subFloat(b float32, q float32) (float32, error) {
+
+	// Check overflow
+	var diff float32
+	diff = b - q
+
+	if (diff > b) == (b >= 0 && q >= 0) {
+		return 0, fmt.Errorf("Math: Subtraction overflow occurred  %f - %f", b, q)
+	}
+
+	return diff, nil
+}
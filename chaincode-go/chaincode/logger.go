@@ -0,0 +1,176 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Log entries are written one JSON object per line, for the peer's log
+// collector to pick up from the chaincode's stdout:
+//
+//	{"channel":"mychannel","function":"ReadOrder","level":"INFO","msg":"invoke succeeded","mspId":"Org1MSP","ts":"...","txId":"..."}
+//
+// CHAINCODE_LOG_LEVEL (DEBUG, INFO, WARNING or ERROR) sets the lowest level
+// written, INFO by default.
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarning
+	levelError
+)
+
+var logLevelNames = []string{"DEBUG", "INFO", "WARNING", "ERROR"}
+
+func (l logLevel) String() string {
+	return enumString("logLevel", logLevelNames, int64(l))
+}
+
+// logFields are the key/values of one log entry.
+type logFields map[string]interface{}
+
+var (
+	logMutex    sync.Mutex
+	logOutput   io.Writer = os.Stdout
+	minLogLevel           = parseLogLevel(os.Getenv("CHAINCODE_LOG_LEVEL"))
+)
+
+// SetLogOutput redirects the log entries, e.g. to os.Stderr or io.Discard when the
+// chaincode runs in process behind a tool whose stdout carries its own output.
+func SetLogOutput(w io.Writer) {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	logOutput = w
+}
+
+// parseLogLevel reads a level name, falling back to INFO for empty or unknown names.
+func parseLogLevel(name string) logLevel {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "WARN" {
+		return levelWarning
+	}
+	for i, levelName := range logLevelNames {
+		if name == levelName {
+			return logLevel(i)
+		}
+	}
+	return levelInfo
+}
+
+// writeLogEntry writes one entry at level with the fields of every set, later
+// sets taking precedence.
+func writeLogEntry(level logLevel, msg string, fieldSets ...logFields) {
+	if level < minLogLevel {
+		return
+	}
+	entry := logFields{}
+	for _, fields := range fieldSets {
+		for key, value := range fields {
+			entry[key] = value
+		}
+	}
+	entry["level"] = level.String()
+	entry["msg"] = msg
+	entry["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	entryAsBytes, err := json.Marshal(entry)
+	if err != nil {
+		entryAsBytes, _ = json.Marshal(logFields{"level": levelError.String(), "msg": "Failed to marshal log entry: " + err.Error()})
+	}
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	logOutput.Write(append(entryAsBytes, '\n'))
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              Transaction Logger                            */
+/* -------------------------------------------------------------------------- */
+
+// txLogger tags every entry with the transaction it is logged for.
+type txLogger struct {
+	fields logFields
+}
+
+// newTxLogger returns a logger for the stub's current transaction. The caller
+// MSP is left out when the creator cannot be read.
+func newTxLogger(stub shim.ChaincodeStubInterface) *txLogger {
+	function, _ := stub.GetFunctionAndParameters()
+	fields := logFields{
+		"channel":  stub.GetChannelID(),
+		"function": function,
+		"txId":     stub.GetTxID(),
+	}
+	if mspID, err := cid.GetMSPID(stub); err == nil {
+		fields["mspId"] = mspID
+	}
+	return &txLogger{fields: fields}
+}
+
+func (l *txLogger) Debug(msg string, fields logFields) {
+	writeLogEntry(levelDebug, msg, l.fields, fields)
+}
+
+func (l *txLogger) Info(msg string, fields logFields) {
+	writeLogEntry(levelInfo, msg, l.fields, fields)
+}
+
+func (l *txLogger) Warning(msg string, fields logFields) {
+	writeLogEntry(levelWarning, msg, l.fields, fields)
+}
+
+func (l *txLogger) Error(msg string, fields logFields) {
+	writeLogEntry(levelError, msg, l.fields, fields)
+}
+
+// Response logs the outcome of an invoke. Refusals the client can act on are
+// warnings; INTERNAL and unstructured failures are errors.
+func (l *txLogger) Response(response pb.Response, elapsed time.Duration) {
+	fields := logFields{"durationMs": elapsed.Milliseconds(), "status": response.Status}
+	if response.Status < shim.ERRORTHRESHOLD {
+		l.Info("invoke succeeded", fields)
+		return
+	}
+
+	chaincodeErr, ok := errcode.Parse(response.Message)
+	if !ok {
+		chaincodeErr = errcode.New(errcode.Unknown, response.Message)
+	}
+	fields["code"] = chaincodeErr.Code
+	fields["error"] = chaincodeErr.Message
+	if chaincodeErr.Code == errcode.Internal || chaincodeErr.Code == errcode.Unknown {
+		l.Error("invoke failed", fields)
+	} else {
+		l.Warning("invoke failed", fields)
+	}
+}
@@ -0,0 +1,87 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureLogs collects the entries logged at or above level until the test ends.
+func captureLogs(t *testing.T, level logLevel) *bytes.Buffer {
+	var buffer bytes.Buffer
+	previousOutput, previousLevel := logOutput, minLogLevel
+	logOutput, minLogLevel = &buffer, level
+	t.Cleanup(func() { logOutput, minLogLevel = previousOutput, previousLevel })
+	return &buffer
+}
+
+func logEntries(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		err := json.Unmarshal([]byte(line), &entry)
+		assert.NoError(t, err, "Log line is not JSON: "+line)
+		entries = append(entries, entry)
+	}
+	buffer.Reset()
+	return entries
+}
+
+func TestTxLogger(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	stub.ChannelID = "mychannel"
+	setCreator(t, stub, "Org1MSP", "admin")
+	seedTradingSlot(t, stub, "slot1")
+
+	// Test Case 1: Entries carry the transaction, function and caller MSP
+	t.Run("Transaction Context", func(t *testing.T) {
+		buffer := captureLogs(t, levelDebug)
+		stub.MockInvoke("tx1", [][]byte{[]byte("ReadTradingSlot"), []byte("slot1")})
+
+		entries := logEntries(t, buffer)
+		assert.Len(t, entries, 2, "Unexpected number of entries")
+		assert.Equal(t, "DEBUG", entries[0]["level"], "Level mismatch")
+		assert.Equal(t, "INFO", entries[1]["level"], "Level mismatch")
+		for _, entry := range entries {
+			assert.Equal(t, "tx1", entry["txId"], "TxId mismatch")
+			assert.Equal(t, "mychannel", entry["channel"], "Channel mismatch")
+			assert.Equal(t, "ReadTradingSlot", entry["function"], "Function mismatch")
+			assert.Equal(t, "Org1MSP", entry["mspId"], "MspId mismatch")
+			assert.NotEmpty(t, entry["ts"], "Missing timestamp")
+		}
+	})
+
+	// Test Case 2: Failures are logged with their error code, at a level matching it
+	t.Run("Failed Invoke", func(t *testing.T) {
+		buffer := captureLogs(t, levelInfo)
+		stub.MockInvoke("tx2", [][]byte{[]byte("ReadTradingSlot"), []byte("slot404")})
+
+		entries := logEntries(t, buffer)
+		assert.Len(t, entries, 1, "Unexpected number of entries")
+		assert.Equal(t, "WARNING", entries[0]["level"], "Level mismatch")
+		assert.Equal(t, "NOT_FOUND", entries[0]["code"], "Code mismatch")
+		assert.Contains(t, entries[0]["error"], "slot404")
+	})
+
+	// Test Case 3: Entries below the configured level are dropped
+	t.Run("Level Filter", func(t *testing.T) {
+		buffer := captureLogs(t, levelError)
+		stub.MockInvoke("tx3", [][]byte{[]byte("ReadTradingSlot"), []byte("slot1")})
+		assert.Empty(t, logEntries(t, buffer), "Entries logged below the level")
+	})
+}
+
+func TestParseLogLevel(t *testing.T) {
+	assert.Equal(t, levelDebug, parseLogLevel("debug"))
+	assert.Equal(t, levelWarning, parseLogLevel(" WARN "))
+	assert.Equal(t, levelError, parseLogLevel("ERROR"))
+	assert.Equal(t, levelInfo, parseLogLevel(""), "Empty level should default to INFO")
+	assert.Equal(t, levelInfo, parseLogLevel("verbose"), "Unknown level should default to INFO")
+}
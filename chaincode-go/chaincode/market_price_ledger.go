@@ -0,0 +1,318 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Band applied to order prices until SetMarketOracleConfig sets one.
+const defaultPriceBandPct = 20
+
+const marketOracleConfigKey = "MarketOracleConfig"
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func getMarketOracleConfig(stub shim.ChaincodeStubInterface) (MarketOracleConfig, error) {
+	config := MarketOracleConfig{Oracles: []string{}, PriceBandPct: defaultPriceBandPct}
+	configAsBytes, err := stub.GetState(marketOracleConfigKey)
+	if err != nil {
+		return config, errors.New("Error accessing state: " + err.Error())
+	}
+	if configAsBytes == nil {
+		return config, nil
+	}
+	err = decodeAsset(configAsBytes, &config)
+	if err != nil {
+		return config, errors.New("Failed to unmarshal market oracle config: " + err.Error())
+	}
+	return config, nil
+}
+
+func getMarketPrice(stub shim.ChaincodeStubInterface, slotID string) (MarketPrice, error) {
+	var price MarketPrice
+	priceAsBytes, err := stub.GetState("MarketPrice_" + slotID)
+	if err != nil {
+		return price, errors.New("Error accessing state: " + err.Error())
+	}
+	if priceAsBytes == nil {
+		return price, errcode.New(errcode.NotFound, "No market price published for slot "+slotID)
+	}
+	err = decodeAsset(priceAsBytes, &price)
+	if err != nil {
+		return price, errors.New("Failed to unmarshal market price: " + err.Error())
+	}
+	return price, nil
+}
+
+// checkPriceBand rejects unit costs further than the configured band from the slot's market price.
+func checkPriceBand(stub shim.ChaincodeStubInterface, price MarketPrice, unitCost float64) error {
+	config, err := getMarketOracleConfig(stub)
+	if err != nil {
+		return err
+	}
+	// NaN compares false against both bounds, so non-finite costs are refused first.
+	if math.IsNaN(unitCost) || math.IsInf(unitCost, 0) {
+		return errcode.New(errcode.InvalidArgument, fmt.Sprintf("Unit cost %v is not a finite number", unitCost))
+	}
+	low := price.Price * (1 - config.PriceBandPct/100)
+	high := price.Price * (1 + config.PriceBandPct/100)
+	if unitCost < low || unitCost > high {
+		return errcode.New(errcode.InvalidArgument, fmt.Sprintf("Unit cost %v is outside the allowed band %v - %v around the market price of slot %s", unitCost, low, high, price.SlotID))
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Market Price Write Methods                        */
+/* -------------------------------------------------------------------------- */
+
+// parseMarketOracleConfigRequest reads the positional arguments of SetMarketOracleConfig.
+func parseMarketOracleConfigRequest(args []string) (MarketOracleConfigRequest, error) {
+	var request MarketOracleConfigRequest
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.PriceBandPct, err = strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse price band: "+err.Error())
+	}
+	request.Oracles = args[1:]
+	return request, validateRequest(&request)
+}
+
+func (request *MarketOracleConfigRequest) validate() []FieldError {
+	var fieldErrors []FieldError
+	if len(request.Oracles) == 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "oracles", Message: "must not be empty"})
+	}
+	for _, oracle := range request.Oracles {
+		if oracle == "" || len(oracle) > 256 {
+			fieldErrors = append(fieldErrors, FieldError{Field: "oracles", Message: "entries must be 1 to 256 characters"})
+			break
+		}
+	}
+	if request.PriceBandPct < 0 || request.PriceBandPct > 100 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "priceBandPct", Message: "must be between 0 and 100"})
+	}
+	return fieldErrors
+}
+
+// ============================================================================================================================
+// SetMarketOracleConfig() - platform admin sets the price band and the oracle identities
+//
+// Inputs - Array of strings
+//                          0
+//                       payload
+//  "{"priceBandPct": 20, "oracles": ["Org2MSP:feed"]}"
+// The positional form (priceBandPct, oracle, oracle, ...) is still accepted.
+// ============================================================================================================================
+func SetMarketOracleConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request MarketOracleConfigRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) >= 2 {
+		request, err = parseMarketOracleConfigRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or at least 2.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	config := MarketOracleConfig{
+		Oracles:      request.Oracles,
+		PriceBandPct: request.PriceBandPct,
+		UpdatedOn:    now,
+	}
+
+	configAsBytes, err := encodeAsset(&config)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal market oracle config: "+err.Error())
+	}
+	err = stub.PutState(marketOracleConfigKey, configAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store market oracle config: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// parseMarketPriceRequest reads the positional arguments of PublishMarketPrice.
+func parseMarketPriceRequest(args []string) (MarketPriceRequest, error) {
+	var request MarketPriceRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.SlotID = args[0]
+	request.Price, err = strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse price: "+err.Error())
+	}
+	request.Source = args[2]
+	request.Timestamp, err = strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse timestamp: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *MarketPriceRequest) validate() []FieldError {
+	fieldErrors := nonEmpty(nil, map[string]string{"slotId": request.SlotID, "source": request.Source})
+	if request.Price <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "price", Message: "must be positive"})
+	}
+	return fieldErrors
+}
+
+// ============================================================================================================================
+// PublishMarketPrice() - oracle publishes the reference price of a slot
+//
+// Inputs - Array of strings
+//                                        0
+//                                     payload
+//  "{"slotId": "slot1234", "price": 3.5, "source": "IEX-DAM", "timestamp": 1700000000}"
+// The positional form (slotID, price, source, timestamp) is still accepted.
+// ============================================================================================================================
+func PublishMarketPrice(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request MarketPriceRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 4 {
+		request, err = parseMarketPriceRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 4.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	identity, err := callerIdentity(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	config, err := getMarketOracleConfig(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	authorised := false
+	for _, oracle := range config.Oracles {
+		if oracle == identity {
+			authorised = true
+			break
+		}
+	}
+	if !authorised {
+		return errorResponse(errcode.Forbidden, "Caller "+identity+" is not an authorised market oracle")
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	marketPrice := MarketPrice{
+		Price:       request.Price,
+		PublishedBy: identity,
+		SlotID:      request.SlotID,
+		Source:      request.Source,
+		Timestamp:   request.Timestamp,
+		UpdatedOn:   now,
+	}
+
+	marketPriceAsBytes, err := encodeAsset(&marketPrice)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal market price: "+err.Error())
+	}
+	err = stub.PutState("MarketPrice_"+marketPrice.SlotID, marketPriceAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store market price: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Market Price Read Methods                         */
+/* -------------------------------------------------------------------------- */
+
+func ReadMarketOracleConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 0 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 0.")
+	}
+
+	config, err := getMarketOracleConfig(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	configAsBytes, _ := json.Marshal(config)
+	return shim.Success(configAsBytes)
+}
+
+func ReadMarketPrice(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the slot ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	marketPriceAsBytes, err := stub.GetState("MarketPrice_" + args[0])
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to fetch MarketPrice for slot "+args[0]+" from the ledger: "+err.Error())
+	}
+
+	if marketPriceAsBytes == nil {
+		return errorResponse(errcode.NotFound, "MarketPrice for slot "+args[0]+" not found.")
+	}
+
+	marketPriceAsBytes, err = upgradeAsset("MarketPrice", marketPriceAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(marketPriceAsBytes)
+}
@@ -0,0 +1,114 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishMarketPrice(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	seedTradingSlot(t, stub, "slot1234")
+	seedTradingSlot(t, stub, "slot9999")
+	seedUser(t, stub, 6, "Org2MSP:user6")
+
+	// Test Case 1: Only the platform admin org configures the oracle
+	t.Run("Non-admin Cannot Configure Oracle", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user1")
+		response := stub.MockInvoke("1", [][]byte{[]byte("SetMarketOracleConfig"), []byte("10"), []byte("Org2MSP:feed")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not a platform admin")
+	})
+
+	// Test Case 2: Authorised oracle publishes a price
+	t.Run("Authorised Oracle Publishes Price", func(t *testing.T) {
+		setCreator(t, stub, "Org1MSP", "admin")
+		response := stub.MockInvoke("2", [][]byte{[]byte("SetMarketOracleConfig"), []byte("10"), []byte("Org2MSP:feed")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		setCreator(t, stub, "Org2MSP", "feed")
+		response = stub.MockInvoke("3", [][]byte{[]byte("PublishMarketPrice"), []byte("slot1234"), []byte("4"), []byte("IEX-DAM"), []byte("1700000000")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("4", [][]byte{[]byte("ReadMarketPrice"), []byte("slot1234")})
+		var price MarketPrice
+		err := json.Unmarshal(response.GetPayload(), &price)
+		assert.NoError(t, err, "Error unmarshalling market price")
+		assert.Equal(t, 4.0, price.Price, "Price mismatch")
+		assert.Equal(t, "Org2MSP:feed", price.PublishedBy, "PublishedBy mismatch")
+	})
+
+	// Test Case 3: Any other identity is refused
+	t.Run("Unauthorised Identity Cannot Publish", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user1")
+		response := stub.MockInvoke("5", [][]byte{[]byte("PublishMarketPrice"), []byte("slot1234"), []byte("1"), []byte("IEX-DAM"), []byte("1700000000")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not an authorised market oracle")
+	})
+
+	order := func(orderID string, unitCost string, slotID string) [][]byte {
+		return [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),      // bidMatchID
+			[]byte("0"),      // bidStatus
+			[]byte(orderID),  // orderID
+			[]byte("999"),    // onMarketPrice, ignored
+			[]byte("200"),    // orderCost
+			[]byte("5"),      // paymentID
+			[]byte(slotID),   // slotID
+			[]byte("300"),    // totalQuantity
+			[]byte(unitCost), // unitCost
+			[]byte("6"),      // userID
+			[]byte("50"),     // slotExecDate
+			[]byte("0"),      // action
+		}
+	}
+
+	// Test Case 4: RegisterOrder takes OnMarketPrice from the oracle
+	t.Run("Order Uses Oracle Price", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user6")
+		response := stub.MockInvoke("6", order("4", "4.2", "slot1234"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		orderAsBytes, err := stub.GetState("Order_4")
+		assert.NoError(t, err, "Error getting order from ledger")
+		var stored Order
+		err = json.Unmarshal(orderAsBytes, &stored)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, "4", stored.OnMarketPrice, "OnMarketPrice mismatch")
+	})
+
+	// Test Case 5: Prices outside the band and slots without a price are rejected
+	t.Run("Order Outside Band Or Without Price", func(t *testing.T) {
+		response := stub.MockInvoke("7", order("5", "4.5", "slot1234"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "outside the allowed band")
+
+		response = stub.MockInvoke("8", order("6", "4", "slot9999"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "No market price")
+	})
+
+	// Test Case 6: NaN and infinite unit costs are rejected, not let through the band
+	t.Run("Non-finite Unit Cost", func(t *testing.T) {
+		for i, unitCost := range []string{"NaN", "+Inf"} {
+			orderID := strconv.Itoa(7 + i)
+			response := stub.MockInvoke("9", order(orderID, unitCost, "slot1234"))
+			assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+			assert.Contains(t, response.GetMessage(), "must be a finite number")
+
+			orderAsBytes, _ := stub.GetState("Order_" + orderID)
+			assert.Nil(t, orderAsBytes, "Order stored with a non-finite unit cost")
+		}
+
+		err := checkPriceBand(stub, MarketPrice{Price: 4, SlotID: "slot1234"}, math.NaN())
+		assert.Error(t, err, "NaN unit cost inside the band")
+		assert.Contains(t, err.Error(), "not a finite number")
+	})
+}
@@ -0,0 +1,222 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Upper bound on the orders of one RegisterOrders call, keeping the proposal and
+// its write set well below the peer's message size limits.
+const maxOrderBatchSize = 500
+
+// State key of the OrderAggregatorConfig singleton
+const orderAggregatorConfigKey = "OrderAggregatorConfig"
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// orderBatchDetails reports why the order at index was refused, as details whose
+// field is the JSON path of the order, or of its offending field, and whose code
+// is the one the order would have failed with on its own.
+func orderBatchDetails(index int, err error) []FieldError {
+	path := "[" + strconv.Itoa(index) + "]"
+	var payloadErr *PayloadError
+	if errors.As(err, &payloadErr) {
+		details := make([]FieldError, 0, len(payloadErr.Fields))
+		for _, field := range payloadErr.Fields {
+			details = append(details, FieldError{Code: errcode.InvalidArgument, Field: path + "." + field.Field, Message: field.Message})
+		}
+		return details
+	}
+	code := errcode.Internal
+	var chaincodeErr *errcode.Error
+	if errors.As(err, &chaincodeErr) {
+		code = chaincodeErr.Code
+	}
+	return []FieldError{{Code: code, Field: path, Message: err.Error()}}
+}
+
+// isOrderAggregator tells whether the caller is one of the identities the platform
+// admin allowed to place orders on behalf of other users.
+func isOrderAggregator(stub shim.ChaincodeStubInterface) (bool, error) {
+	configAsBytes, err := stub.GetState(orderAggregatorConfigKey)
+	if err != nil {
+		return false, errors.New("Error accessing state: " + err.Error())
+	}
+	if configAsBytes == nil {
+		return false, nil
+	}
+	var config OrderAggregatorConfig
+	err = decodeAsset(configAsBytes, &config)
+	if err != nil {
+		return false, errors.New("Failed to unmarshal aggregator config: " + err.Error())
+	}
+	identity, err := callerIdentity(stub)
+	if err != nil {
+		return false, err
+	}
+	for _, aggregator := range config.Aggregators {
+		if aggregator == identity {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Order Batch Write Methods                         */
+/* -------------------------------------------------------------------------- */
+
+// ============================================================================================================================
+// RegisterOrders() - registers a batch of orders atomically: every order is stored, or none is
+//
+// Each order is decoded and validated exactly as a RegisterOrder JSON payload would be. Users batch their own orders; the aggregators set by
+// SetOrderAggregators batch orders of any user who has accepted the platform terms. If any order fails, the transaction is rejected with
+// every refused order in its details, e.g. field "[2]" or "[2].unitCost", each with the code that order failed with. The error takes the
+// code the refused orders share, or INVALID_ARGUMENT when they differ. On success the IDs of the stored orders are returned.
+//
+// Inputs - Array of strings
+//     0
+//   orders
+//  "[{"id":4,"slotId":"slot1","action":"Buy","totalQuantity":300,"unitCost":3.5,"orderCost":1050,"userId":6}, ...]"
+// ============================================================================================================================
+func RegisterOrders(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Items are decoded one by one so a malformed order is reported like any other.
+	var requests []json.RawMessage
+	err := json.Unmarshal([]byte(args[0]), &requests)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse orders: "+err.Error())
+	}
+	if len(requests) == 0 {
+		return errorResponse(errcode.InvalidArgument, "Order batch is empty.")
+	}
+	if len(requests) > maxOrderBatchSize {
+		return errorResponse(errcode.InvalidArgument, "Order batch of "+strconv.Itoa(len(requests))+" exceeds the maximum of "+strconv.Itoa(maxOrderBatchSize)+" orders.")
+	}
+
+	aggregator, err := isOrderAggregator(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	// Validate the whole batch before writing anything. Reads do not see this
+	// transaction's own writes, so an ID may only appear once.
+	orders := make([]Order, 0, len(requests))
+	details := []FieldError{}
+	seen := map[int64]bool{}
+	for i, requestAsBytes := range requests {
+		var request OrderRequest
+		err = decodePayload(string(requestAsBytes), &request)
+		if err != nil {
+			details = append(details, orderBatchDetails(i, err)...)
+			continue
+		}
+		if seen[request.ID] {
+			details = append(details, orderBatchDetails(i, errcode.New(errcode.InvalidArgument, "Order ID appears more than once in the batch"))...)
+			continue
+		}
+		seen[request.ID] = true
+
+		order, err := prepareOrder(stub, request, aggregator)
+		if err != nil {
+			details = append(details, orderBatchDetails(i, err)...)
+			continue
+		}
+		orders = append(orders, order)
+	}
+	if len(details) > 0 {
+		code := details[0].Code
+		for _, detail := range details {
+			if detail.Code != code {
+				code = errcode.InvalidArgument
+			}
+		}
+		rejected := errcode.New(code, "Order batch rejected")
+		rejected.Details = details
+		return errorResponseFrom(rejected)
+	}
+
+	orderIDs := make([]int64, 0, len(orders))
+	for i := range orders {
+		orderAsBytes, err := encodeAsset(&orders[i])
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to marshal order: "+err.Error())
+		}
+		err = stub.PutState("Order_"+strconv.FormatInt(orders[i].ID, 10), orderAsBytes)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Could not store order: "+err.Error())
+		}
+		orderIDs = append(orderIDs, orders[i].ID)
+	}
+
+	orderIDsAsBytes, _ := json.Marshal(orderIDs)
+	return shim.Success(orderIDsAsBytes)
+}
+
+// ============================================================================================================================
+// SetOrderAggregators() - platform admin replaces the list of identities allowed to batch orders on behalf of other users
+//
+// Inputs - Array of strings
+//           0..n
+//  "<MSP ID>:<common name>", ...
+// ============================================================================================================================
+func SetOrderAggregators(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	err := sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	config := OrderAggregatorConfig{
+		Aggregators: append([]string{}, args...),
+		UpdatedOn:   now,
+	}
+	configAsBytes, err := encodeAsset(&config)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal aggregator config: "+err.Error())
+	}
+	err = stub.PutState(orderAggregatorConfigKey, configAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store aggregator config: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
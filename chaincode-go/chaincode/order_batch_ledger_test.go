@@ -0,0 +1,161 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+func TestRegisterOrders(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	seedTradingSlot(t, stub, "slot1")
+	seedMarketPrice(t, stub, "slot1", 3.5)
+	seedUser(t, stub, 6, "Org2MSP:user6")
+	setCreator(t, stub, "Org2MSP", "user6")
+
+	order := func(id int64, action string, unitCost float64) map[string]interface{} {
+		return map[string]interface{}{"id": id, "slotId": "slot1", "action": action, "totalQuantity": 100, "unitCost": unitCost, "orderCost": 100 * unitCost, "userId": 6}
+	}
+	batch := func(orders ...map[string]interface{}) []byte {
+		ordersAsBytes, _ := json.Marshal(orders)
+		return ordersAsBytes
+	}
+
+	// Test Case 1: One invalid order rejects the whole batch, with per-item details
+	t.Run("Batch Rejected", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("RegisterOrders"), batch(
+			order(1, "Buy", 3.5),
+			order(2, "Hold", 3.5),
+			order(1, "Sell", 3.5),
+		)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+
+		batchErr, ok := errcode.Parse(response.GetMessage())
+		assert.True(t, ok, "Response carries no structured error")
+		assert.Equal(t, errcode.InvalidArgument, batchErr.Code, "Code mismatch")
+		assert.Equal(t, "Order batch rejected", batchErr.Message, "Message mismatch")
+		assert.Len(t, batchErr.Details, 2, "Unexpected number of errors")
+		assert.Equal(t, "[1].action", batchErr.Details[0].Field, "Field mismatch")
+		assert.Contains(t, batchErr.Details[0].Message, "unknown Action")
+		assert.Equal(t, "[2]", batchErr.Details[1].Field, "Field mismatch")
+
+		orderAsBytes, _ := stub.GetState("Order_1")
+		assert.Nil(t, orderAsBytes, "Order stored from a rejected batch")
+	})
+
+	// Test Case 2: Duplicate IDs and failed validations are each reported
+	t.Run("Per Item Errors", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("RegisterOrders"), batch(
+			order(1, "Buy", 3.5),
+			order(1, "Sell", 3.5),
+			map[string]interface{}{"id": 3, "slotId": "slot1", "totalQuantity": 100, "unitCost": 3.5, "userId": 6},
+		)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+
+		batchErr, ok := errcode.Parse(response.GetMessage())
+		assert.True(t, ok, "Response carries no structured error")
+		assert.Equal(t, []FieldError{
+			{Code: errcode.InvalidArgument, Field: "[1]", Message: "Order ID appears more than once in the batch"},
+			{Code: errcode.InvalidArgument, Field: "[2].action", Message: "is required"},
+		}, batchErr.Details, "Details mismatch")
+	})
+
+	// Test Case 3: A valid batch stores every order
+	t.Run("Batch Committed", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("RegisterOrders"), batch(
+			order(1, "Buy", 3.5),
+			order(2, "Sell", 3.5),
+		)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Equal(t, "[1,2]", string(response.GetPayload()), "Stored IDs mismatch")
+
+		var stored Order
+		orderAsBytes, _ := stub.GetState("Order_2")
+		err := json.Unmarshal(orderAsBytes, &stored)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, Sell, stored.UserAction, "Action mismatch")
+		assert.Equal(t, 350.0, stored.OrderCost, "OrderCost mismatch")
+		assert.Equal(t, 100.0, stored.RemainingQuantity, "RemainingQuantity mismatch")
+	})
+
+	// Test Case 4: Orders of other users are refused with their own code
+	t.Run("Other User Refused", func(t *testing.T) {
+		seedUser(t, stub, 7, "Org2MSP:user7")
+		other := order(20, "Buy", 3.5)
+		other["userId"] = 7
+		response := stub.MockInvoke("5", [][]byte{[]byte("RegisterOrders"), batch(order(21, "Buy", 3.5), other)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+
+		batchErr, ok := errcode.Parse(response.GetMessage())
+		assert.True(t, ok, "Response carries no structured error")
+		assert.Equal(t, errcode.Forbidden, batchErr.Code, "Code mismatch")
+		assert.Len(t, batchErr.Details, 1, "Unexpected number of errors")
+		assert.Equal(t, FieldError{Code: errcode.Forbidden, Field: "[1]", Message: "Caller Org2MSP:user6 is not the owner of User 7"}, batchErr.Details[0], "Detail mismatch")
+	})
+
+	// Test Case 5: Refused orders failing differently make an INVALID_ARGUMENT batch
+	t.Run("Mixed Codes", func(t *testing.T) {
+		other := order(22, "Buy", 3.5)
+		other["userId"] = 7
+		missing := order(23, "Buy", 3.5)
+		missing["slotId"] = "missing"
+		response := stub.MockInvoke("6", [][]byte{[]byte("RegisterOrders"), batch(other, missing)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+
+		batchErr, ok := errcode.Parse(response.GetMessage())
+		assert.True(t, ok, "Response carries no structured error")
+		assert.Equal(t, errcode.InvalidArgument, batchErr.Code, "Code mismatch")
+		assert.Len(t, batchErr.Details, 2, "Unexpected number of errors")
+		assert.Equal(t, errcode.Forbidden, batchErr.Details[0].Code, "Code mismatch")
+		assert.Equal(t, errcode.NotFound, batchErr.Details[1].Code, "Code mismatch")
+	})
+
+	// Test Case 6: An aggregator set by the admin batches orders of several users
+	t.Run("Aggregator Batch", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "aggregator")
+		defer setCreator(t, stub, "Org2MSP", "user6")
+		response := stub.MockInvoke("7", [][]byte{[]byte("SetOrderAggregators"), []byte("Org2MSP:aggregator")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Non-admin unexpectedly set the aggregators")
+		assert.Contains(t, response.GetMessage(), "FORBIDDEN")
+
+		response = invokeAsAdmin(t, stub, "8", [][]byte{[]byte("SetOrderAggregators"), []byte("Org2MSP:aggregator")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		forUser7 := order(31, "Sell", 3.5)
+		forUser7["userId"] = 7
+		forUnknown := order(32, "Sell", 3.5)
+		forUnknown["userId"] = 9
+		response = stub.MockInvoke("9", [][]byte{[]byte("RegisterOrders"), batch(order(30, "Buy", 3.5), forUser7, forUnknown)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Order of an unknown user unexpectedly accepted")
+		assert.Contains(t, response.GetMessage(), "NOT_FOUND")
+
+		response = stub.MockInvoke("10", [][]byte{[]byte("RegisterOrders"), batch(order(30, "Buy", 3.5), forUser7)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		assert.Equal(t, "[30,31]", string(response.GetPayload()), "Stored IDs mismatch")
+
+		for id, userID := range map[string]int64{"Order_30": 6, "Order_31": 7} {
+			var stored Order
+			orderAsBytes, _ := stub.GetState(id)
+			err := json.Unmarshal(orderAsBytes, &stored)
+			assert.NoError(t, err, "Error unmarshalling order")
+			assert.Equal(t, userID, stored.UserID, "UserID mismatch")
+		}
+	})
+
+	// Test Case 7: Oversized batches are refused up front
+	t.Run("Batch Too Large", func(t *testing.T) {
+		orders := []map[string]interface{}{}
+		for i := 0; i <= maxOrderBatchSize; i++ {
+			orders = append(orders, order(int64(100+i), "Buy", 3.5))
+		}
+		response := stub.MockInvoke("4", [][]byte{[]byte("RegisterOrders"), batch(orders...)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "exceeds the maximum")
+	})
+}
@@ -0,0 +1,185 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Write functions take either their historical positional arguments or a single
+// JSON object. Payloads are decoded strictly: every key must name a field of the
+// request struct, fields tagged `payload:"required"` must be present, and
+// request types implementing payloadValidator check their values. All problems
+// are reported together, one FieldError per field.
+
+// FieldError is a problem with one field of a JSON payload. It is reported to
+// clients as a detail of the INVALID_ARGUMENT error.
+type FieldError = errcode.FieldError
+
+// PayloadError collects the FieldErrors of a rejected payload.
+type PayloadError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *PayloadError) Error() string {
+	fieldsAsBytes, _ := json.Marshal(e.Fields)
+	return "Invalid payload: " + string(fieldsAsBytes)
+}
+
+// payloadValidator is implemented by request types with value constraints.
+type payloadValidator interface {
+	validate() []FieldError
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// isJSONPayload reports whether args are a single JSON object rather than positional arguments.
+func isJSONPayload(args []string) bool {
+	return len(args) == 1 && strings.HasPrefix(strings.TrimSpace(args[0]), "{")
+}
+
+// decodePayload strictly decodes a JSON object into request, a pointer to a request struct.
+func decodePayload(payload string, request interface{}) error {
+	var raw map[string]json.RawMessage
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	err := decoder.Decode(&raw)
+	if err != nil {
+		return errcode.New(errcode.InvalidArgument, "Invalid payload: "+err.Error())
+	}
+	if raw == nil || decoder.More() {
+		return errcode.New(errcode.InvalidArgument, "Invalid payload: expecting a single JSON object")
+	}
+
+	value := reflect.ValueOf(request).Elem()
+	fields := map[string]int{}
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+
+	fieldErrors := []FieldError{}
+	for name, fieldAsBytes := range raw {
+		i, ok := fields[name]
+		if !ok {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "unknown field"})
+			continue
+		}
+		err = json.Unmarshal(fieldAsBytes, value.Field(i).Addr().Interface())
+		if err != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: fieldErrorMessage(err)})
+		}
+	}
+	for name, i := range fields {
+		if value.Type().Field(i).Tag.Get("payload") != "required" {
+			continue
+		}
+		if fieldAsBytes, ok := raw[name]; !ok || bytes.Equal(fieldAsBytes, []byte("null")) {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "is required"})
+		}
+	}
+	if len(fieldErrors) == 0 {
+		if validator, ok := request.(payloadValidator); ok {
+			fieldErrors = validator.validate()
+		}
+	}
+	return payloadError(fieldErrors)
+}
+
+// validateRequest runs the value checks of a request built from positional
+// arguments, so both forms of a write function accept the same values.
+func validateRequest(request payloadValidator) error {
+	return payloadError(request.validate())
+}
+
+func payloadError(fieldErrors []FieldError) error {
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	sort.Slice(fieldErrors, func(i, j int) bool { return fieldErrors[i].Field < fieldErrors[j].Field })
+	return &PayloadError{Fields: fieldErrors}
+}
+
+// fieldErrorMessage drops the Go type names encoding/json puts in its errors.
+func fieldErrorMessage(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return "cannot be a JSON " + typeErr.Value
+	}
+	return err.Error()
+}
+
+// nonNegative appends a FieldError for each named value below zero, NaN or infinite.
+func nonNegative(fieldErrors []FieldError, values map[string]float64) []FieldError {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if math.IsNaN(values[name]) || math.IsInf(values[name], 0) {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "must be a finite number, got " + strconv.FormatFloat(values[name], 'f', -1, 64)})
+		} else if values[name] < 0 {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "must not be negative, got " + strconv.FormatFloat(values[name], 'f', -1, 64)})
+		}
+	}
+	return fieldErrors
+}
+
+// nonEmpty holds string fields to the limits sanitize_arguments puts on positional arguments.
+func nonEmpty(fieldErrors []FieldError, values map[string]string) []FieldError {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if values[name] == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "must not be empty"})
+		} else if len(values[name]) > 256 {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "must be <= 256 characters"})
+		}
+	}
+	return fieldErrors
+}
+
+// parseOptionalFloat parses a positional amount, treating an empty argument as zero.
+func parseOptionalFloat(arg string, name string) (float64, error) {
+	if arg == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, errcode.New(errcode.InvalidArgument, "Failed to parse "+name+": "+err.Error())
+	}
+	return value, nil
+}
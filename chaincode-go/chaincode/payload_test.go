@@ -0,0 +1,249 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+func TestDecodePayload(t *testing.T) {
+	// Test Case 1: A complete payload decodes into the request
+	t.Run("Valid Payload", func(t *testing.T) {
+		var request BidMatchRequest
+		err := decodePayload(`{"id":1,"bidSlot":"slot1","bidStatus":"BidExecuted","bidUnitPrice":5,"buyerUserId":3,"sellerUserId":4,"originalBidUnits":100}`, &request)
+		assert.NoError(t, err, "Unexpected error decoding payload")
+		assert.Equal(t, BidExecuted, request.BidStatus, "BidStatus mismatch")
+		assert.Equal(t, 100.0, request.OriginalBidUnits, "OriginalBidUnits mismatch")
+	})
+
+	// Test Case 2: Every field problem is reported, sorted by field
+	t.Run("Field Errors", func(t *testing.T) {
+		var request OrderRequest
+		err := decodePayload(`{"id":"4","slotId":"slot1","action":"Hold","unitCost":3.5,"userId":6,"colour":"red"}`, &request)
+		payloadErr, ok := err.(*PayloadError)
+		assert.True(t, ok, "Expected a PayloadError, got %v", err)
+		assert.Equal(t, []FieldError{
+			{Field: "action", Message: `unknown Action "Hold"`},
+			{Field: "colour", Message: "unknown field"},
+			{Field: "id", Message: "cannot be a JSON string"},
+			{Field: "totalQuantity", Message: "is required"},
+		}, payloadErr.Fields)
+	})
+
+	// Test Case 3: Values are validated once the payload decodes
+	t.Run("Value Validation", func(t *testing.T) {
+		var request PaymentRequest
+		err := decodePayload(`{"id":"P1","paymentType":"WalletRecharge","totalAmount":-5,"userId":3,"debitedFrom":"bank","creditedTo":"3"}`, &request)
+		assert.Error(t, err, "Negative amount accepted")
+		assert.Contains(t, err.Error(), `"field":"totalAmount"`)
+	})
+
+	// Test Case 4: Only a single JSON object is accepted
+	t.Run("Trailing Data", func(t *testing.T) {
+		var request UserRequest
+		err := decodePayload(`{"id":1,"category":"Prosumer","source":"Solar"} {}`, &request)
+		assert.Error(t, err, "Trailing data accepted")
+	})
+}
+
+func TestJSONPayloadWrites(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	t.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+	setCreator(t, stub, "Org1MSP", "admin")
+	seedTradingSlot(t, stub, "slot1")
+	seedMarketPrice(t, stub, "slot1", 3.5)
+	seedOrder(t, stub, Order{ID: 50, BidStatus: BidCreated, RemainingQuantity: 300, SlotID: "slot1", TotalQuantity: 300, UserAction: Buy, UserID: 3})
+
+	// Test Case 1: A user is created from a JSON payload, with PII in the transient map
+	t.Run("UpdateUserProfile", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{userPIITransientKey: []byte(`{"location":"Location 6","meterId":"MeterId 6","salt":"secret-salt-of-user-6"}`)}
+		defer func() { stub.TransientMap = nil }()
+		response := stub.MockInvoke("1", [][]byte{[]byte("UpdateUserProfile"), []byte(`{"id":6,"category":"Consumer","source":"Battery"}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		user, err := getUser(stub, 6)
+		assert.NoError(t, err, "Error reading user")
+		assert.Equal(t, Consumer, user.Category, "Category mismatch")
+		assert.Equal(t, Battery, user.Source, "Source mismatch")
+	})
+
+	// Test Case 2: An order is registered from a JSON payload
+	t.Run("RegisterOrder", func(t *testing.T) {
+		response := stub.MockInvoke("2", [][]byte{[]byte("RegisterOrder"), []byte(`{"id":4,"slotId":"slot1","action":"Sell","totalQuantity":300,"unitCost":3.5,"orderCost":1050,"userId":6}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var order Order
+		orderAsBytes, _ := stub.GetState("Order_4")
+		err := json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, Sell, order.UserAction, "Action mismatch")
+		assert.Equal(t, 1050.0, order.OrderCost, "OrderCost mismatch")
+	})
+
+	// Test Case 3: A BidMatch is processed from a JSON payload
+	t.Run("ProcessBidMatch", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("ProcessBidMatch"), []byte(`{"id":1,"bidSlot":"slot1","bidStatus":"BidAccepted","bidUnitPrice":4,"buyerUserId":3,"sellerUserId":6,"originalBidUnits":100,"transactionBuyId":50,"transactionSellId":4}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		bidMatch, err := getBidMatch(stub, "1")
+		assert.NoError(t, err, "Error reading BidMatch")
+		assert.Equal(t, BidAccepted, bidMatch.BidStatus, "BidStatus mismatch")
+		assert.NotZero(t, bidMatch.BidMatchTms, "BidMatchTms not defaulted")
+	})
+
+	// Test Case 4: A payment is recorded from a JSON payload, once
+	t.Run("RecordPayment", func(t *testing.T) {
+		response := stub.MockInvoke("4", [][]byte{[]byte("RecordPayment"), []byte(`{"id":"P1","paymentType":"WalletRecharge","totalAmount":100,"userId":6,"debitedFrom":"bank","creditedTo":"6"}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		paymentAsBytes, _ := stub.GetState("Payment_P1")
+		assert.Contains(t, string(paymentAsBytes), `"paymentType":"WalletRecharge"`)
+
+		response = stub.MockInvoke("4", [][]byte{[]byte("RecordPayment"), []byte(`{"id":"P1","paymentType":"WalletRecharge","totalAmount":900,"userId":6,"debitedFrom":"bank","creditedTo":"6"}`)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "CONFLICT")
+
+		paymentAsBytes, _ = stub.GetState("Payment_P1")
+		assert.Contains(t, string(paymentAsBytes), `"totalAmount":100`, "Payment overwritten")
+
+		// Nor is a PaymentDetail, even one stored under the ID this transaction derives.
+		stub.TxID = "4b"
+		detailKey := "PaymentDetail_" + strconv.FormatInt(txSequenceID(stub, 0), 10)
+		stub.MockTransactionStart("seedDetail")
+		_ = stub.PutState(detailKey, []byte(`{"debitedFrom":"someone else"}`))
+		stub.MockTransactionEnd("seedDetail")
+		response = stub.MockInvoke("4b", [][]byte{[]byte("RecordPayment"), []byte(`{"id":"P1b","paymentType":"WalletRecharge","totalAmount":100,"userId":6,"debitedFrom":"bank","creditedTo":"6"}`)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "CONFLICT")
+
+		detailAsBytes, _ := stub.GetState(detailKey)
+		assert.Contains(t, string(detailAsBytes), "someone else", "PaymentDetail overwritten")
+	})
+
+	// Test Case 5: Rejected payloads write nothing and name the offending fields
+	t.Run("Rejected Payload", func(t *testing.T) {
+		response := stub.MockInvoke("5", [][]byte{[]byte("RecordPayment"), []byte(`{"id":"P2","paymentType":"WalletRecharge","totalAmount":100,"userId":6,"debitedFrom":"bank","creditedTo":"6","platformFee":3}`)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		payloadErr, ok := errcode.Parse(response.GetMessage())
+		assert.True(t, ok, response.GetMessage())
+		assert.Equal(t, errcode.InvalidArgument, payloadErr.Code, "Code mismatch")
+		assert.Equal(t, []FieldError{{Field: "platformFee", Message: "unknown field"}}, payloadErr.Details, "Details mismatch")
+
+		paymentAsBytes, _ := stub.GetState("Payment_P2")
+		assert.Nil(t, paymentAsBytes, "Payment stored from a rejected payload")
+
+		// Settlement and dispute payment IDs cannot be taken from this entry point.
+		for _, paymentID := range []string{"Settlement_slot1_6", "Dispute_D1"} {
+			response = stub.MockInvoke("5", [][]byte{[]byte("RecordPayment"), []byte(`{"id":"` + paymentID + `","paymentType":"WalletRecharge","totalAmount":100,"userId":6,"debitedFrom":"bank","creditedTo":"6"}`)})
+			assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+			assert.Contains(t, response.GetMessage(), "must not start with")
+
+			paymentAsBytes, _ = stub.GetState("Payment_" + paymentID)
+			assert.Nil(t, paymentAsBytes, "Reserved payment ID stored")
+		}
+	})
+
+	// Test Case 6: Positional amounts that do not parse are no longer ignored
+	t.Run("Positional Parse Errors", func(t *testing.T) {
+		response := stub.MockInvoke("6", [][]byte{[]byte("RecordPayment"), []byte("P3"), []byte("WalletRecharge"),
+			[]byte("100"), []byte("6"), []byte("bank"), []byte("6"), []byte(""), []byte("0"), []byte("abc"),
+			[]byte("0"), []byte("0"), []byte("0")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Failed to parse token amount")
+	})
+
+	// Test Case 7: Positional values are validated like payload values
+	t.Run("Positional Validation", func(t *testing.T) {
+		response := stub.MockInvoke("7", [][]byte{[]byte("RegisterOrder"), []byte("0"), []byte("BidCreated"), []byte("5"),
+			[]byte("0"), []byte("0"), []byte("0"), []byte("slot1"), []byte("300"), []byte("-3.5"), []byte("6"),
+			[]byte("0"), []byte("Sell")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		payloadErr, ok := errcode.Parse(response.GetMessage())
+		assert.True(t, ok, response.GetMessage())
+		assert.Equal(t, errcode.InvalidArgument, payloadErr.Code, "Code mismatch")
+		assert.Equal(t, []FieldError{{Field: "unitCost", Message: "must not be negative, got -3.5"}}, payloadErr.Details, "Details mismatch")
+
+		orderAsBytes, _ := stub.GetState("Order_5")
+		assert.Nil(t, orderAsBytes, "Order stored from invalid positional arguments")
+	})
+
+	// Test Case 8: An order is amended from a JSON payload
+	t.Run("AmendOrder", func(t *testing.T) {
+		response := stub.MockInvoke("8", [][]byte{[]byte("AmendOrder"), []byte(`{"id":4,"unitCost":3.6,"totalQuantity":250}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		order, err := getOrder(stub, "4")
+		assert.NoError(t, err, "Error reading order")
+		assert.Equal(t, 3.6, order.UnitCost, "UnitCost mismatch")
+		assert.Equal(t, int64(250), order.TotalQuantity, "TotalQuantity mismatch")
+	})
+
+	// Test Case 9: Slots are created and moved on from JSON payloads
+	t.Run("Trading Slots", func(t *testing.T) {
+		response := stub.MockInvoke("9", [][]byte{[]byte("CreateTradingSlot"), []byte(`{"id":"slot2","startTime":1700003600,"endTime":1700004500,"gateClosure":1700000000}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("10", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte(`{"id":"slot2","status":"Closed"}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		slot, err := getTradingSlot(stub, "slot2")
+		assert.NoError(t, err, "Error reading slot")
+		assert.Equal(t, SlotClosed, slot.Status, "Status mismatch")
+		assert.Equal(t, int64(1700000000), slot.GateClosure, "GateClosure mismatch")
+	})
+
+	// Test Case 10: Configuration is set from JSON payloads
+	t.Run("Configuration", func(t *testing.T) {
+		response := stub.MockInvoke("11", [][]byte{[]byte("SetEmissionFactor"), []byte(`{"source":"DG Set","kgCo2ePerKwh":0.82}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("12", [][]byte{[]byte("SetSettlementConfig"), []byte(`{"netPerUser":true,"penaltyPct":10}`)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		config, err := getSettlementConfig(stub)
+		assert.NoError(t, err, "Error reading settlement config")
+		assert.True(t, config.NetPerUser, "NetPerUser mismatch")
+		assert.Equal(t, 10.0, config.PenaltyPct, "PenaltyPct mismatch")
+	})
+
+	// Test Case 11: Every writer rejects its payload field by field
+	t.Run("Rejected Writer Payloads", func(t *testing.T) {
+		cases := []struct {
+			function string
+			payload  string
+			fields   []FieldError
+		}{
+			{"AmendOrder", `{"id":4,"unitCost":0,"totalQuantity":250}`, []FieldError{{Field: "unitCost", Message: "must be positive"}}},
+			{"TransferREC", `{"id":"1_1","fromUserId":6}`, []FieldError{{Field: "toUserId", Message: "is required"}}},
+			{"RetireREC", `{"id":"1_1","userId":6,"note":""}`, []FieldError{{Field: "note", Message: "must not be empty"}}},
+			{"CreateTradingSlot", `{"id":"slot3","startTime":10,"endTime":5,"gateClosure":20}`, []FieldError{
+				{Field: "endTime", Message: "must be after startTime"},
+				{Field: "gateClosure", Message: "must not be after startTime"},
+			}},
+			{"GenerateTradingSlots", `{"firstStart":10,"durationSec":0,"count":96}`, []FieldError{{Field: "durationSec", Message: "must be positive"}}},
+			{"OpenDispute", `{"id":"D1","bidMatchId":1,"userId":3,"type":"Colour","reason":"x"}`, []FieldError{{Field: "type", Message: `unknown DisputeType "Colour"`}}},
+			{"SubmitEvidence", `{"disputeId":"D1","userId":3,"documentHash":"abc","description":"photo"}`, []FieldError{{Field: "documentHash", Message: "must be a hex encoded SHA-256 digest"}}},
+			{"ResolveDispute", `{"id":"D1","outcome":"Rejected","adjustmentAmount":5,"resolution":"no"}`, []FieldError{{Field: "adjustmentAmount", Message: "must be 0 for a rejected dispute"}}},
+			{"PublishMarketPrice", `{"slotId":"slot1","price":-1,"source":"IEX-DAM","timestamp":1}`, []FieldError{{Field: "price", Message: "must be positive"}}},
+			{"CommitOrder", `{"id":7,"slotId":"slot1","userId":6,"action":"Buy","commitment":"abc","unitCost":3}`, []FieldError{{Field: "unitCost", Message: "unknown field"}}},
+			{"PublishPlatformTerms", `{"version":"2.0","documentHash":"9f86d0"}`, []FieldError{{Field: "documentUri", Message: "is required"}}},
+			{"SetMarketOracleConfig", `{"priceBandPct":120,"oracles":[]}`, []FieldError{
+				{Field: "oracles", Message: "must not be empty"},
+				{Field: "priceBandPct", Message: "must be between 0 and 100"},
+			}},
+		}
+		for i, c := range cases {
+			response := stub.MockInvoke(fmt.Sprintf("reject%d", i), [][]byte{[]byte(c.function), []byte(c.payload)})
+			assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "%s unexpectedly succeeded", c.function)
+			payloadErr, ok := errcode.Parse(response.GetMessage())
+			assert.True(t, ok, response.GetMessage())
+			assert.Equal(t, errcode.InvalidArgument, payloadErr.Code, "%s code mismatch", c.function)
+			assert.Equal(t, c.fields, payloadErr.Details, "%s details mismatch", c.function)
+		}
+	})
+}
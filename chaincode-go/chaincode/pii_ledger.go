@@ -0,0 +1,164 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Transient map key carrying {"location": ..., "meterId": ..., "salt": ...} for UpdateUserProfile
+const userPIITransientKey = "user_pii"
+
+// Shortest salt accepted; the salt is the only secret input of the public PII hash.
+const minUserPIISaltLength = 16
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// userPIICollection names the private data collection owned by an org, as
+// declared in collections_config.json.
+func userPIICollection(mspID string) string {
+	return mspID + "PrivateCollection"
+}
+
+func hashUserPII(pii UserPII) string {
+	piiAsBytes, _ := json.Marshal(pii)
+	sum := sha256.Sum256(piiAsBytes)
+	return hex.EncodeToString(sum[:])
+}
+
+// userPIIFromTransient reads the PII fields passed out of band, so they never
+// appear in the transaction's arguments on the channel.
+func userPIIFromTransient(stub shim.ChaincodeStubInterface) (UserPII, bool, error) {
+	var pii UserPII
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return pii, false, errors.New("Error reading transient data: " + err.Error())
+	}
+	piiAsBytes, ok := transientMap[userPIITransientKey]
+	if !ok {
+		return pii, false, nil
+	}
+	err = json.Unmarshal(piiAsBytes, &pii)
+	if err != nil {
+		return pii, false, errors.New("Failed to unmarshal transient " + userPIITransientKey + ": " + err.Error())
+	}
+	if pii.Location == "" || pii.MeterId == "" {
+		return pii, false, errcode.New(errcode.InvalidArgument, "Transient "+userPIITransientKey+" must contain location and meterId")
+	}
+	if len(pii.Salt) < minUserPIISaltLength {
+		return pii, false, errcode.New(errcode.InvalidArgument, "Transient "+userPIITransientKey+" must contain a secret salt of at least "+strconv.Itoa(minUserPIISaltLength)+" characters")
+	}
+	return pii, true, nil
+}
+
+// putUserPII stores the PII in the user's org collection and records its hash
+// on the public profile. The salt comes with the PII in the transient map and is
+// kept only in the collection, so the hash cannot be brute-forced from public data.
+func putUserPII(stub shim.ChaincodeStubInterface, user *User, pii UserPII) error {
+	pii.UserID = user.ID
+	piiAsBytes, _ := json.Marshal(pii)
+	err := stub.PutPrivateData(userPIICollection(user.OrgMSP), strconv.FormatInt(user.ID, 10), piiAsBytes)
+	if err != nil {
+		return errors.New("Could not store user PII: " + err.Error())
+	}
+	user.PIIHash = hashUserPII(pii)
+	user.Location = ""
+	user.MeterId = ""
+	return nil
+}
+
+// verifyClientOrgMatchesPeerOrg makes sure the peer answering the query belongs
+// to the caller's org, so private data is only read from the owning org's peers.
+func verifyClientOrgMatchesPeerOrg(stub shim.ChaincodeStubInterface) (string, error) {
+	clientMSP, err := cid.GetMSPID(stub)
+	if err != nil {
+		return "", errors.New("Failed to read caller MSP: " + err.Error())
+	}
+	peerMSP, err := shim.GetMSPID()
+	if err != nil {
+		return "", errors.New("Failed to read peer MSP: " + err.Error())
+	}
+	if clientMSP != peerMSP {
+		return "", errcode.New(errcode.Forbidden, "Caller from "+clientMSP+" is not authorized to read private data from a "+peerMSP+" peer")
+	}
+	return clientMSP, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               PII Read Methods                             */
+/* -------------------------------------------------------------------------- */
+
+func ReadUserPII(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+	user, err := getUser(stub, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	// Only members of the owning org may read, and only through their own peers.
+	mspID, err := verifyClientOrgMatchesPeerOrg(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if user.OrgMSP == "" {
+		return errorResponse(errcode.StateViolation, "User "+args[0]+" has no private data")
+	}
+	if mspID != user.OrgMSP {
+		return errorResponse(errcode.Forbidden, "Caller from "+mspID+" is not a member of "+userPIICollection(user.OrgMSP))
+	}
+
+	piiAsBytes, err := stub.GetPrivateData(userPIICollection(user.OrgMSP), args[0])
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing private data: "+err.Error())
+	}
+	if piiAsBytes == nil {
+		return errorResponse(errcode.NotFound, "Private data for User "+args[0]+" does not exist.")
+	}
+	var pii UserPII
+	err = json.Unmarshal(piiAsBytes, &pii)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to unmarshal user PII: "+err.Error())
+	}
+	if hashUserPII(pii) != user.PIIHash {
+		return errorResponse(errcode.Internal, "Private data for User "+args[0]+" does not match the public hash")
+	}
+
+	return shim.Success(piiAsBytes)
+}
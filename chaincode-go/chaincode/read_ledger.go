@@ -0,0 +1,264 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                             User Read Methods                              */
+/* -------------------------------------------------------------------------- */
+
+func ReadUserProfile(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing the user ID.
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+
+	// Users are stored under their bare ID.
+	userProfileAsBytes, err := stub.GetState(strconv.FormatInt(userID, 10))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	if userProfileAsBytes == nil {
+		return errorResponse(errcode.NotFound, "User with ID "+strconv.FormatInt(userID, 10)+" does not exist.")
+	}
+
+	userProfileAsBytes, err = upgradeAsset("User", userProfileAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(userProfileAsBytes)
+}
+
+func ReadPlatformContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the user ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing the user ID.
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+
+	// Attempt to retrieve the platform contract from the state using the user ID.
+	platformContractAsBytes, err := stub.GetState("PlatformContract_" + strconv.FormatInt(userID, 10))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	if platformContractAsBytes == nil {
+		return errorResponse(errcode.NotFound, "Platform Contract for User with ID "+strconv.FormatInt(userID, 10)+" does not exist.")
+	}
+
+	platformContractAsBytes, err = upgradeAsset("PlatformContract", platformContractAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(platformContractAsBytes)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Payment Read Methods                             */
+/* -------------------------------------------------------------------------- */
+
+func ReadPayment(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the payment ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Retrieve the payment ID from the arguments.
+	paymentID := args[0]
+
+	// Attempt to retrieve the payment from the state using the payment ID.
+	paymentAsBytes, err := stub.GetState("Payment_" + paymentID)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	if paymentAsBytes == nil {
+		return errorResponse(errcode.NotFound, "Payment with ID "+paymentID+" does not exist.")
+	}
+
+	paymentAsBytes, err = upgradeAsset("Payment", paymentAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(paymentAsBytes)
+}
+
+func ReadPaymentDetail(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the ID of the PaymentDetail to retrieve.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing ID.
+	paymentDetailID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse PaymentDetail ID: "+err.Error())
+	}
+
+	// Retrieve the paymentDetail from state.
+	paymentDetailAsBytes, err := stub.GetState("PaymentDetail_" + strconv.FormatInt(paymentDetailID, 10))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to fetch PaymentDetail with ID "+args[0]+" from the ledger: "+err.Error())
+	}
+
+	if paymentDetailAsBytes == nil {
+		return errorResponse(errcode.NotFound, "PaymentDetail with ID "+args[0]+" not found.")
+	}
+
+	paymentDetailAsBytes, err = upgradeAsset("PaymentDetail", paymentDetailAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(paymentDetailAsBytes)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Energy Bid Read Methods                           */
+/* -------------------------------------------------------------------------- */
+
+func ReadOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the ID of the Order to retrieve.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing ID.
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse Order ID: "+err.Error())
+	}
+
+	// Retrieve the order from state.
+	orderAsBytes, err := stub.GetState("Order_" + strconv.FormatInt(orderID, 10))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to fetch Order with ID "+args[0]+" from the ledger: "+err.Error())
+	}
+
+	if orderAsBytes == nil {
+		return errorResponse(errcode.NotFound, "Order with ID "+args[0]+" not found.")
+	}
+
+	orderAsBytes, err = upgradeAsset("Order", orderAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(orderAsBytes)
+}
+
+func ReadBidMatch(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the ID of the BidMatch to retrieve.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing ID.
+	bidMatchID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse BidMatch ID: "+err.Error())
+	}
+
+	// Retrieve the bidMatch from state.
+	bidMatchAsBytes, err := stub.GetState("BidMatch_" + strconv.FormatInt(bidMatchID, 10))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to fetch BidMatch with ID "+args[0]+" from the ledger: "+err.Error())
+	}
+
+	if bidMatchAsBytes == nil {
+		return errorResponse(errcode.NotFound, "BidMatch with ID "+args[0]+" not found.")
+	}
+
+	bidMatchAsBytes, err = upgradeAsset("BidMatch", bidMatchAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(bidMatchAsBytes)
+}
+
+func ReadOrderFills(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the ID of the Order whose fills to list.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	// Parsing ID.
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse Order ID: "+err.Error())
+	}
+
+	iterator, err := stub.GetStateByPartialCompositeKey(OrderFillIndex, []string{strconv.FormatInt(orderID, 10)})
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query fills of Order with ID "+args[0]+": "+err.Error())
+	}
+	defer iterator.Close()
+
+	// Collect every BidMatch that currently fills the order.
+	fills := []BidMatch{}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to query fills of Order with ID "+args[0]+": "+err.Error())
+		}
+		_, keyParts, err := stub.SplitCompositeKey(entry.Key)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to split fill key: "+err.Error())
+		}
+		bidMatchAsBytes, err := stub.GetState("BidMatch_" + keyParts[1])
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to fetch BidMatch with ID "+keyParts[1]+" from the ledger: "+err.Error())
+		}
+		if bidMatchAsBytes == nil {
+			continue
+		}
+		var bidMatch BidMatch
+		err = decodeAsset(bidMatchAsBytes, &bidMatch)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal BidMatch: "+err.Error())
+		}
+		fills = append(fills, bidMatch)
+	}
+
+	fillsAsBytes, _ := json.Marshal(fills)
+	return shim.Success(fillsAsBytes)
+}
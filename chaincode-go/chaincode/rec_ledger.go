@@ -0,0 +1,479 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// BidMatch units are kWh, certificates are issued per whole MWh.
+const kWhPerREC = 1000
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func isRenewable(source EnergySource) bool {
+	return source == Solar || source == Wind
+}
+
+func getREC(stub shim.ChaincodeStubInterface, recID string) (REC, error) {
+	var rec REC
+	recAsBytes, err := stub.GetState("REC_" + recID)
+	if err != nil {
+		return rec, errors.New("Error accessing state: " + err.Error())
+	}
+	if recAsBytes == nil {
+		return rec, errcode.New(errcode.NotFound, "REC with ID "+recID+" not found.")
+	}
+	err = decodeAsset(recAsBytes, &rec)
+	if err != nil {
+		return rec, errors.New("Failed to unmarshal REC: " + err.Error())
+	}
+	return rec, nil
+}
+
+func putREC(stub shim.ChaincodeStubInterface, rec REC) error {
+	recAsBytes, err := encodeAsset(&rec)
+	if err != nil {
+		return err
+	}
+	return stub.PutState("REC_"+rec.ID, recAsBytes)
+}
+
+func putRECIndex(stub shim.ChaincodeStubInterface, index string, attribute string, recID string) error {
+	indexKey, err := stub.CreateCompositeKey(index, []string{attribute, recID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(indexKey, []byte{0x00})
+}
+
+func delRECIndex(stub shim.ChaincodeStubInterface, index string, attribute string, recID string) error {
+	indexKey, err := stub.CreateCompositeKey(index, []string{attribute, recID})
+	if err != nil {
+		return err
+	}
+	return stub.DelState(indexKey)
+}
+
+func getRECIssuance(stub shim.ChaincodeStubInterface, bidMatchID int64) (RECIssuance, error) {
+	issuance := RECIssuance{BidMatchID: bidMatchID}
+	issuanceAsBytes, err := stub.GetState("RECIssuance_" + strconv.FormatInt(bidMatchID, 10))
+	if err != nil {
+		return issuance, errors.New("Error accessing state: " + err.Error())
+	}
+	if issuanceAsBytes == nil {
+		return issuance, nil
+	}
+	err = decodeAsset(issuanceAsBytes, &issuance)
+	if err != nil {
+		return issuance, errors.New("Failed to unmarshal REC issuance: " + err.Error())
+	}
+	return issuance, nil
+}
+
+// stampEmissionSource records the seller's source on a BidMatch when it is first
+// recorded or changes seller, so later edits of the seller's profile do not relabel
+// the energy for certificates or emissions.
+func stampEmissionSource(stub shim.ChaincodeStubInterface, previous *BidMatch, bidMatch *BidMatch) error {
+	if previous != nil && previous.EmissionSource != nil && previous.SellerUserId == bidMatch.SellerUserId {
+		bidMatch.EmissionSource = previous.EmissionSource
+		return nil
+	}
+	// Without a seller profile the source of the energy is unknown.
+	seller, err := getUser(stub, bidMatch.SellerUserId)
+	if err != nil {
+		return err
+	}
+	bidMatch.EmissionSource = &seller.Source
+	return nil
+}
+
+// issueRECs credits the renewable kWh a BidMatch has delivered to its buyer's
+// accrual for the match's source, and mints a certificate for every MWh the accrual
+// completes. Each call credits only what was delivered beyond the previous credit,
+// so a match that is executed again or has its delivery reported late is counted once.
+// Certificates cannot be taken back once transferred or retired, so a credited match
+// can no longer lose units, leave BidExecuted or change buyer or source.
+func issueRECs(stub shim.ChaincodeStubInterface, bidMatch BidMatch) error {
+	issuance, err := getRECIssuance(stub, bidMatch.ID)
+	if err != nil {
+		return err
+	}
+	var units float64
+	if bidMatch.BidStatus == BidExecuted && bidMatch.EmissionSource != nil && isRenewable(*bidMatch.EmissionSource) {
+		units = bidMatch.DeliveredBidUnits
+	}
+	if issuance.Units > 0 && (units < issuance.Units || *bidMatch.EmissionSource != issuance.Source || bidMatch.BuyerUserId != issuance.BuyerID) {
+		return errcode.New(errcode.StateViolation, "Certificates have been credited for "+strconv.FormatFloat(issuance.Units, 'f', -1, 64)+
+			" kWh of BidMatch "+strconv.FormatInt(bidMatch.ID, 10)+"; its delivery, status, buyer and source can no longer be reduced or changed")
+	}
+	if units <= issuance.Units {
+		return nil
+	}
+	source := *bidMatch.EmissionSource
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return err
+	}
+
+	accrualKey := "RECAccrual_" + strconv.FormatInt(bidMatch.BuyerUserId, 10) + "_" + strconv.FormatInt(int64(source), 10)
+	accrualAsBytes, err := stub.GetState(accrualKey)
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	accrual := RECAccrual{BuyerID: bidMatch.BuyerUserId, Source: source}
+	if accrualAsBytes != nil {
+		err = decodeAsset(accrualAsBytes, &accrual)
+		if err != nil {
+			return errors.New("Failed to unmarshal REC accrual: " + err.Error())
+		}
+	}
+
+	accrual.PendingUnits += units - issuance.Units
+	count := int64(math.Floor(accrual.PendingUnits / kWhPerREC))
+	accrual.PendingUnits -= float64(count * kWhPerREC)
+	accrual.UpdatedOn = now
+
+	for i := issuance.Certificates + 1; i <= issuance.Certificates+count; i++ {
+		rec := REC{
+			BidMatchID:  bidMatch.ID,
+			GeneratorID: bidMatch.SellerUserId,
+			ID:          strconv.FormatInt(bidMatch.ID, 10) + "_" + strconv.FormatInt(i, 10),
+			IssuedOn:    now,
+			OwnerID:     bidMatch.BuyerUserId,
+			Source:      source,
+			Status:      RECActive,
+			VintageSlot: bidMatch.BidSlot,
+		}
+		rec.UpdatedOn = rec.IssuedOn
+
+		// Certificates are never reissued over one that has been transferred or retired.
+		existingAsBytes, err := stub.GetState("REC_" + rec.ID)
+		if err != nil {
+			return errors.New("Error accessing state: " + err.Error())
+		}
+		if existingAsBytes != nil {
+			return errcode.New(errcode.Conflict, "REC with ID "+rec.ID+" already exists")
+		}
+		err = putREC(stub, rec)
+		if err != nil {
+			return err
+		}
+		err = putRECIndex(stub, RECOwnerIndex, strconv.FormatInt(rec.OwnerID, 10), rec.ID)
+		if err != nil {
+			return err
+		}
+		err = putRECIndex(stub, RECVintageIndex, rec.VintageSlot, rec.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	accrualAsBytes, err = encodeAsset(&accrual)
+	if err != nil {
+		return err
+	}
+	err = stub.PutState(accrualKey, accrualAsBytes)
+	if err != nil {
+		return err
+	}
+
+	issuance.BuyerID = bidMatch.BuyerUserId
+	issuance.Certificates += count
+	issuance.Source = source
+	issuance.Units = units
+	issuanceAsBytes, err := encodeAsset(&issuance)
+	if err != nil {
+		return err
+	}
+	return stub.PutState("RECIssuance_"+strconv.FormatInt(bidMatch.ID, 10), issuanceAsBytes)
+}
+
+// queryRECsByIndex collects the certificates listed under an index attribute.
+func queryRECsByIndex(stub shim.ChaincodeStubInterface, index string, attribute string) ([]REC, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(index, []string{attribute})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	recs := []REC{}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := stub.SplitCompositeKey(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		rec, err := getREC(stub, keyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              REC Write Methods                             */
+/* -------------------------------------------------------------------------- */
+
+// parseRECTransferRequest reads the positional arguments of TransferREC.
+func parseRECTransferRequest(args []string) (RECTransferRequest, error) {
+	var request RECTransferRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.ID = args[0]
+	request.FromUserID, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse from User ID: "+err.Error())
+	}
+	request.ToUserID, err = strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse to User ID: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *RECTransferRequest) validate() []FieldError {
+	return nonEmpty(nil, map[string]string{"id": request.ID})
+}
+
+// ============================================================================================================================
+// TransferREC() - hand an active certificate over to another user
+//
+// Inputs - Array of strings
+//                           0
+//                        payload
+//  "{"id": "7_1", "fromUserId": 3, "toUserId": 4}"
+// The positional form (recID, fromUserID, toUserID) is still accepted.
+// ============================================================================================================================
+func TransferREC(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request RECTransferRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 3 {
+		request, err = parseRECTransferRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 3.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	rec, err := getREC(stub, request.ID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	fromUserID := request.FromUserID
+	toUserID := request.ToUserID
+
+	if rec.OwnerID != fromUserID {
+		return errorResponse(errcode.Forbidden, "REC with ID "+rec.ID+" is not held by User "+strconv.FormatInt(fromUserID, 10))
+	}
+	err = assertUserCaller(stub, fromUserID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if rec.Status != RECActive {
+		return errorResponse(errcode.StateViolation, "REC with ID "+rec.ID+" is "+RECStatusString(rec.Status)+" and cannot be transferred")
+	}
+	_, err = getUser(stub, toUserID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = delRECIndex(stub, RECOwnerIndex, strconv.FormatInt(fromUserID, 10), rec.ID)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not update REC owner index: "+err.Error())
+	}
+	err = putRECIndex(stub, RECOwnerIndex, strconv.FormatInt(toUserID, 10), rec.ID)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not update REC owner index: "+err.Error())
+	}
+
+	rec.OwnerID = toUserID
+	rec.UpdatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = putREC(stub, rec)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store REC: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// parseRECRetireRequest reads the positional arguments of RetireREC.
+func parseRECRetireRequest(args []string) (RECRetireRequest, error) {
+	var request RECRetireRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.ID = args[0]
+	request.UserID, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+	request.Note = args[2]
+	return request, validateRequest(&request)
+}
+
+func (request *RECRetireRequest) validate() []FieldError {
+	return nonEmpty(nil, map[string]string{"id": request.ID, "note": request.Note})
+}
+
+// ============================================================================================================================
+// RetireREC() - claim a certificate against the holder's consumption, after which it can no longer move
+//
+// Inputs - Array of strings
+//                                      0
+//                                   payload
+//  "{"id": "7_1", "userId": 3, "note": "Scope 2 claim 2024"}"
+// The positional form (recID, userID, note) is still accepted.
+// ============================================================================================================================
+func RetireREC(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request RECRetireRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 3 {
+		request, err = parseRECRetireRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 3.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	rec, err := getREC(stub, request.ID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	userID := request.UserID
+
+	if rec.OwnerID != userID {
+		return errorResponse(errcode.Forbidden, "REC with ID "+rec.ID+" is not held by User "+strconv.FormatInt(userID, 10))
+	}
+	err = assertUserCaller(stub, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if rec.Status != RECActive {
+		return errorResponse(errcode.StateViolation, "REC with ID "+rec.ID+" is already "+RECStatusString(rec.Status))
+	}
+
+	rec.Status = RECRetired
+	rec.RetirementNote = request.Note
+	rec.RetiredOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	rec.UpdatedOn = rec.RetiredOn
+	err = putREC(stub, rec)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store REC: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              REC Read Methods                              */
+/* -------------------------------------------------------------------------- */
+
+func ReadREC(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the ID of the REC to retrieve.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	recAsBytes, err := stub.GetState("REC_" + args[0])
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to fetch REC with ID "+args[0]+" from the ledger: "+err.Error())
+	}
+
+	if recAsBytes == nil {
+		return errorResponse(errcode.NotFound, "REC with ID "+args[0]+" not found.")
+	}
+
+	recAsBytes, err = upgradeAsset("REC", recAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(recAsBytes)
+}
+
+func QueryRECsByOwner(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the user ID of the holder.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	ownerID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+
+	recs, err := queryRECsByIndex(stub, RECOwnerIndex, strconv.FormatInt(ownerID, 10))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query RECs: "+err.Error())
+	}
+
+	recsAsBytes, _ := json.Marshal(recs)
+	return shim.Success(recsAsBytes)
+}
+
+func QueryRECsByVintage(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the slot the certificates were generated in.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	recs, err := queryRECsByIndex(stub, RECVintageIndex, args[0])
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query RECs: "+err.Error())
+	}
+
+	recsAsBytes, _ := json.Marshal(recs)
+	return shim.Success(recsAsBytes)
+}
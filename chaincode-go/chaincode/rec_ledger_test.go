@@ -0,0 +1,194 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueAndTransferREC(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org2MSP", "operator")
+
+	// Seller 1 generates from Solar, seller 2 from a DG Set, users 3 and 4 buy.
+	for _, user := range [][]string{
+		{"1", "Prosumer", "Location 1", "MeterId 1", "Solar"},
+		{"2", "Prosumer", "Location 2", "MeterId 2", "DG Set"},
+		{"3", "Consumer", "Location 3", "MeterId 3", "Battery"},
+		{"4", "Consumer", "Location 4", "MeterId 4", "Battery"},
+	} {
+		registerUser(t, stub, user)
+	}
+
+	seedOrder(t, stub, Order{ID: 6, BidStatus: BidCreated, RemainingQuantity: 100000, SlotID: "Slot1", TotalQuantity: 100000, UserAction: Buy, UserID: 3})
+	// Sell orders are numbered "7" followed by the seller's ID.
+	for orderID, seller := range map[int64]int64{71: 1, 72: 2, 799: 99} {
+		seedOrder(t, stub, Order{ID: orderID, BidStatus: BidCreated, RemainingQuantity: 100000, SlotID: "Slot1", TotalQuantity: 100000, UserAction: Sell, UserID: seller})
+	}
+
+	bidMatch := func(id string, status string, delivered string, seller string) [][]byte {
+		return [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte("1"),          // bidMatchTms
+			[]byte("Slot1"),      // bidSlot
+			[]byte(status),       // bidStatus
+			[]byte("5"),          // bidUnitPrice
+			[]byte("3"),          // buyerUserId
+			[]byte(delivered),    // deliveredBidUnits
+			[]byte(id),           // ID
+			[]byte(delivered),    // originalBidUnits
+			[]byte(seller),       // sellerUserId
+			[]byte("6"),          // transactionBuyID
+			[]byte("7" + seller), // transactionSellID
+		}
+	}
+
+	// Test Case 1: Executed Solar match mints one token per whole MWh
+	t.Run("Executed Renewable Match Issues RECs", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "2", bidMatch("10", "3", "2500", "1"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("3", [][]byte{[]byte("QueryRECsByOwner"), []byte("3")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var recs []REC
+		err := json.Unmarshal(response.GetPayload(), &recs)
+		assert.NoError(t, err, "Error unmarshalling RECs")
+		assert.Len(t, recs, 2, "Unexpected number of RECs")
+		assert.Equal(t, int64(1), recs[0].GeneratorID, "GeneratorID mismatch")
+		assert.Equal(t, "Slot1", recs[0].VintageSlot, "VintageSlot mismatch")
+	})
+
+	// Test Case 2: Re-processing an executed match does not mint again, the
+	// leftover 500 kWh completes a MWh with the next match
+	t.Run("Remainder Carries Over", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "4", bidMatch("10", "3", "2500", "1"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = invokeAsAdmin(t, stub, "5", bidMatch("11", "3", "500", "1"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("6", [][]byte{[]byte("QueryRECsByVintage"), []byte("Slot1")})
+		var recs []REC
+		err := json.Unmarshal(response.GetPayload(), &recs)
+		assert.NoError(t, err, "Error unmarshalling RECs")
+		assert.Len(t, recs, 3, "Unexpected number of RECs")
+	})
+
+	// Test Case 3: Non-renewable sellers do not mint
+	t.Run("DG Set Match Issues No RECs", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "7", bidMatch("12", "3", "5000", "2"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("8", [][]byte{[]byte("ReadREC"), []byte("12_1")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+
+	// Test Case 4: Transfer moves the token to the new holder
+	t.Run("Transfer REC", func(t *testing.T) {
+		response := stub.MockInvoke("9", [][]byte{[]byte("TransferREC"), []byte("10_1"), []byte("3"), []byte("4")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("10", [][]byte{[]byte("QueryRECsByOwner"), []byte("4")})
+		var recs []REC
+		err := json.Unmarshal(response.GetPayload(), &recs)
+		assert.NoError(t, err, "Error unmarshalling RECs")
+		assert.Len(t, recs, 1, "Unexpected number of RECs")
+		assert.Equal(t, "10_1", recs[0].ID, "REC ID mismatch")
+
+		response = stub.MockInvoke("11", [][]byte{[]byte("TransferREC"), []byte("10_1"), []byte("3"), []byte("4")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 5: Only the holder's identity can move or retire a token
+	t.Run("Non-Owner Refused", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "intruder")
+		defer setCreator(t, stub, "Org2MSP", "operator")
+
+		response := stub.MockInvoke("12", [][]byte{[]byte("TransferREC"), []byte("10_1"), []byte("4"), []byte("3")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "FORBIDDEN")
+
+		response = stub.MockInvoke("13", [][]byte{[]byte("RetireREC"), []byte("10_1"), []byte("4"), []byte("claim")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "FORBIDDEN")
+	})
+
+	// Test Case 6: Unknown sellers are refused rather than silently minting nothing
+	t.Run("Unknown Seller", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "14", bidMatch("13", "3", "1000", "99"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "NOT_FOUND")
+	})
+
+	// Test Case 7: Retired tokens cannot be transferred
+	t.Run("Retire REC", func(t *testing.T) {
+		response := stub.MockInvoke("15", [][]byte{[]byte("RetireREC"), []byte("10_1"), []byte("4"), []byte("FY24 scope 2 claim")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("16", [][]byte{[]byte("TransferREC"), []byte("10_1"), []byte("4"), []byte("3")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Retired")
+	})
+
+	// Test Case 8: A credited match cannot leave BidExecuted, so its certificates
+	// are never minted twice or left standing for energy that was not delivered
+	t.Run("Credited Match Stays Executed", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "17", bidMatch("10", "1", "2500", "1"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "STATE_VIOLATION")
+
+		response = invokeAsAdmin(t, stub, "18", bidMatch("10", "3", "2000", "1"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "STATE_VIOLATION")
+
+		response = stub.MockInvoke("19", [][]byte{[]byte("ReadREC"), []byte("10_1")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var rec REC
+		err := json.Unmarshal(response.GetPayload(), &rec)
+		assert.NoError(t, err, "Error unmarshalling REC")
+		assert.Equal(t, int64(4), rec.OwnerID, "OwnerID mismatch")
+		assert.Equal(t, RECRetired, rec.Status, "Status mismatch")
+	})
+
+	// Test Case 9: Units delivered after execution are credited when they are reported
+	t.Run("Late Delivery Issues RECs", func(t *testing.T) {
+		late := func(delivered string) [][]byte {
+			args := bidMatch("14", "3", delivered, "1")
+			args[8] = []byte("2000") // originalBidUnits
+			return args
+		}
+		response := invokeAsAdmin(t, stub, "20", late("0"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = invokeAsAdmin(t, stub, "21", late("1500"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = invokeAsAdmin(t, stub, "22", late("2000"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		for _, recID := range []string{"14_1", "14_2"} {
+			response = stub.MockInvoke("23", [][]byte{[]byte("ReadREC"), []byte(recID)})
+			assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		}
+		response = stub.MockInvoke("24", [][]byte{[]byte("ReadREC"), []byte("14_3")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 10: Certificates follow the source the seller had when the match was recorded
+	t.Run("Source Switched Before Execution", func(t *testing.T) {
+		response := invokeAsAdmin(t, stub, "25", bidMatch("15", "1", "1000", "2"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		registerUser(t, stub, []string{"2", "Prosumer", "Location 2", "MeterId 2", "Solar"})
+		response = invokeAsAdmin(t, stub, "26", bidMatch("15", "3", "1000", "2"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("27", [][]byte{[]byte("ReadREC"), []byte("15_1")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not found")
+	})
+}
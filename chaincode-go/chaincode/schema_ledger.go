@@ -0,0 +1,330 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// assetMigration upgrades a stored record by one schema version, in place.
+type assetMigration func(record map[string]json.RawMessage) error
+
+// assetIndexer writes the composite-key index entries of a stored record.
+type assetIndexer func(stub shim.ChaincodeStubInterface, record []byte) error
+
+// assetSchema describes how one asset kind is stored and how to upgrade it.
+// The current version of a kind is len(Migrations): Migrations[n] takes a record
+// from version n to n+1. Records written before versioning have no schemaVersion
+// and are version 0. Records of a kind are stored under Prefix followed by their ID,
+// except singletons, which are stored under StartKey alone. Index, when set, rebuilds
+// the kind's composite-key indexes.
+type assetSchema struct {
+	EndKey     string
+	Index      assetIndexer
+	Migrations []assetMigration
+	Prefix     string
+	StartKey   string
+}
+
+// MigrationPage reports one page of MigrateAssets. An empty Bookmark means the
+// kind has been fully migrated.
+type MigrationPage struct {
+	Bookmark string `json:"bookmark"`
+	Kind     string `json:"kind"`
+	Migrated int64  `json:"migrated"`
+	Scanned  int64  `json:"scanned"`
+}
+
+// assetSchemas is keyed by the Go type name of each stored asset. To change a
+// stored layout, change the struct and append the matching migration here.
+// UserPII and SealedBid are not versioned: their hashes are computed over their JSON.
+var assetSchemas = map[string]assetSchema{
+	"BidMatch":                indexedSchema(prefixSchema("BidMatch_", introduceSchemaVersion), indexBidMatch),
+	"Dispute":                 prefixSchema("Dispute_", introduceSchemaVersion),
+	"DisputeArbitratorConfig": singletonSchema(disputeArbitratorConfigKey, introduceSchemaVersion),
+	"EmissionFactor":          prefixSchema("EmissionFactor_", introduceSchemaVersion),
+	"FeeSchedule":             prefixSchema("FeeSchedule_", introduceSchemaVersion),
+	"MarketOracleConfig":      singletonSchema(marketOracleConfigKey, introduceSchemaVersion),
+	"MarketPrice":             prefixSchema("MarketPrice_", introduceSchemaVersion),
+	"MonthlyVolume":           prefixSchema("MonthlyVolume_", introduceSchemaVersion),
+	"Order":                   prefixSchema("Order_", migrateOrderCostKey),
+	"OrderAggregatorConfig":   singletonSchema(orderAggregatorConfigKey, introduceSchemaVersion),
+	"Payment":                 prefixSchema("Payment_", introduceSchemaVersion),
+	"PaymentDetail":           prefixSchema("PaymentDetail_", introduceSchemaVersion),
+	"PlatformContract":        prefixSchema("PlatformContract_", introduceSchemaVersion),
+	"PlatformTerms":           prefixSchema("PlatformTerms_", introduceSchemaVersion),
+	"REC":                     prefixSchema("REC_", introduceSchemaVersion),
+	"RECAccrual":              prefixSchema("RECAccrual_", introduceSchemaVersion),
+	"RECIssuance":             prefixSchema("RECIssuance_", introduceSchemaVersion),
+	"SealedOrder":             prefixSchema("SealedOrder_", introduceSchemaVersion),
+	"SettlementConfig":        singletonSchema(settlementConfigKey, introduceSchemaVersion),
+	"SlotSettlement":          prefixSchema("SlotSettlement_", introduceSchemaVersion),
+	"TradingSlot":             prefixSchema("TradingSlot_", introduceSchemaVersion),
+	"VolumeContribution":      prefixSchema("VolumeContribution_", introduceSchemaVersion),
+	// Users are keyed by their bare numeric ID.
+	"User": {StartKey: "0", EndKey: ":", Migrations: []assetMigration{introduceSchemaVersion}},
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func prefixSchema(prefix string, migrations ...assetMigration) assetSchema {
+	startKey, endKey := prefixRange(prefix)
+	return assetSchema{StartKey: startKey, EndKey: endKey, Migrations: migrations, Prefix: prefix}
+}
+
+func singletonSchema(key string, migrations ...assetMigration) assetSchema {
+	return assetSchema{StartKey: key, EndKey: key + "\x00", Migrations: migrations}
+}
+
+func indexedSchema(schema assetSchema, index assetIndexer) assetSchema {
+	schema.Index = index
+	return schema
+}
+
+// indexBidMatch backfills the slot index of matches written before it existed.
+func indexBidMatch(stub shim.ChaincodeStubInterface, record []byte) error {
+	var bidMatch BidMatch
+	err := json.Unmarshal(record, &bidMatch)
+	if err != nil {
+		return errors.New("Failed to unmarshal BidMatch: " + err.Error())
+	}
+	return putBidMatchSlotIndex(stub, nil, bidMatch)
+}
+
+// key returns the state key of the record with the given ID; singletons ignore the ID.
+func (schema assetSchema) key(id string) string {
+	if schema.EndKey == schema.StartKey+"\x00" {
+		return schema.StartKey
+	}
+	return schema.Prefix + id
+}
+
+// id returns the ID of the record stored under key; singletons have none.
+func (schema assetSchema) id(key string) string {
+	if schema.EndKey == schema.StartKey+"\x00" {
+		return ""
+	}
+	return strings.TrimPrefix(key, schema.Prefix)
+}
+
+// rangeStart returns the key a paged scan of the kind resumes from; an empty
+// bookmark starts from the first record.
+func (schema assetSchema) rangeStart(kind string, bookmark string) (string, error) {
+	if bookmark == "" {
+		return schema.StartKey, nil
+	}
+	if bookmark < schema.StartKey || bookmark >= schema.EndKey {
+		return "", errcode.New(errcode.InvalidArgument, "Bookmark "+bookmark+" is not a "+kind+" key.")
+	}
+	return bookmark, nil
+}
+
+// introduceSchemaVersion is the 0 -> 1 step of kinds whose layout did not change.
+func introduceSchemaVersion(record map[string]json.RawMessage) error {
+	return nil
+}
+
+// migrateOrderCostKey moves OrderCost from the "status" key it was mistakenly
+// serialized under to "orderCost".
+func migrateOrderCostKey(record map[string]json.RawMessage) error {
+	if cost, ok := record["status"]; ok {
+		if _, exists := record["orderCost"]; !exists {
+			record["orderCost"] = cost
+		}
+		delete(record, "status")
+	}
+	return nil
+}
+
+// assetKind is the assetSchemas key of a stored asset, or "" for unversioned types.
+func assetKind(asset interface{}) string {
+	assetType := reflect.TypeOf(asset)
+	for assetType.Kind() == reflect.Ptr {
+		assetType = assetType.Elem()
+	}
+	if _, ok := assetSchemas[assetType.Name()]; !ok {
+		return ""
+	}
+	return assetType.Name()
+}
+
+// upgradeAsset brings a stored record of the given kind up to the current schema.
+// Current records are returned unchanged.
+func upgradeAsset(kind string, data []byte) ([]byte, error) {
+	schema, ok := assetSchemas[kind]
+	if !ok {
+		return nil, errors.New("Unknown asset kind " + kind + ".")
+	}
+	current := int64(len(schema.Migrations))
+
+	var record map[string]json.RawMessage
+	err := json.Unmarshal(data, &record)
+	if err != nil {
+		return nil, errors.New("Failed to unmarshal " + kind + ": " + err.Error())
+	}
+	var version int64
+	if raw, ok := record["schemaVersion"]; ok {
+		err = json.Unmarshal(raw, &version)
+		if err != nil {
+			return nil, errors.New("Failed to parse " + kind + " schemaVersion: " + err.Error())
+		}
+	}
+	if version == current {
+		return data, nil
+	}
+	if version > current || version < 0 {
+		return nil, errors.New(kind + " schemaVersion " + strconv.FormatInt(version, 10) + " is not supported by this chaincode (current " + strconv.FormatInt(current, 10) + ").")
+	}
+
+	for ; version < current; version++ {
+		err = schema.Migrations[version](record)
+		if err != nil {
+			return nil, errors.New("Failed to migrate " + kind + " from schemaVersion " + strconv.FormatInt(version, 10) + ": " + err.Error())
+		}
+	}
+	record["schemaVersion"] = json.RawMessage(strconv.FormatInt(current, 10))
+	return json.Marshal(record)
+}
+
+// decodeAsset unmarshals a stored record into asset, upgrading it to the current
+// schema first. Unversioned types are unmarshalled as they are.
+func decodeAsset(data []byte, asset interface{}) error {
+	kind := assetKind(asset)
+	if kind != "" {
+		var err error
+		data, err = upgradeAsset(kind, data)
+		if err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, asset)
+}
+
+// encodeAsset stamps asset, a pointer to a stored type, with its current schema
+// version and marshals it for PutState.
+func encodeAsset(asset interface{}) ([]byte, error) {
+	if kind := assetKind(asset); kind != "" {
+		value := reflect.ValueOf(asset)
+		for value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+		field := value.FieldByName("SchemaVersion")
+		if field.IsValid() && field.CanSet() {
+			field.SetInt(int64(len(assetSchemas[kind].Migrations)))
+		}
+	}
+	return json.Marshal(asset)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Schema Write Methods                             */
+/* -------------------------------------------------------------------------- */
+
+// ============================================================================================================================
+// MigrateAssets() - platform admin rewrites up to pageSize records of one asset kind at the current schema version
+//
+// Pass the returned bookmark back in to continue with the next page; an empty bookmark starts from the first record.
+// The indexes of kinds that have them are rebuilt for every record scanned, which backfills indexes added after
+// the records were written.
+//
+// Inputs - Array of strings
+//     0   ,     1     ,     2
+//    kind ,  pageSize ,  bookmark
+//  "Order",   "100"   ,   ""
+// ============================================================================================================================
+func MigrateAssets(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 3.")
+	}
+
+	err := assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	kind := args[0]
+	schema, ok := assetSchemas[kind]
+	if !ok {
+		return errorResponse(errcode.InvalidArgument, "Unknown asset kind "+kind+".")
+	}
+	pageSize, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse page size: "+err.Error())
+	}
+	if pageSize <= 0 {
+		return errorResponse(errcode.InvalidArgument, "Page size must be positive.")
+	}
+	startKey, err := schema.rangeStart(kind, args[2])
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	iterator, err := stub.GetStateByRange(startKey, schema.EndKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query "+kind+" records: "+err.Error())
+	}
+	defer iterator.Close()
+
+	page := MigrationPage{Kind: kind}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to read "+kind+" records: "+err.Error())
+		}
+		if page.Scanned == pageSize {
+			page.Bookmark = entry.Key
+			break
+		}
+		page.Scanned++
+
+		upgraded, err := upgradeAsset(kind, entry.Value)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to migrate "+entry.Key+": "+err.Error())
+		}
+		if schema.Index != nil {
+			err = schema.Index(stub, upgraded)
+			if err != nil {
+				return errorResponse(errcode.Internal, "Could not index "+entry.Key+": "+err.Error())
+			}
+		}
+		if string(upgraded) == string(entry.Value) {
+			continue
+		}
+		err = stub.PutState(entry.Key, upgraded)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Could not store "+entry.Key+": "+err.Error())
+		}
+		page.Migrated++
+	}
+
+	pageAsBytes, _ := json.Marshal(page)
+	return shim.Success(pageAsBytes)
+}
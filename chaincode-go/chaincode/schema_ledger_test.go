@@ -0,0 +1,105 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateAssets(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+
+	// Orders written before versioning carry OrderCost under "status".
+	stub.MockTransactionStart("seedOrders")
+	_ = stub.PutState("Order_1", []byte(`{"bidMatchId":0,"bidStatus":0,"id":1,"status":500,"totalQuantity":100,"unitCost":5,"userId":3}`))
+	_ = stub.PutState("Order_2", []byte(`{"bidMatchId":0,"bidStatus":0,"id":2,"status":80,"totalQuantity":20,"unitCost":4,"userId":4}`))
+	_ = stub.PutState("Order_3", []byte(`{"id":3,"orderCost":10,"schemaVersion":1,"totalQuantity":2,"unitCost":5,"userId":4}`))
+	stub.MockTransactionEnd("seedOrders")
+
+	// Test Case 1: Legacy records are upgraded on read
+	t.Run("Upgrade On Read", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{[]byte("ReadOrder"), []byte("1")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var order Order
+		err := json.Unmarshal(response.GetPayload(), &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, 500.0, order.OrderCost, "OrderCost not migrated")
+		assert.Equal(t, int64(1), order.SchemaVersion, "SchemaVersion mismatch")
+		assert.NotContains(t, string(response.GetPayload()), `"status"`, "Legacy key returned")
+
+		// Reading does not rewrite the ledger.
+		stored, _ := stub.GetState("Order_1")
+		assert.Contains(t, string(stored), `"status":500`, "Record rewritten on read")
+	})
+
+	// Test Case 2: Only the platform admin can migrate
+	t.Run("Non-Admin Refused", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "operator")
+		defer setCreator(t, stub, "Org1MSP", "admin")
+
+		response := stub.MockInvoke("2", [][]byte{[]byte("MigrateAssets"), []byte("Order"), []byte("10"), []byte("")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+	})
+
+	// Test Case 3: MigrateAssets rewrites records a page at a time
+	t.Run("Paged Migration", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("MigrateAssets"), []byte("Order"), []byte("2"), []byte("")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var page MigrationPage
+		err := json.Unmarshal(response.GetPayload(), &page)
+		assert.NoError(t, err, "Error unmarshalling page")
+		assert.Equal(t, MigrationPage{Bookmark: "Order_3", Kind: "Order", Migrated: 2, Scanned: 2}, page)
+
+		response = stub.MockInvoke("4", [][]byte{[]byte("MigrateAssets"), []byte("Order"), []byte("2"), []byte(page.Bookmark)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		err = json.Unmarshal(response.GetPayload(), &page)
+		assert.NoError(t, err, "Error unmarshalling page")
+		assert.Equal(t, MigrationPage{Kind: "Order", Migrated: 0, Scanned: 1}, page, "Current record rewritten")
+
+		stored, _ := stub.GetState("Order_2")
+		var order Order
+		err = json.Unmarshal(stored, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, 80.0, order.OrderCost, "OrderCost mismatch")
+		assert.Equal(t, int64(1), order.SchemaVersion, "SchemaVersion mismatch")
+		assert.NotContains(t, string(stored), `"status"`, "Legacy key kept")
+	})
+
+	// Test Case 4: Records from a newer chaincode are not silently downgraded
+	t.Run("Newer Schema", func(t *testing.T) {
+		stub.MockTransactionStart("seedNewer")
+		_ = stub.PutState("Order_4", []byte(`{"id":4,"schemaVersion":9}`))
+		stub.MockTransactionEnd("seedNewer")
+
+		response := stub.MockInvoke("5", [][]byte{[]byte("ReadOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not supported")
+	})
+
+	// Test Case 5: Unknown kinds are rejected
+	t.Run("Unknown Kind", func(t *testing.T) {
+		response := stub.MockInvoke("6", [][]byte{[]byte("MigrateAssets"), []byte("Widget"), []byte("10"), []byte("")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Unknown asset kind")
+	})
+
+	// Test Case 6: Migrating BidMatches backfills their slot index
+	t.Run("Index Backfill", func(t *testing.T) {
+		stub.MockTransactionStart("seedBidMatch")
+		_ = stub.PutState("BidMatch_9", []byte(`{"id":9,"bidSlot":"slot1","schemaVersion":1}`))
+		stub.MockTransactionEnd("seedBidMatch")
+
+		response := stub.MockInvoke("7", [][]byte{[]byte("MigrateAssets"), []byte("BidMatch"), []byte("10"), []byte("")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		indexKey, _ := stub.CreateCompositeKey(BidMatchSlotIndex, []string{"slot1", "9"})
+		indexed, _ := stub.GetState(indexKey)
+		assert.NotNil(t, indexed, "Slot index not backfilled")
+	})
+}
@@ -0,0 +1,401 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Transient map key carrying the SealedBid cleartext for CommitOrder and RevealOrder
+const sealedBidTransientKey = "sealed_bid"
+
+// Shortest salt accepted; a short salt lets anyone brute force the price from the public commitment.
+const minSealedBidSaltLength = 16
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// sealedBidCommitment is the value a client hashes when committing: the unit cost
+// and quantity exactly as they will be revealed, and a secret salt.
+func sealedBidCommitment(bid SealedBid) string {
+	sum := sha256.Sum256([]byte(bid.UnitCost + ":" + bid.TotalQuantity + ":" + bid.Salt))
+	return hex.EncodeToString(sum[:])
+}
+
+func getSealedOrder(stub shim.ChaincodeStubInterface, orderID string) (SealedOrder, bool, error) {
+	var sealed SealedOrder
+	sealedAsBytes, err := stub.GetState("SealedOrder_" + orderID)
+	if err != nil {
+		return sealed, false, errors.New("Error accessing state: " + err.Error())
+	}
+	if sealedAsBytes == nil {
+		return sealed, false, nil
+	}
+	err = decodeAsset(sealedAsBytes, &sealed)
+	if err != nil {
+		return sealed, false, errors.New("Failed to unmarshal sealed order: " + err.Error())
+	}
+	return sealed, true, nil
+}
+
+func sealedBidFromTransient(stub shim.ChaincodeStubInterface) (SealedBid, bool, error) {
+	var bid SealedBid
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return bid, false, errors.New("Error reading transient data: " + err.Error())
+	}
+	bidAsBytes, ok := transientMap[sealedBidTransientKey]
+	if !ok {
+		return bid, false, nil
+	}
+	err = json.Unmarshal(bidAsBytes, &bid)
+	if err != nil {
+		return bid, false, errors.New("Failed to unmarshal transient " + sealedBidTransientKey + ": " + err.Error())
+	}
+	return bid, true, nil
+}
+
+func checkSealedBidSalt(bid SealedBid) error {
+	if len(bid.Salt) < minSealedBidSaltLength {
+		return errcode.New(errcode.InvalidArgument, "Sealed bid must contain a secret salt of at least "+strconv.Itoa(minSealedBidSaltLength)+" characters")
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Sealed Order Write Methods                        */
+/* -------------------------------------------------------------------------- */
+
+// parseSealedOrderRequest reads the positional arguments of CommitOrder.
+func parseSealedOrderRequest(args []string) (SealedOrderRequest, error) {
+	var request SealedOrderRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.ID, err = strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse order ID: "+err.Error())
+	}
+	request.SlotID = args[1]
+	request.UserID, err = strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse UserID: "+err.Error())
+	}
+	request.UserAction, err = parseAction(args[3])
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid action "+args[3])
+	}
+	request.Commitment = args[4]
+	return request, validateRequest(&request)
+}
+
+func (request *SealedOrderRequest) validate() []FieldError {
+	fieldErrors := nonEmpty(nil, map[string]string{"slotId": request.SlotID})
+	if decoded, err := hex.DecodeString(request.Commitment); err != nil || len(decoded) != sha256.Size {
+		fieldErrors = append(fieldErrors, FieldError{Field: "commitment", Message: "must be a hex encoded SHA-256 digest"})
+	}
+	if request.ID <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "id", Message: "must be positive"})
+	}
+	return fieldErrors
+}
+
+// ============================================================================================================================
+// CommitOrder() - the user commits to a hidden order for a slot that is still before gate closure
+//
+// Inputs - Array of strings
+//                                           0
+//                                        payload
+//  "{"id": 4, "slotId": "slot1", "userId": 6, "action": "Buy", "commitment": "5e884898da..."}"
+// The positional form (orderID, slotID, userID, action, commitment) is still accepted,
+// with optional transient "sealed_bid" = {"unitCost": "...", "totalQuantity": "...", "salt": "..."},
+// which is checked against the commitment and kept in the user's org collection for the reveal.
+// The salt must be a secret of at least 16 characters, or the reveal is refused.
+// ============================================================================================================================
+func CommitOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request SealedOrderRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 5 {
+		request, err = parseSealedOrderRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 5.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	orderID := request.ID
+	// Keys are built from the parsed ID so "4" and "04" name the same order.
+	key := strconv.FormatInt(orderID, 10)
+	userID := request.UserID
+	action := request.UserAction
+	commitment := strings.ToLower(request.Commitment)
+
+	// The ID must be free both as a commitment and as a plain order.
+	_, found, err := getSealedOrder(stub, key)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	existingOrderAsBytes, err := stub.GetState("Order_" + key)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	if found || existingOrderAsBytes != nil {
+		return errorResponse(errcode.Conflict, "Order with ID "+key+" already exists.")
+	}
+
+	err = assertUserCaller(stub, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = assertTermsAccepted(stub, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	_, err = getOpenTradingSlot(stub, request.SlotID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	// Keep the cleartext off the channel, in the user's own org collection.
+	bid, hasBid, err := sealedBidFromTransient(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if hasBid {
+		err = checkSealedBidSalt(bid)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		if sealedBidCommitment(bid) != commitment {
+			return errorResponse(errcode.InvalidArgument, "Transient "+sealedBidTransientKey+" does not match the commitment")
+		}
+		user, err := getUser(stub, userID)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		if user.OrgMSP == "" {
+			return errorResponse(errcode.StateViolation, "User "+strconv.FormatInt(userID, 10)+" has no private data collection")
+		}
+		bidAsBytes, _ := json.Marshal(bid)
+		err = stub.PutPrivateData(userPIICollection(user.OrgMSP), "SealedBid_"+key, bidAsBytes)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Could not store sealed bid: "+err.Error())
+		}
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	sealed := SealedOrder{
+		Commitment: commitment,
+		CreatedOn:  now,
+		ID:         orderID,
+		SlotID:     request.SlotID,
+		UserAction: action,
+		UserID:     userID,
+	}
+	sealedAsBytes, err := encodeAsset(&sealed)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal sealed order: "+err.Error())
+	}
+	err = stub.PutState("SealedOrder_"+key, sealedAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store sealed order: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// ============================================================================================================================
+// RevealOrder() - after gate closure the user opens a commitment, which registers it as an Order
+//
+// Inputs - Array of strings
+//     0
+//  orderID
+// with transient "sealed_bid" = {"unitCost": "...", "totalQuantity": "...", "salt": "..."},
+// or without it when the cleartext was stored in the user's org collection by CommitOrder.
+// ============================================================================================================================
+func RevealOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse order ID: "+err.Error())
+	}
+	key := strconv.FormatInt(orderID, 10)
+	sealed, found, err := getSealedOrder(stub, key)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if !found {
+		return errorResponse(errcode.NotFound, "SealedOrder with ID "+key+" not found.")
+	}
+	if sealed.Revealed {
+		return errorResponse(errcode.StateViolation, "SealedOrder with ID "+key+" has already been revealed")
+	}
+	err = assertUserCaller(stub, sealed.UserID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	// Reveals open at gate closure and end once the slot has been matched.
+	slot, err := getTradingSlot(stub, sealed.SlotID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if now < slot.GateClosure {
+		return errorResponse(errcode.StateViolation, "Gate closure of TradingSlot "+slot.ID+" has not passed yet")
+	}
+	if slot.Status != SlotOpen && slot.Status != SlotClosed {
+		return errorResponse(errcode.StateViolation, "TradingSlot "+slot.ID+" is "+SlotStatusString(slot.Status))
+	}
+
+	bid, hasBid, err := sealedBidFromTransient(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if !hasBid {
+		user, err := getUser(stub, sealed.UserID)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		bidAsBytes, err := stub.GetPrivateData(userPIICollection(user.OrgMSP), "SealedBid_"+key)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Error accessing private data: "+err.Error())
+		}
+		if bidAsBytes == nil {
+			return errorResponse(errcode.InvalidArgument, "No sealed bid given in transient "+sealedBidTransientKey+" or stored for order "+key)
+		}
+		err = json.Unmarshal(bidAsBytes, &bid)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal sealed bid: "+err.Error())
+		}
+	}
+	err = checkSealedBidSalt(bid)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if sealedBidCommitment(bid) != sealed.Commitment {
+		return errorResponse(errcode.InvalidArgument, "Revealed bid does not match the commitment of order "+key)
+	}
+
+	unitCost, err := strconv.ParseFloat(bid.UnitCost, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse UnitCost: "+err.Error())
+	}
+	totalQuantity, err := strconv.ParseInt(bid.TotalQuantity, 10, 64)
+	if err != nil || totalQuantity <= 0 {
+		return errorResponse(errcode.InvalidArgument, "Invalid TotalQuantity "+bid.TotalQuantity)
+	}
+
+	// The revealed price is held to the same band as an open order.
+	marketPrice, err := getMarketPrice(stub, sealed.SlotID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = checkPriceBand(stub, marketPrice, unitCost)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	order := Order{
+		BidStatus:         BidCreated,
+		CreatedOn:         now,
+		ID:                sealed.ID,
+		OnMarketPrice:     strconv.FormatFloat(marketPrice.Price, 'f', -1, 64),
+		OrderCost:         unitCost * float64(totalQuantity),
+		RemainingQuantity: float64(totalQuantity),
+		SlotID:            sealed.SlotID,
+		SlotExecDate:      slot.StartTime,
+		TotalQuantity:     totalQuantity,
+		UnitCost:          unitCost,
+		UserAction:        sealed.UserAction,
+		UserID:            sealed.UserID,
+	}
+	order.UpdatedOn = order.CreatedOn
+	err = putOrder(stub, order)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store order: "+err.Error())
+	}
+
+	sealed.Revealed = true
+	sealed.RevealedOn = now
+	sealedAsBytes, err := encodeAsset(&sealed)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal sealed order: "+err.Error())
+	}
+	err = stub.PutState("SealedOrder_"+key, sealedAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store sealed order: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Sealed Order Read Methods                         */
+/* -------------------------------------------------------------------------- */
+
+func ReadSealedOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the order ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse order ID: "+err.Error())
+	}
+	key := strconv.FormatInt(orderID, 10)
+	sealed, found, err := getSealedOrder(stub, key)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if !found {
+		return errorResponse(errcode.NotFound, "SealedOrder with ID "+key+" does not exist.")
+	}
+
+	sealedAsBytes, _ := json.Marshal(sealed)
+	return shim.Success(sealedAsBytes)
+}
@@ -0,0 +1,141 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealedOrders(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org2MSP", "user6")
+
+	registerUser(t, stub, []string{"6", "Consumer", "Location 6", "MeterId 6", "Battery"})
+	seedTradingSlot(t, stub, "slot1")
+	seedMarketPrice(t, stub, "slot1", 3.5)
+
+	bid := SealedBid{Salt: "s3cr3t-s4lt-0f-16+", TotalQuantity: "300", UnitCost: "3.6"}
+	bidAsBytes, _ := json.Marshal(bid)
+	commitment := sealedBidCommitment(bid)
+
+	// Test Case 1: The commitment is public, the price and quantity are not
+	t.Run("Commit Sealed Order", func(t *testing.T) {
+		stub.TransientMap = map[string][]byte{"sealed_bid": bidAsBytes}
+		defer func() { stub.TransientMap = nil }()
+		response := stub.MockInvoke("2", [][]byte{
+			[]byte("CommitOrder"),
+			[]byte("4"),        // orderID
+			[]byte("slot1"),    // slotID
+			[]byte("6"),        // userID
+			[]byte("0"),        // action
+			[]byte(commitment), // commitment
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		sealedAsBytes, _ := stub.GetState("SealedOrder_4")
+		assert.NotContains(t, string(sealedAsBytes), "3.6", "Price leaked to the public state")
+		assert.NotNil(t, stub.PvtState["Org2MSPPrivateCollection"]["SealedBid_4"], "Sealed bid not kept privately")
+
+		orderAsBytes, _ := stub.GetState("Order_4")
+		assert.Nil(t, orderAsBytes, "Order registered before the reveal")
+	})
+
+	// Test Case 2: The ID cannot be reused and the bid cannot be opened early
+	t.Run("Reveal Before Gate Closure", func(t *testing.T) {
+		response := stub.MockInvoke("3", [][]byte{[]byte("RegisterOrder"), []byte("1"), []byte("0"), []byte("4"), []byte("0"),
+			[]byte("200"), []byte("5"), []byte("slot1"), []byte("300"), []byte("3.5"), []byte("6"), []byte("50"), []byte("0")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "sealed until revealed")
+
+		response = stub.MockInvoke("3", [][]byte{[]byte("CommitOrder"), []byte("04"), []byte("slot1"), []byte("6"), []byte("0"), []byte(commitment)})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "CONFLICT")
+
+		response = stub.MockInvoke("4", [][]byte{[]byte("RevealOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "has not passed yet")
+	})
+
+	// Move the slot past its gate closure.
+	past := time.Now().Add(-time.Minute).Unix()
+	slotAsBytes, _ := json.Marshal(TradingSlot{ID: "slot1", GateClosure: past, StartTime: past + 3600, EndTime: past + 4500})
+	stub.MockTransactionStart("closeSlot")
+	_ = stub.PutState("TradingSlot_slot1", slotAsBytes)
+	stub.MockTransactionEnd("closeSlot")
+
+	// Test Case 3: A reveal that does not match the commitment is rejected
+	t.Run("Mismatched Reveal", func(t *testing.T) {
+		forged, _ := json.Marshal(SealedBid{Salt: "s3cr3t-s4lt-0f-16+", TotalQuantity: "300", UnitCost: "3.0"})
+		stub.TransientMap = map[string][]byte{"sealed_bid": forged}
+		defer func() { stub.TransientMap = nil }()
+		response := stub.MockInvoke("5", [][]byte{[]byte("RevealOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "does not match the commitment")
+	})
+
+	// Test Case 4: A short salt is refused even when it matches the commitment
+	t.Run("Short Salt Reveal", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user8")
+		registerUser(t, stub, []string{"8", "Consumer", "Location 8", "MeterId 8", "Battery"})
+		// Commit before gate closure, then close the slot again.
+		open := TradingSlot{ID: "slot1", GateClosure: time.Now().Add(time.Hour).Unix(), StartTime: past + 3600, EndTime: past + 4500}
+		openAsBytes, _ := json.Marshal(open)
+		stub.MockTransactionStart("reopenSlot")
+		_ = stub.PutState("TradingSlot_slot1", openAsBytes)
+		stub.MockTransactionEnd("reopenSlot")
+
+		short := SealedBid{Salt: "s3cr3t", TotalQuantity: "300", UnitCost: "3.6"}
+		shortAsBytes, _ := json.Marshal(short)
+		stub.TransientMap = map[string][]byte{"sealed_bid": shortAsBytes}
+		response := stub.MockInvoke("6", [][]byte{[]byte("CommitOrder"), []byte("5"), []byte("slot1"), []byte("8"), []byte("0"), []byte(sealedBidCommitment(short))})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "at least 16 characters")
+
+		stub.TransientMap = nil
+		response = stub.MockInvoke("7", [][]byte{[]byte("CommitOrder"), []byte("5"), []byte("slot1"), []byte("8"), []byte("0"), []byte(sealedBidCommitment(short))})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		stub.MockTransactionStart("closeSlot")
+		_ = stub.PutState("TradingSlot_slot1", slotAsBytes)
+		stub.MockTransactionEnd("closeSlot")
+
+		stub.TransientMap = map[string][]byte{"sealed_bid": shortAsBytes}
+		defer func() { stub.TransientMap = nil }()
+		response = stub.MockInvoke("8", [][]byte{[]byte("RevealOrder"), []byte("5")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "at least 16 characters")
+
+		orderAsBytes, _ := stub.GetState("Order_5")
+		assert.Nil(t, orderAsBytes, "Order registered with a short salt")
+	})
+
+	// Test Case 5: Only the owner can reveal, under any spelling of the ID, and the reveal registers the order
+	t.Run("Reveal After Gate Closure", func(t *testing.T) {
+		setCreator(t, stub, "Org2MSP", "user7")
+		response := stub.MockInvoke("6", [][]byte{[]byte("RevealOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is not the owner")
+
+		setCreator(t, stub, "Org2MSP", "user6")
+		response = stub.MockInvoke("7", [][]byte{[]byte("RevealOrder"), []byte("04")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var order Order
+		orderAsBytes, _ := stub.GetState("Order_4")
+		err := json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, 3.6, order.UnitCost, "UnitCost mismatch")
+		assert.Equal(t, int64(300), order.TotalQuantity, "TotalQuantity mismatch")
+		assert.Equal(t, past+3600, order.SlotExecDate, "SlotExecDate mismatch")
+		assert.Equal(t, BidCreated, order.BidStatus, "BidStatus mismatch")
+
+		response = stub.MockInvoke("8", [][]byte{[]byte("RevealOrder"), []byte("4")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "already been revealed")
+	})
+}
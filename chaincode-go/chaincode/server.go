@@ -0,0 +1,249 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Chaincode-as-a-service mode. When CHAINCODE_SERVER_ADDRESS is set the binary
+// listens for the peer instead of dialling it, so it can run outside the
+// peer-managed container, e.g. under an orchestrator or a debugger:
+//
+//	CHAINCODE_ID                 package ID the peer knows the chaincode by (required)
+//	CHAINCODE_SERVER_ADDRESS     gRPC listen address, e.g. "0.0.0.0:9999"
+//	CHAINCODE_TLS_KEY            PEM key file; TLS is enabled when key and cert are set
+//	CHAINCODE_TLS_CERT           PEM certificate file
+//	CHAINCODE_CLIENT_CA_CERT     PEM CA file; when set, peers must present a certificate it signed
+//	CHAINCODE_READINESS_ADDRESS  optional HTTP listen address for /healthz and /readyz
+
+// How long in-flight transactions get to finish once a shutdown signal arrives
+const shutdownGracePeriod = 30 * time.Second
+
+// serverConfig is the chaincode-as-a-service configuration.
+type serverConfig struct {
+	Address          string
+	CCID             string
+	ClientCACert     []byte
+	ReadinessAddress string
+	TLSCert          []byte
+	TLSKey           []byte
+}
+
+// chaincodeService serves the chaincode to peers over gRPC and reports its
+// readiness over HTTP.
+type chaincodeService struct {
+	grpcServer      *grpc.Server
+	readinessServer *http.Server
+	ready           int32 // 1 while serving and not shutting down
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// serverConfigFromEnv reads the service configuration. ok is false when
+// CHAINCODE_SERVER_ADDRESS is not set and the chaincode should dial the peer.
+func serverConfigFromEnv() (config serverConfig, ok bool, err error) {
+	config.Address = os.Getenv("CHAINCODE_SERVER_ADDRESS")
+	if config.Address == "" {
+		return config, false, nil
+	}
+	config.CCID = os.Getenv("CHAINCODE_ID")
+	if config.CCID == "" {
+		return config, true, errors.New("CHAINCODE_ID must be set when CHAINCODE_SERVER_ADDRESS is")
+	}
+	config.ReadinessAddress = os.Getenv("CHAINCODE_READINESS_ADDRESS")
+
+	keyFile, certFile, caFile := os.Getenv("CHAINCODE_TLS_KEY"), os.Getenv("CHAINCODE_TLS_CERT"), os.Getenv("CHAINCODE_CLIENT_CA_CERT")
+	if (keyFile == "") != (certFile == "") {
+		return config, true, errors.New("CHAINCODE_TLS_KEY and CHAINCODE_TLS_CERT must be set together")
+	}
+	if caFile != "" && keyFile == "" {
+		return config, true, errors.New("CHAINCODE_CLIENT_CA_CERT requires CHAINCODE_TLS_KEY and CHAINCODE_TLS_CERT")
+	}
+	files := []struct {
+		path   string
+		target *[]byte
+	}{{keyFile, &config.TLSKey}, {certFile, &config.TLSCert}, {caFile, &config.ClientCACert}}
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		*file.target, err = ioutil.ReadFile(file.path)
+		if err != nil {
+			return config, true, errors.New("Failed to read " + file.path + ": " + err.Error())
+		}
+	}
+	return config, true, nil
+}
+
+// tlsConfig is the server TLS configuration, nil when TLS is disabled.
+func (c serverConfig) tlsConfig() (*tls.Config, error) {
+	if c.TLSKey == nil {
+		return nil, nil
+	}
+	certificate, err := tls.X509KeyPair(c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, errors.New("Failed to load TLS key pair: " + err.Error())
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+	if c.ClientCACert != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(c.ClientCACert) {
+			return nil, errors.New("Failed to parse client CA certificate")
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             Chaincode Service                              */
+/* -------------------------------------------------------------------------- */
+
+// newChaincodeService registers cc with a gRPC server set up like the one of
+// shim.ChaincodeServer.Start, keeping hold of it so it can be stopped gracefully.
+func newChaincodeService(config serverConfig, cc shim.Chaincode) (*chaincodeService, error) {
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	options := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: time.Minute, Timeout: 20 * time.Second}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: time.Minute, PermitWithoutStream: true}),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	service := &chaincodeService{grpcServer: grpc.NewServer(options...)}
+	pb.RegisterChaincodeServer(service.grpcServer, &shim.ChaincodeServer{CCID: config.CCID, Address: config.Address, CC: cc})
+
+	if config.ReadinessAddress != "" {
+		service.readinessServer = &http.Server{Addr: config.ReadinessAddress, Handler: service.readinessHandler(), ReadHeaderTimeout: 5 * time.Second}
+	}
+	return service, nil
+}
+
+// readinessHandler answers /healthz while the process is up and /readyz while
+// peers can be served.
+func (s *chaincodeService) readinessHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&s.ready) == 1 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	return mux
+}
+
+// Serve serves peers on listener, and readiness probes on their own address,
+// until Shutdown is called.
+func (s *chaincodeService) Serve(listener net.Listener) error {
+	if s.readinessServer != nil {
+		readinessListener, err := net.Listen("tcp", s.readinessServer.Addr)
+		if err != nil {
+			return errors.New("Failed to listen for readiness probes: " + err.Error())
+		}
+		go s.readinessServer.Serve(readinessListener)
+	}
+	atomic.StoreInt32(&s.ready, 1)
+	err := s.grpcServer.Serve(listener)
+	atomic.StoreInt32(&s.ready, 0)
+	return err
+}
+
+// Shutdown reports the service as not ready and lets open transactions finish,
+// stopping hard once ctx is done.
+func (s *chaincodeService) Shutdown(ctx context.Context) {
+	atomic.StoreInt32(&s.ready, 0)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+	if s.readinessServer != nil {
+		s.readinessServer.Shutdown(ctx)
+	}
+}
+
+// runChaincodeServer serves cc until SIGINT or SIGTERM, then shuts down gracefully.
+func runChaincodeServer(config serverConfig, cc shim.Chaincode) error {
+	service, err := newChaincodeService(config, cc)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", config.Address)
+	if err != nil {
+		return errors.New("Failed to listen on " + config.Address + ": " + err.Error())
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		received := <-signals
+		writeLogEntry(levelInfo, "Shutting down chaincode server", logFields{"signal": received.String()})
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+		defer cancel()
+		service.Shutdown(ctx)
+	}()
+
+	writeLogEntry(levelInfo, "Starting chaincode server", logFields{"address": config.Address, "ccid": config.CCID, "tls": config.TLSKey != nil})
+	err = service.Serve(listener)
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	// Serve only returns cleanly once Shutdown has stopped the server.
+	<-shutdownDone
+	return nil
+}
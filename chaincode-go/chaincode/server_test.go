@@ -0,0 +1,148 @@
+package chaincode
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// writeTLSFiles writes a self-signed certificate and its key as PEM files.
+func writeTLSFiles(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "chaincode"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err.Error())
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %s", err.Error())
+	}
+
+	dir := t.TempDir()
+	keyFile, certFile := filepath.Join(dir, "key.pem"), filepath.Join(dir, "cert.pem")
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err == nil {
+		err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600)
+	}
+	if err != nil {
+		t.Fatalf("Failed to write TLS files: %s", err.Error())
+	}
+	return keyFile, certFile
+}
+
+func TestServerConfigFromEnv(t *testing.T) {
+	// Test Case 1: Without a server address the chaincode dials the peer as before
+	t.Run("Peer Managed", func(t *testing.T) {
+		t.Setenv("CHAINCODE_SERVER_ADDRESS", "")
+		_, external, err := serverConfigFromEnv()
+		assert.NoError(t, err)
+		assert.False(t, external, "Service mode without an address")
+	})
+
+	// Test Case 2: Service mode needs the chaincode ID and a complete key pair
+	t.Run("Incomplete Configuration", func(t *testing.T) {
+		t.Setenv("CHAINCODE_SERVER_ADDRESS", "0.0.0.0:9999")
+		t.Setenv("CHAINCODE_ID", "")
+		_, _, err := serverConfigFromEnv()
+		assert.EqualError(t, err, "CHAINCODE_ID must be set when CHAINCODE_SERVER_ADDRESS is")
+
+		t.Setenv("CHAINCODE_ID", "basic:abc")
+		t.Setenv("CHAINCODE_TLS_KEY", "/tmp/key.pem")
+		_, _, err = serverConfigFromEnv()
+		assert.EqualError(t, err, "CHAINCODE_TLS_KEY and CHAINCODE_TLS_CERT must be set together")
+	})
+
+	// Test Case 3: TLS files are loaded and a client CA makes peer certificates mandatory
+	t.Run("Mutual TLS", func(t *testing.T) {
+		keyFile, certFile := writeTLSFiles(t)
+		t.Setenv("CHAINCODE_SERVER_ADDRESS", "0.0.0.0:9999")
+		t.Setenv("CHAINCODE_ID", "basic:abc")
+		t.Setenv("CHAINCODE_TLS_KEY", keyFile)
+		t.Setenv("CHAINCODE_TLS_CERT", certFile)
+		t.Setenv("CHAINCODE_CLIENT_CA_CERT", certFile)
+
+		config, external, err := serverConfigFromEnv()
+		assert.NoError(t, err)
+		assert.True(t, external, "Service mode not detected")
+		assert.Equal(t, "basic:abc", config.CCID, "CCID mismatch")
+
+		tlsConfig, err := config.tlsConfig()
+		assert.NoError(t, err)
+		assert.Len(t, tlsConfig.Certificates, 1, "Certificate not loaded")
+		assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth, "ClientAuth mismatch")
+	})
+}
+
+func TestChaincodeService(t *testing.T) {
+	service, err := newChaincodeService(serverConfig{CCID: "basic:abc", Address: "127.0.0.1:0"}, new(SimpleChaincode))
+	assert.NoError(t, err)
+	readiness := service.readinessHandler()
+	probe := func(path string) int {
+		recorder := httptest.NewRecorder()
+		readiness.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz"), "Ready before serving")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- service.Serve(listener) }()
+
+	// Test Case 1: Peers can connect once the service is serving
+	t.Run("Serving", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+		assert.NoError(t, err, "Failed to connect to the chaincode server")
+		if conn != nil {
+			conn.Close()
+		}
+		assert.Equal(t, http.StatusOK, probe("/readyz"), "Not ready while serving")
+		assert.Equal(t, http.StatusOK, probe("/healthz"), "Not healthy while serving")
+	})
+
+	// Test Case 2: Shutdown stops serving and withdraws readiness
+	t.Run("Shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		service.Shutdown(ctx)
+
+		select {
+		case err := <-served:
+			assert.NoError(t, err, "Serve failed")
+		case <-time.After(5 * time.Second):
+			t.Fatal("Serve did not return after Shutdown")
+		}
+		assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz"), "Ready after shutdown")
+		assert.Equal(t, http.StatusOK, probe("/healthz"), "Not healthy after shutdown")
+	})
+}
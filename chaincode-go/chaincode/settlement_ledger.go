@@ -0,0 +1,486 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+const settlementConfigKey = "SettlementConfig"
+
+// Counterparty of every settlement payment; users are debited to and credited from the platform.
+const settlementAccount = "platform"
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// getSettlementConfig returns the stored config, or gross settlement without penalties.
+func getSettlementConfig(stub shim.ChaincodeStubInterface) (SettlementConfig, error) {
+	var config SettlementConfig
+	configAsBytes, err := stub.GetState(settlementConfigKey)
+	if err != nil {
+		return config, errors.New("Error accessing state: " + err.Error())
+	}
+	if configAsBytes == nil {
+		return config, nil
+	}
+	err = decodeAsset(configAsBytes, &config)
+	if err != nil {
+		return config, errors.New("Failed to unmarshal settlement config: " + err.Error())
+	}
+	return config, nil
+}
+
+// getSlotSettlement returns the settlement of a slot, if any round has been settled.
+func getSlotSettlement(stub shim.ChaincodeStubInterface, slotID string) (SlotSettlement, bool, error) {
+	var settlement SlotSettlement
+	settlementAsBytes, err := stub.GetState("SlotSettlement_" + slotID)
+	if err != nil {
+		return settlement, false, errors.New("Error accessing state: " + err.Error())
+	}
+	if settlementAsBytes == nil {
+		return settlement, false, nil
+	}
+	err = decodeAsset(settlementAsBytes, &settlement)
+	if err != nil {
+		return settlement, false, errors.New("Failed to unmarshal slot settlement: " + err.Error())
+	}
+	return settlement, true, nil
+}
+
+// assertBidMatchNotSettled refuses changes to a match paid in an earlier settlement
+// round of a slot that is still waiting on disputed matches.
+func assertBidMatchNotSettled(stub shim.ChaincodeStubInterface, bidMatch BidMatch) error {
+	settlement, found, err := getSlotSettlement(stub, bidMatch.BidSlot)
+	if err != nil || !found {
+		return err
+	}
+	for _, bidMatchID := range settlement.BidMatchIDs {
+		if bidMatchID == bidMatch.ID {
+			return errcode.New(errcode.StateViolation, "BidMatch "+strconv.FormatInt(bidMatch.ID, 10)+" has already been settled")
+		}
+	}
+	return nil
+}
+
+// queryBidMatchesBySlot lists the BidMatches of a slot in ID order, from the slot index only.
+// Matches written before the index existed must be indexed by MigrateAssets "BidMatch" first.
+func queryBidMatchesBySlot(stub shim.ChaincodeStubInterface, slotID string) ([]BidMatch, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(BidMatchSlotIndex, []string{slotID})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	bidMatches := []BidMatch{}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := stub.SplitCompositeKey(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		bidMatch, err := getBidMatch(stub, keyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		bidMatches = append(bidMatches, bidMatch)
+	}
+	sort.Slice(bidMatches, func(i, j int) bool { return bidMatches[i].ID < bidMatches[j].ID })
+	return bidMatches, nil
+}
+
+// settlementPosition accumulates what one user owes and is owed in a slot.
+type settlementPosition struct {
+	credit  float64
+	debit   float64
+	fee     float64
+	penalty float64
+	value   float64
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Settlement Write Methods                         */
+/* -------------------------------------------------------------------------- */
+
+// parseSettlementConfigRequest reads the positional arguments of SetSettlementConfig.
+func parseSettlementConfigRequest(args []string) (SettlementConfigRequest, error) {
+	var request SettlementConfigRequest
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.NetPerUser, err = strconv.ParseBool(args[0])
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse netPerUser: "+err.Error())
+	}
+	request.PenaltyPct, err = strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse penalty: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *SettlementConfigRequest) validate() []FieldError {
+	if request.PenaltyPct < 0 || request.PenaltyPct > 100 {
+		return []FieldError{{Field: "penaltyPct", Message: "must be between 0 and 100"}}
+	}
+	return nil
+}
+
+// ============================================================================================================================
+// SetSettlementConfig() - platform admin sets how slots are settled
+//
+// Inputs - Array of strings
+//                    0
+//                 payload
+//  "{"netPerUser": true, "penaltyPct": 10}"
+// The positional form (netPerUser, penaltyPct) is still accepted.
+// ============================================================================================================================
+func SetSettlementConfig(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request SettlementConfigRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 2 {
+		request, err = parseSettlementConfigRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 2.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	config := SettlementConfig{
+		NetPerUser: request.NetPerUser,
+		PenaltyPct: request.PenaltyPct,
+		UpdatedOn:  now,
+	}
+	configAsBytes, err := encodeAsset(&config)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal settlement config: "+err.Error())
+	}
+	err = stub.PutState(settlementConfigKey, configAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store settlement config: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// ============================================================================================================================
+// SettleSlot() - platform admin turns the executed BidMatches of a closed slot into payments, in one transaction
+//
+// Buyers are debited the delivered value plus the platform fee, sellers are credited the delivered value
+// less a penalty on undelivered units. With netPerUser each user gets a single payment for the round.
+// Matches under an open dispute are left pending and the slot stays unsettled; once the disputes are
+// resolved, calling SettleSlot again settles them in a further round and marks the slot Settled.
+// Matches are read from the slot index, so run MigrateAssets "BidMatch" once after upgrading
+// from a version without it.
+//
+// Inputs - Array of strings
+//     0
+//   slotID
+// ============================================================================================================================
+func SettleSlot(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	err := assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	identity, err := callerIdentity(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	slot, err := getTradingSlot(stub, args[0])
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if slot.Status == SlotSettled {
+		return errorResponse(errcode.StateViolation, "TradingSlot "+slot.ID+" is already settled")
+	}
+	if slot.Status == SlotOpen {
+		return errorResponse(errcode.InvalidArgument, "TradingSlot "+slot.ID+" must be closed before it is settled")
+	}
+
+	config, err := getSettlementConfig(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	schedule, found, err := getActiveFeeSchedule(stub)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to read fee schedule: "+err.Error())
+	}
+	if !found {
+		return errorResponse(errcode.StateViolation, "No active fee schedule to compute the platform fee.")
+	}
+
+	bidMatches, err := queryBidMatchesBySlot(stub, slot.ID)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query BidMatches: "+err.Error())
+	}
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	// Earlier rounds are carried forward and their matches are not paid again.
+	settlement, found, err := getSlotSettlement(stub, slot.ID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if !found {
+		settlement = SlotSettlement{BidMatchIDs: []int64{}, PaymentIDs: []string{}, SlotID: slot.ID}
+	}
+	settled := map[int64]bool{}
+	for _, bidMatchID := range settlement.BidMatchIDs {
+		settled[bidMatchID] = true
+	}
+	settlement.NetPerUser = config.NetPerUser
+	settlement.PendingBidMatchIDs = []int64{}
+	settlement.Rounds++
+	settlement.SettledBy = identity
+	settlement.SettledOn = now
+
+	// Price every executed match before writing anything.
+	positions := map[int64]*settlementPosition{}
+	position := func(userID int64) *settlementPosition {
+		if positions[userID] == nil {
+			positions[userID] = &settlementPosition{}
+		}
+		return positions[userID]
+	}
+	type matchSettlement struct {
+		bidMatch BidMatch
+		fee      float64
+		penalty  float64
+		value    float64
+	}
+	matches := []matchSettlement{}
+	for _, bidMatch := range bidMatches {
+		if bidMatch.BidStatus != BidExecuted || settled[bidMatch.ID] {
+			continue
+		}
+		disputed, err := isDisputed(stub, bidMatch.ID)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		if disputed {
+			settlement.PendingBidMatchIDs = append(settlement.PendingBidMatchIDs, bidMatch.ID)
+			continue
+		}
+
+		value := roundCents(bidMatch.DeliveredBidUnits * float64(bidMatch.BidUnitPrice))
+		fee, err := computePlatformFee(stub, schedule, bidMatch.BuyerUserId, value)
+		if err != nil {
+			return wrapErrorResponse("Failed to compute platform fee: ", err)
+		}
+		var penalty float64
+		if bidMatch.OriginalBidUnits > bidMatch.DeliveredBidUnits {
+			undelivered := (bidMatch.OriginalBidUnits - bidMatch.DeliveredBidUnits) * float64(bidMatch.BidUnitPrice)
+			penalty = roundCents(undelivered * config.PenaltyPct / 100)
+		}
+
+		matches = append(matches, matchSettlement{bidMatch: bidMatch, fee: fee, penalty: penalty, value: value})
+		buyer := position(bidMatch.BuyerUserId)
+		buyer.debit += value + fee
+		buyer.fee += fee
+		buyer.value += value
+		seller := position(bidMatch.SellerUserId)
+		seller.credit += value - penalty
+		seller.penalty += penalty
+		seller.value += value
+
+		settlement.BidMatchIDs = append(settlement.BidMatchIDs, bidMatch.ID)
+		settlement.TotalFees += fee
+		settlement.TotalPenalties += penalty
+		settlement.TotalValue += value
+	}
+	if len(matches) == 0 && len(settlement.PendingBidMatchIDs) > 0 {
+		return errorResponse(errcode.StateViolation, "Every unsettled BidMatch of TradingSlot "+slot.ID+" is under an open dispute")
+	}
+	settlement.TotalFees = roundCents(settlement.TotalFees)
+	settlement.TotalPenalties = roundCents(settlement.TotalPenalties)
+	settlement.TotalValue = roundCents(settlement.TotalValue)
+
+	// PaymentDetail IDs are derived from the transaction ID, one sequence number per payment.
+	detailSeq := 0
+	record := func(p Payment, pd PaymentDetail) error {
+		pd.ID = txSequenceID(stub, detailSeq)
+		pd.FeeScheduleVersion = schedule.Version
+		detailSeq++
+		p.CreatedOn = settlement.SettledOn
+		err := putPayment(stub, p, pd)
+		if err != nil {
+			return err
+		}
+		settlement.PaymentIDs = append(settlement.PaymentIDs, p.ID)
+		return nil
+	}
+
+	if config.NetPerUser {
+		userIDs := []int64{}
+		for userID := range positions {
+			userIDs = append(userIDs, userID)
+		}
+		sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+		for _, userID := range userIDs {
+			pos := positions[userID]
+			net := roundCents(pos.credit - pos.debit)
+			paymentID := "Settlement_" + slot.ID + "_" + strconv.FormatInt(userID, 10)
+			if settlement.Rounds > 1 {
+				paymentID += "_" + strconv.FormatInt(settlement.Rounds, 10)
+			}
+			p := Payment{
+				ID:          paymentID,
+				PaymentType: SellerEnergySoldTokenRefund,
+				TotalAmount: net,
+				UserID:      userID,
+			}
+			pd := PaymentDetail{
+				DebitedFrom:       settlementAccount,
+				CreditedTo:        strconv.FormatInt(userID, 10),
+				TotalUnitCost:     roundCents(pos.value),
+				PlatformFee:       roundCents(pos.fee),
+				PenaltyFromSeller: roundCents(pos.penalty),
+			}
+			if net < 0 {
+				p.PaymentType = BuyerEnergyPurchased
+				p.TotalAmount = -net
+				pd.DebitedFrom, pd.CreditedTo = pd.CreditedTo, pd.DebitedFrom
+			}
+			err = record(p, pd)
+			if err != nil {
+				return errorResponseFrom(err)
+			}
+		}
+	} else {
+		for _, match := range matches {
+			bidMatchID := strconv.FormatInt(match.bidMatch.ID, 10)
+			err = record(Payment{
+				BidMatchID:  match.bidMatch.ID,
+				ID:          "Settlement_" + slot.ID + "_" + bidMatchID + "_Buy",
+				PaymentType: BuyerEnergyPurchased,
+				TotalAmount: roundCents(match.value + match.fee),
+				UserID:      match.bidMatch.BuyerUserId,
+			}, PaymentDetail{
+				DebitedFrom:   strconv.FormatInt(match.bidMatch.BuyerUserId, 10),
+				CreditedTo:    settlementAccount,
+				TotalUnitCost: match.value,
+				PlatformFee:   match.fee,
+			})
+			if err != nil {
+				return errorResponseFrom(err)
+			}
+			err = record(Payment{
+				BidMatchID:  match.bidMatch.ID,
+				ID:          "Settlement_" + slot.ID + "_" + bidMatchID + "_Sell",
+				PaymentType: SellerEnergySoldTokenRefund,
+				TotalAmount: roundCents(match.value - match.penalty),
+				UserID:      match.bidMatch.SellerUserId,
+			}, PaymentDetail{
+				DebitedFrom:       settlementAccount,
+				CreditedTo:        strconv.FormatInt(match.bidMatch.SellerUserId, 10),
+				TotalUnitCost:     match.value,
+				PenaltyFromSeller: match.penalty,
+			})
+			if err != nil {
+				return errorResponseFrom(err)
+			}
+		}
+	}
+
+	settlementAsBytes, err := encodeAsset(&settlement)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal slot settlement: "+err.Error())
+	}
+	err = stub.PutState("SlotSettlement_"+slot.ID, settlementAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store slot settlement: "+err.Error())
+	}
+
+	// A settled slot accepts no further matches or settlements; it is only settled once no match is pending.
+	if len(settlement.PendingBidMatchIDs) == 0 {
+		slot.Status = SlotSettled
+		slot.UpdatedOn = settlement.SettledOn
+		err = putTradingSlot(stub, slot)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Could not store trading slot: "+err.Error())
+		}
+	}
+
+	return shim.Success(settlementAsBytes)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Settlement Read Methods                          */
+/* -------------------------------------------------------------------------- */
+
+func ReadSlotSettlement(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the slot ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	settlementAsBytes, err := stub.GetState("SlotSettlement_" + args[0])
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	if settlementAsBytes == nil {
+		return errorResponse(errcode.NotFound, "SlotSettlement for TradingSlot "+args[0]+" does not exist.")
+	}
+
+	settlementAsBytes, err = upgradeAsset("SlotSettlement", settlementAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(settlementAsBytes)
+}
@@ -0,0 +1,236 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettleSlot(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+
+	for _, user := range [][]string{
+		{"3", "Consumer", "Location 3", "MeterId 3", "Battery"},
+		{"4", "Prosumer", "Location 4", "MeterId 4", "Solar"},
+	} {
+		registerUser(t, stub, user)
+	}
+	response := stub.MockInvoke("2", [][]byte{[]byte("ProposeFeeSchedule"), []byte(`{"percentFee":2}`)})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	response = stub.MockInvoke("3", [][]byte{[]byte("ApproveFeeSchedule"), []byte("1")})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Each slot has a buy and a sell order of both users, numbered <slot><6 for buy, 7 for sell><user>.
+	orderID := func(slotID string, side string, userID string) string {
+		return strings.TrimPrefix(slotID, "slot") + side + userID
+	}
+	seedSlot := func(slotID string) {
+		seedTradingSlot(t, stub, slotID)
+		for _, userID := range []int64{3, 4} {
+			for side, action := range map[string]Action{"6": Buy, "7": Sell} {
+				id, _ := strconv.ParseInt(orderID(slotID, side, strconv.FormatInt(userID, 10)), 10, 64)
+				seedOrder(t, stub, Order{ID: id, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: slotID, TotalQuantity: 1000, UserAction: action, UserID: userID})
+			}
+		}
+	}
+	bidMatch := func(id string, slotID string, status string, delivered string, buyer string, seller string) [][]byte {
+		return [][]byte{
+			[]byte("ProcessBidMatch"),
+			[]byte(strconv.FormatInt(time.Now().Unix(), 10)), // bidMatchTms
+			[]byte(slotID),                                   // bidSlot
+			[]byte(status),                                   // bidStatus
+			[]byte("5"),                                      // bidUnitPrice
+			[]byte(buyer),                                    // buyerUserId
+			[]byte(delivered),                                // deliveredBidUnits
+			[]byte(id),                                       // ID
+			[]byte("100"),                                    // originalBidUnits
+			[]byte(seller),                                   // sellerUserId
+			[]byte(orderID(slotID, "6", buyer)),              // transactionBuyID
+			[]byte(orderID(slotID, "7", seller)),             // transactionSellID
+		}
+	}
+	readPayment := func(paymentID string) (Payment, PaymentDetail) {
+		var payment Payment
+		paymentAsBytes, _ := stub.GetState("Payment_" + paymentID)
+		err := json.Unmarshal(paymentAsBytes, &payment)
+		assert.NoError(t, err, "Error unmarshalling payment "+paymentID)
+		var detail PaymentDetail
+		detailAsBytes, _ := stub.GetState("PaymentDetail_" + strconv.FormatInt(payment.PaymentDetailId, 10))
+		err = json.Unmarshal(detailAsBytes, &detail)
+		assert.NoError(t, err, "Error unmarshalling payment detail "+paymentID)
+		return payment, detail
+	}
+
+	seedSlot("slot1")
+	for i, args := range [][][]byte{
+		bidMatch("1", "slot1", "3", "100", "3", "4"),
+		bidMatch("2", "slot1", "3", "80", "3", "4"),
+		bidMatch("3", "slot1", "2", "0", "3", "4"),
+	} {
+		response := stub.MockInvoke(strconv.Itoa(10+i), args)
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	}
+
+	// Test Case 1: A slot still taking orders cannot be settled
+	t.Run("Open Slot Refused", func(t *testing.T) {
+		response := stub.MockInvoke("20", [][]byte{[]byte("SettleSlot"), []byte("slot1")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "must be closed")
+	})
+
+	// Test Case 2: Each executed match yields a buyer debit and a seller credit
+	t.Run("Gross Settlement", func(t *testing.T) {
+		response := stub.MockInvoke("21", [][]byte{[]byte("SetSettlementConfig"), []byte("false"), []byte("10")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("22", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte("slot1"), []byte("Closed")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("23", [][]byte{[]byte("SettleSlot"), []byte("slot1")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var settlement SlotSettlement
+		err := json.Unmarshal(response.GetPayload(), &settlement)
+		assert.NoError(t, err, "Error unmarshalling settlement")
+		assert.Equal(t, []int64{1, 2}, settlement.BidMatchIDs, "Rejected match settled")
+		assert.Len(t, settlement.PaymentIDs, 4, "Unexpected number of payments")
+		assert.Equal(t, 18.0, settlement.TotalFees, "TotalFees mismatch")
+		assert.Equal(t, 10.0, settlement.TotalPenalties, "TotalPenalties mismatch")
+
+		buy, buyDetail := readPayment("Settlement_slot1_1_Buy")
+		assert.Equal(t, 510.0, buy.TotalAmount, "Buyer debit mismatch")
+		assert.Equal(t, "3", buyDetail.DebitedFrom, "DebitedFrom mismatch")
+		assert.Equal(t, 10.0, buyDetail.PlatformFee, "PlatformFee mismatch")
+
+		sell, sellDetail := readPayment("Settlement_slot1_2_Sell")
+		assert.Equal(t, 390.0, sell.TotalAmount, "Seller credit mismatch")
+		assert.Equal(t, 10.0, sellDetail.PenaltyFromSeller, "PenaltyFromSeller mismatch")
+		assert.NotEqual(t, buy.PaymentDetailId, sell.PaymentDetailId, "PaymentDetail IDs collide")
+	})
+
+	// Test Case 3: A settled slot is final
+	t.Run("Settle Twice", func(t *testing.T) {
+		response := stub.MockInvoke("24", [][]byte{[]byte("SettleSlot"), []byte("slot1")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "already settled")
+
+		response = stub.MockInvoke("25", bidMatch("2", "slot1", "3", "100", "3", "4"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "already settled")
+	})
+
+	// Test Case 4: With netting each user gets one payment for the slot
+	t.Run("Net Settlement", func(t *testing.T) {
+		seedSlot("slot2")
+		response := stub.MockInvoke("26", bidMatch("4", "slot2", "3", "100", "3", "4"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("27", bidMatch("5", "slot2", "3", "40", "4", "3"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("28", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte("slot2"), []byte("Settled")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "only be settled through SettleSlot")
+
+		response = stub.MockInvoke("29", [][]byte{[]byte("SetSettlementConfig"), []byte("true"), []byte("0")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("30", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte("slot2"), []byte("Matched")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("31", [][]byte{[]byte("SettleSlot"), []byte("slot2")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		// User 3 owes 500 + 10 fee and is owed 200; user 4 is owed 500 and owes 200 + 4 fee.
+		buyer, buyerDetail := readPayment("Settlement_slot2_3")
+		assert.Equal(t, BuyerEnergyPurchased, buyer.PaymentType, "PaymentType mismatch")
+		assert.Equal(t, 310.0, buyer.TotalAmount, "Net debit mismatch")
+		assert.Equal(t, "3", buyerDetail.DebitedFrom, "DebitedFrom mismatch")
+		seller, sellerDetail := readPayment("Settlement_slot2_4")
+		assert.Equal(t, SellerEnergySoldTokenRefund, seller.PaymentType, "PaymentType mismatch")
+		assert.Equal(t, 296.0, seller.TotalAmount, "Net credit mismatch")
+		assert.Equal(t, "4", sellerDetail.CreditedTo, "CreditedTo mismatch")
+
+		response = stub.MockInvoke("32", [][]byte{[]byte("ReadTradingSlot"), []byte("slot2")})
+		var slot TradingSlot
+		err := json.Unmarshal(response.GetPayload(), &slot)
+		assert.NoError(t, err, "Error unmarshalling slot")
+		assert.Equal(t, SlotSettled, slot.Status, "Status mismatch")
+	})
+
+	// Test Case 5: A disputed match is left pending while the rest of the slot is settled
+	t.Run("Disputed Match Pending", func(t *testing.T) {
+		seedSlot("slot3")
+		response := stub.MockInvoke("33", bidMatch("6", "slot3", "3", "100", "3", "4"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("34", bidMatch("7", "slot3", "3", "40", "3", "4"))
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		stub.MockTransactionStart("openDispute")
+		_ = stub.PutState(openDisputeKey(6), []byte("D6"))
+		stub.MockTransactionEnd("openDispute")
+		response = stub.MockInvoke("35", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte("slot3"), []byte("Closed")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("36", [][]byte{[]byte("SettleSlot"), []byte("slot3")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var settlement SlotSettlement
+		err := json.Unmarshal(response.GetPayload(), &settlement)
+		assert.NoError(t, err, "Error unmarshalling settlement")
+		assert.Equal(t, []int64{7}, settlement.BidMatchIDs, "BidMatchIDs mismatch")
+		assert.Equal(t, []int64{6}, settlement.PendingBidMatchIDs, "PendingBidMatchIDs mismatch")
+		buyer, _ := readPayment("Settlement_slot3_3")
+		assert.Equal(t, 204.0, buyer.TotalAmount, "Net debit mismatch")
+
+		// The paid match is final, and the slot stays open to settlement until the dispute closes.
+		response = stub.MockInvoke("37", bidMatch("7", "slot3", "3", "20", "3", "4"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "has already been settled")
+		response = stub.MockInvoke("38", [][]byte{[]byte("SettleSlot"), []byte("slot3")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "under an open dispute")
+
+		stub.MockTransactionStart("resolveDispute")
+		_ = stub.DelState(openDisputeKey(6))
+		stub.MockTransactionEnd("resolveDispute")
+		response = stub.MockInvoke("39", [][]byte{[]byte("SettleSlot"), []byte("slot3")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		err = json.Unmarshal(response.GetPayload(), &settlement)
+		assert.NoError(t, err, "Error unmarshalling settlement")
+		assert.Equal(t, []int64{7, 6}, settlement.BidMatchIDs, "BidMatchIDs mismatch")
+		assert.Empty(t, settlement.PendingBidMatchIDs, "Match left pending")
+		assert.Equal(t, int64(2), settlement.Rounds, "Rounds mismatch")
+		assert.Equal(t, 700.0, settlement.TotalValue, "TotalValue mismatch")
+		buyer, _ = readPayment("Settlement_slot3_3_2")
+		assert.Equal(t, 510.0, buyer.TotalAmount, "Second round debit mismatch")
+
+		slot, err := getTradingSlot(stub, "slot3")
+		assert.NoError(t, err, "Error reading slot")
+		assert.Equal(t, SlotSettled, slot.Status, "Status mismatch")
+	})
+
+	// Test Case 6: Matches written before the slot index existed are found once migrated
+	t.Run("Migrated Matches", func(t *testing.T) {
+		seedSlot("slot4")
+		legacyAsBytes, _ := json.Marshal(BidMatch{BidSlot: "slot4", BidStatus: BidExecuted, BidUnitPrice: 5, BuyerUserId: 3,
+			DeliveredBidUnits: 100, ID: 8, OriginalBidUnits: 100, SellerUserId: 4})
+		stub.MockTransactionStart("seedLegacyBidMatch")
+		_ = stub.PutState("BidMatch_8", legacyAsBytes)
+		stub.MockTransactionEnd("seedLegacyBidMatch")
+		response := stub.MockInvoke("40", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte("slot4"), []byte("Closed")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		// Matches written before the slot index are settled once MigrateAssets has indexed them.
+		response = invokeAsAdmin(t, stub, "41", [][]byte{[]byte("MigrateAssets"), []byte("BidMatch"), []byte("100"), []byte("")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("42", [][]byte{[]byte("SettleSlot"), []byte("slot4")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		var settlement SlotSettlement
+		err := json.Unmarshal(response.GetPayload(), &settlement)
+		assert.NoError(t, err, "Error unmarshalling settlement")
+		assert.Equal(t, []int64{8}, settlement.BidMatchIDs, "Migrated match not settled")
+	})
+}
@@ -0,0 +1,449 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Upper bound on slots created by one GenerateTradingSlots call.
+const maxGeneratedSlots = 1000
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func getTradingSlot(stub shim.ChaincodeStubInterface, slotID string) (TradingSlot, error) {
+	var slot TradingSlot
+	slotAsBytes, err := stub.GetState("TradingSlot_" + slotID)
+	if err != nil {
+		return slot, errors.New("Error accessing state: " + err.Error())
+	}
+	if slotAsBytes == nil {
+		return slot, errcode.New(errcode.NotFound, "TradingSlot with ID "+slotID+" does not exist.")
+	}
+	err = decodeAsset(slotAsBytes, &slot)
+	if err != nil {
+		return slot, errors.New("Failed to unmarshal trading slot: " + err.Error())
+	}
+	return slot, nil
+}
+
+func putTradingSlot(stub shim.ChaincodeStubInterface, slot TradingSlot) error {
+	slotAsBytes, err := encodeAsset(&slot)
+	if err != nil {
+		return err
+	}
+	return stub.PutState("TradingSlot_"+slot.ID, slotAsBytes)
+}
+
+// createTradingSlot validates the slot times and stores a new Open slot.
+func createTradingSlot(stub shim.ChaincodeStubInterface, slotID string, startTime int64, endTime int64, gateClosure int64) error {
+	if endTime <= startTime {
+		return errcode.New(errcode.InvalidArgument, "Slot "+slotID+" must end after it starts")
+	}
+	if gateClosure > startTime {
+		return errcode.New(errcode.InvalidArgument, "Gate closure of slot "+slotID+" must not be after its start")
+	}
+
+	existingSlotAsBytes, err := stub.GetState("TradingSlot_" + slotID)
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	if existingSlotAsBytes != nil {
+		return errcode.New(errcode.Conflict, "TradingSlot with ID "+slotID+" already exists")
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	slot := TradingSlot{
+		CreatedOn:   now,
+		EndTime:     endTime,
+		GateClosure: gateClosure,
+		ID:          slotID,
+		StartTime:   startTime,
+		Status:      SlotOpen,
+	}
+	slot.UpdatedOn = slot.CreatedOn
+	return putTradingSlot(stub, slot)
+}
+
+// getOpenTradingSlot loads a slot that still accepts orders at the transaction timestamp.
+func getOpenTradingSlot(stub shim.ChaincodeStubInterface, slotID string) (TradingSlot, error) {
+	slot, err := getTradingSlot(stub, slotID)
+	if err != nil {
+		return slot, err
+	}
+	if slot.Status != SlotOpen {
+		return slot, errcode.New(errcode.StateViolation, "TradingSlot "+slotID+" is "+SlotStatusString(slot.Status))
+	}
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return slot, err
+	}
+	if now >= slot.GateClosure {
+		return slot, errcode.New(errcode.StateViolation, "Gate closure of TradingSlot "+slotID+" has passed")
+	}
+	return slot, nil
+}
+
+// assertSlotNotSettled refuses changes to a settled slot. Slots that were never
+// put on the calendar have nothing to protect.
+func assertSlotNotSettled(stub shim.ChaincodeStubInterface, slotID string) error {
+	slotAsBytes, err := stub.GetState("TradingSlot_" + slotID)
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	if slotAsBytes == nil {
+		return nil
+	}
+	var slot TradingSlot
+	err = decodeAsset(slotAsBytes, &slot)
+	if err != nil {
+		return errors.New("Failed to unmarshal trading slot: " + err.Error())
+	}
+	if slot.Status == SlotSettled {
+		return errcode.New(errcode.StateViolation, "TradingSlot "+slotID+" is already settled")
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              Slot Write Methods                            */
+/* -------------------------------------------------------------------------- */
+
+// parseTradingSlotRequest reads the positional arguments of CreateTradingSlot.
+func parseTradingSlotRequest(args []string) (TradingSlotRequest, error) {
+	var request TradingSlotRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.ID = args[0]
+	request.StartTime, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse start time: "+err.Error())
+	}
+	request.EndTime, err = strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse end time: "+err.Error())
+	}
+	request.GateClosure, err = strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse gate closure: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *TradingSlotRequest) validate() []FieldError {
+	fieldErrors := nonEmpty(nil, map[string]string{"id": request.ID})
+	if request.EndTime <= request.StartTime {
+		fieldErrors = append(fieldErrors, FieldError{Field: "endTime", Message: "must be after startTime"})
+	}
+	if request.GateClosure > request.StartTime {
+		fieldErrors = append(fieldErrors, FieldError{Field: "gateClosure", Message: "must not be after startTime"})
+	}
+	return fieldErrors
+}
+
+// ============================================================================================================================
+// CreateTradingSlot() - platform admin opens a single slot
+//
+// Inputs - Array of strings
+//                                              0
+//                                           payload
+//  "{"id": "slot1234", "startTime": 1700003600, "endTime": 1700004500, "gateClosure": 1700000000}"
+// The positional form (slotID, startTime, endTime, gateClosure) is still accepted.
+// ============================================================================================================================
+func CreateTradingSlot(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request TradingSlotRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 4 {
+		request, err = parseTradingSlotRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 4.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = createTradingSlot(stub, request.ID, request.StartTime, request.EndTime, request.GateClosure)
+	if err != nil {
+		return wrapErrorResponse("Could not create trading slot: ", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// parseTradingSlotsRequest reads the positional arguments of GenerateTradingSlots.
+func parseTradingSlotsRequest(args []string) (TradingSlotsRequest, error) {
+	var request TradingSlotsRequest
+	var err error
+
+	err = sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.FirstStart, err = strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse first start time: "+err.Error())
+	}
+	request.DurationSec, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse slot duration: "+err.Error())
+	}
+	request.Count, err = strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse slot count: "+err.Error())
+	}
+	request.GateClosureLeadSec, err = strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse gate closure lead time: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *TradingSlotsRequest) validate() []FieldError {
+	var fieldErrors []FieldError
+	if request.Count <= 0 || request.Count > maxGeneratedSlots {
+		fieldErrors = append(fieldErrors, FieldError{Field: "count", Message: "must be between 1 and " + strconv.Itoa(maxGeneratedSlots)})
+	}
+	if request.DurationSec <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "durationSec", Message: "must be positive"})
+	}
+	return nonNegative(fieldErrors, map[string]float64{"gateClosureLeadSec": float64(request.GateClosureLeadSec)})
+}
+
+// ============================================================================================================================
+// GenerateTradingSlots() - platform admin opens consecutive slots, each identified by its start time
+//
+// Inputs - Array of strings
+//                                         0
+//                                      payload
+//  "{"firstStart": 1700003600, "durationSec": 900, "count": 96, "gateClosureLeadSec": 3600}"
+// The positional form (firstStart, durationSec, count, gateClosureLeadSec) is still accepted.
+// ============================================================================================================================
+func GenerateTradingSlots(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request TradingSlotsRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 4 {
+		request, err = parseTradingSlotsRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 4.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	firstStart := request.FirstStart
+	duration := request.DurationSec
+	count := request.Count
+	gateClosureLead := request.GateClosureLeadSec
+
+	slotIDs := []string{}
+	for i := int64(0); i < count; i++ {
+		startTime := firstStart + i*duration
+		slotID := strconv.FormatInt(startTime, 10)
+		err = createTradingSlot(stub, slotID, startTime, startTime+duration, startTime-gateClosureLead)
+		if err != nil {
+			return wrapErrorResponse("Could not create trading slot: ", err)
+		}
+		slotIDs = append(slotIDs, slotID)
+	}
+
+	slotIDsAsBytes, _ := json.Marshal(slotIDs)
+	return shim.Success(slotIDsAsBytes)
+}
+
+// parseTradingSlotStatusRequest reads the positional arguments of UpdateTradingSlotStatus.
+func parseTradingSlotStatusRequest(args []string) (TradingSlotStatusRequest, error) {
+	var request TradingSlotStatusRequest
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.ID = args[0]
+	status, ok := slotStatusMap[args[1]]
+	if !ok {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid slot status provided.")
+	}
+	request.Status = status
+	return request, validateRequest(&request)
+}
+
+func (request *TradingSlotStatusRequest) validate() []FieldError {
+	return nonEmpty(nil, map[string]string{"id": request.ID})
+}
+
+// ============================================================================================================================
+// UpdateTradingSlotStatus() - platform admin moves a slot forward through Open, Closed, Matched and Settled
+//
+// Inputs - Array of strings
+//                     0
+//                  payload
+//  "{"id": "slot1234", "status": "Closed"}"
+// The positional form (slotID, status) is still accepted.
+// ============================================================================================================================
+func UpdateTradingSlotStatus(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request TradingSlotStatusRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 2 {
+		request, err = parseTradingSlotStatusRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 2.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	status := request.Status
+	slot, err := getTradingSlot(stub, request.ID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if status <= slot.Status {
+		return errorResponse(errcode.StateViolation, "TradingSlot "+slot.ID+" cannot move from "+SlotStatusString(slot.Status)+" to "+SlotStatusString(status))
+	}
+	if status == SlotSettled {
+		return errorResponse(errcode.StateViolation, "TradingSlot "+slot.ID+" can only be settled through SettleSlot")
+	}
+
+	slot.Status = status
+	slot.UpdatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = putTradingSlot(stub, slot)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store trading slot: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              Slot Read Methods                             */
+/* -------------------------------------------------------------------------- */
+
+func ReadTradingSlot(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the slot ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	slotAsBytes, err := stub.GetState("TradingSlot_" + args[0])
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to fetch TradingSlot with ID "+args[0]+" from the ledger: "+err.Error())
+	}
+
+	if slotAsBytes == nil {
+		return errorResponse(errcode.NotFound, "TradingSlot with ID "+args[0]+" not found.")
+	}
+
+	slotAsBytes, err = upgradeAsset("TradingSlot", slotAsBytes)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(slotAsBytes)
+}
+
+// ============================================================================================================================
+// QueryTradingSlots() - slots starting within a time range, ordered by start time
+//
+// Inputs - Array of strings
+//      0      ,      1
+//  fromStart  ,   toStart
+// ============================================================================================================================
+func QueryTradingSlots(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	fromStart, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse start of range: "+err.Error())
+	}
+	toStart, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse end of range: "+err.Error())
+	}
+
+	startKey, endKey := prefixRange("TradingSlot_")
+	iterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query trading slots: "+err.Error())
+	}
+	defer iterator.Close()
+
+	slots := []TradingSlot{}
+	for iterator.HasNext() {
+		entry, err := iterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to query trading slots: "+err.Error())
+		}
+		var slot TradingSlot
+		err = decodeAsset(entry.Value, &slot)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal trading slot: "+err.Error())
+		}
+		if slot.StartTime >= fromStart && slot.StartTime <= toStart {
+			slots = append(slots, slot)
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].StartTime < slots[j].StartTime })
+
+	slotsAsBytes, _ := json.Marshal(slots)
+	return shim.Success(slotsAsBytes)
+}
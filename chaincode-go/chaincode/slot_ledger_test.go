@@ -0,0 +1,119 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTradingSlots(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+	seedUser(t, stub, 6, "Org1MSP:admin")
+
+	firstStart := time.Now().Add(2 * time.Hour).Unix()
+
+	// Test Case 1: Generate consecutive slots
+	t.Run("Generate Trading Slots", func(t *testing.T) {
+		response := stub.MockInvoke("1", [][]byte{
+			[]byte("GenerateTradingSlots"),
+			[]byte(strconv.FormatInt(firstStart, 10)), // firstStart
+			[]byte("900"),  // durationSec
+			[]byte("4"),    // count
+			[]byte("3600"), // gateClosureLeadSec
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		response = stub.MockInvoke("2", [][]byte{
+			[]byte("QueryTradingSlots"),
+			[]byte(strconv.FormatInt(firstStart, 10)),
+			[]byte(strconv.FormatInt(firstStart+1800, 10)),
+		})
+		var slots []TradingSlot
+		err := json.Unmarshal(response.GetPayload(), &slots)
+		assert.NoError(t, err, "Error unmarshalling slots")
+		assert.Len(t, slots, 3, "Unexpected number of slots")
+		assert.Equal(t, firstStart-3600, slots[0].GateClosure, "GateClosure mismatch")
+		assert.Equal(t, SlotOpen, slots[0].Status, "Status mismatch")
+	})
+
+	// Test Case 2: Orders are accepted for open slots and take the slot's start as exec date
+	t.Run("Order For Open Slot", func(t *testing.T) {
+		slotID := strconv.FormatInt(firstStart, 10)
+		seedMarketPrice(t, stub, slotID, 3.5)
+		response := stub.MockInvoke("3", [][]byte{
+			[]byte("RegisterOrder"),
+			[]byte("1"),    // bidMatchID
+			[]byte("0"),    // bidStatus
+			[]byte("4"),    // orderID
+			[]byte("0"),    // onMarketPrice
+			[]byte("200"),  // orderCost
+			[]byte("5"),    // paymentID
+			[]byte(slotID), // slotID
+			[]byte("300"),  // totalQuantity
+			[]byte("3.5"),  // unitCost
+			[]byte("6"),    // userID
+			[]byte("50"),   // slotExecDate
+			[]byte("0"),    // action
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		orderAsBytes, _ := stub.GetState("Order_4")
+		var order Order
+		err := json.Unmarshal(orderAsBytes, &order)
+		assert.NoError(t, err, "Error unmarshalling order")
+		assert.Equal(t, firstStart, order.SlotExecDate, "SlotExecDate mismatch")
+	})
+
+	// Test Case 3: Unknown slots, closed slots and slots past gate closure are rejected
+	t.Run("Order For Unknown Or Closed Slot", func(t *testing.T) {
+		order := func(slotID string) [][]byte {
+			return [][]byte{[]byte("RegisterOrder"), []byte("1"), []byte("0"), []byte("5"), []byte("0"), []byte("200"),
+				[]byte("5"), []byte(slotID), []byte("300"), []byte("3.5"), []byte("6"), []byte("50"), []byte("0")}
+		}
+
+		response := stub.MockInvoke("4", order("slotUnknown"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "does not exist")
+
+		closedSlot := strconv.FormatInt(firstStart+900, 10)
+		seedMarketPrice(t, stub, closedSlot, 3.5)
+		response = stub.MockInvoke("5", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte(closedSlot), []byte("Closed")})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		response = stub.MockInvoke("6", order(closedSlot))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "is Closed")
+
+		pastStart := time.Now().Add(-time.Hour).Unix()
+		response = stub.MockInvoke("7", [][]byte{
+			[]byte("CreateTradingSlot"),
+			[]byte("slotPast"),
+			[]byte(strconv.FormatInt(pastStart, 10)),
+			[]byte(strconv.FormatInt(pastStart+900, 10)),
+			[]byte(strconv.FormatInt(pastStart-60, 10)),
+		})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+		seedMarketPrice(t, stub, "slotPast", 3.5)
+		response = stub.MockInvoke("8", order("slotPast"))
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "Gate closure")
+	})
+
+	// Test Case 4: Slot status only moves forward and only for the admin org
+	t.Run("Slot Status Transitions", func(t *testing.T) {
+		closedSlot := strconv.FormatInt(firstStart+900, 10)
+		response := stub.MockInvoke("9", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte(closedSlot), []byte("Open")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+
+		setCreator(t, stub, "Org2MSP", "user1")
+		response = stub.MockInvoke("10", [][]byte{[]byte("UpdateTradingSlotStatus"), []byte(closedSlot), []byte("Matched")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "not a platform admin")
+	})
+}
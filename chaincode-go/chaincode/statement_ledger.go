@@ -0,0 +1,333 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Statement is a user's monthly account statement returned by GenerateStatement.
+// It only depends on ledger data, so the same period always yields the same
+// document and it can be rendered and signed off-chain.
+// Struct fields are alphabetically ordered for cross-language determinism.
+type Statement struct {
+	ClosingBalance float64            `json:"closingBalance"`
+	Fees           float64            `json:"fees"`
+	OpeningBalance float64            `json:"openingBalance"`
+	Orders         []StatementOrder   `json:"orders"`
+	Payments       []StatementPayment `json:"payments"`
+	Penalties      float64            `json:"penalties"`
+	Period         string             `json:"period"`
+	PeriodEnd      int64              `json:"periodEnd"`
+	PeriodStart    int64              `json:"periodStart"`
+	Refunds        float64            `json:"refunds"`
+	Trades         []StatementTrade   `json:"trades"`
+	UserID         int64              `json:"userId"`
+}
+
+type StatementOrder struct {
+	Action         string  `json:"action"`
+	CreatedOn      int64   `json:"createdOn"`
+	FilledQuantity float64 `json:"filledQuantity"`
+	ID             int64   `json:"id"`
+	SlotID         string  `json:"slotId"`
+	Status         string  `json:"status"`
+	TotalQuantity  int64   `json:"totalQuantity"`
+	UnitCost       float64 `json:"unitCost"`
+}
+
+type StatementTrade struct {
+	BidMatchID     int64   `json:"bidMatchId"`
+	BidMatchTms    int64   `json:"bidMatchTms"`
+	DeliveredUnits float64 `json:"deliveredUnits"`
+	Side           string  `json:"side"`
+	SlotID         string  `json:"slotId"`
+	Status         string  `json:"status"`
+	UnitPrice      int64   `json:"unitPrice"`
+	Value          float64 `json:"value"`
+}
+
+// StatementPayment is a payment as it affects the user's balance: credits are
+// positive, debits negative.
+type StatementPayment struct {
+	Amount    float64 `json:"amount"`
+	CreatedOn int64   `json:"createdOn"`
+	Fee       float64 `json:"fee"`
+	ID        string  `json:"id"`
+	Penalty   float64 `json:"penalty"`
+	Refund    float64 `json:"refund"`
+	Type      string  `json:"type"`
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// statementPeriod turns "YYYYMM" into the [start, end) Unix range of that UTC month.
+func statementPeriod(period string) (int64, int64, error) {
+	start, err := time.Parse("200601", period)
+	if err != nil {
+		return 0, 0, errcode.New(errcode.InvalidArgument, "Period must be formatted YYYYMM: "+err.Error())
+	}
+	return start.Unix(), start.AddDate(0, 1, 0).Unix(), nil
+}
+
+// paymentDirection is +1 for payment types that credit the user's wallet and -1 for debits.
+func paymentDirection(paymentType PaymentType) float64 {
+	switch paymentType {
+	case SellerTokenAmount, BuyerEnergyPurchased:
+		return -1
+	}
+	return 1
+}
+
+func getPaymentDetail(stub shim.ChaincodeStubInterface, detailID int64) (PaymentDetail, error) {
+	var pd PaymentDetail
+	pdAsBytes, err := stub.GetState("PaymentDetail_" + strconv.FormatInt(detailID, 10))
+	if err != nil {
+		return pd, errors.New("Error accessing state: " + err.Error())
+	}
+	if pdAsBytes == nil {
+		return pd, nil
+	}
+	err = decodeAsset(pdAsBytes, &pd)
+	if err != nil {
+		return pd, errors.New("Failed to unmarshal payment detail: " + err.Error())
+	}
+	return pd, nil
+}
+
+// statementPayment returns how a payment affects a user, and false when it does not.
+// Besides their own payments, users are debited by dispute adjustments paid from them.
+func statementPayment(stub shim.ChaincodeStubInterface, payment Payment, userID int64) (StatementPayment, bool, error) {
+	if payment.UserID != userID && payment.PaymentType != DisputeAdjustment {
+		return StatementPayment{}, false, nil
+	}
+	pd, err := getPaymentDetail(stub, payment.PaymentDetailId)
+	if err != nil {
+		return StatementPayment{}, false, err
+	}
+
+	line := StatementPayment{
+		CreatedOn: payment.CreatedOn,
+		ID:        payment.ID,
+		Type:      PaymentTypeString(payment.PaymentType),
+	}
+	if payment.UserID != userID {
+		if pd.DebitedFrom != strconv.FormatInt(userID, 10) {
+			return StatementPayment{}, false, nil
+		}
+		line.Amount = -payment.TotalAmount
+		return line, true, nil
+	}
+
+	line.Amount = paymentDirection(payment.PaymentType) * payment.TotalAmount
+	line.Fee = pd.PlatformFee
+	line.Penalty = pd.PenaltyFromSeller
+	line.Refund = pd.BidRefundAmount + pd.PlatformFeeRefundAmount
+	if payment.PaymentType == DisputeAdjustment {
+		// Penalty refunds from a dispute are carried as a token refund.
+		line.Refund += pd.TokenAmountRefund
+	}
+	return line, true, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           Statement Read Methods                           */
+/* -------------------------------------------------------------------------- */
+
+// ============================================================================================================================
+// GenerateStatement() - a user's orders, trades and payments for one calendar month (UTC)
+//
+// Inputs - Array of strings
+//    0   ,    1
+//  userID, period
+//   "3"  , "202410"
+// ============================================================================================================================
+func GenerateStatement(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+	_, err = getUser(stub, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	periodStart, periodEnd, err := statementPeriod(args[1])
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	inPeriod := func(tms int64) bool { return tms >= periodStart && tms < periodEnd }
+
+	statement := Statement{
+		Orders:      []StatementOrder{},
+		Payments:    []StatementPayment{},
+		Period:      args[1],
+		PeriodEnd:   periodEnd,
+		PeriodStart: periodStart,
+		Trades:      []StatementTrade{},
+		UserID:      userID,
+	}
+
+	// Orders placed in the period.
+	startKey, endKey := prefixRange("Order_")
+	orderIterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query Orders: "+err.Error())
+	}
+	defer orderIterator.Close()
+	for orderIterator.HasNext() {
+		entry, err := orderIterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to query Orders: "+err.Error())
+		}
+		var order Order
+		err = decodeAsset(entry.Value, &order)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal order: "+err.Error())
+		}
+		if order.UserID != userID || !inPeriod(order.CreatedOn) {
+			continue
+		}
+		statement.Orders = append(statement.Orders, StatementOrder{
+			Action:         ActionString(order.UserAction),
+			CreatedOn:      order.CreatedOn,
+			FilledQuantity: order.FilledQuantity,
+			ID:             order.ID,
+			SlotID:         order.SlotID,
+			Status:         EnergyBidStatusString(order.BidStatus),
+			TotalQuantity:  order.TotalQuantity,
+			UnitCost:       order.UnitCost,
+		})
+	}
+
+	// Matches the user bought or sold in, by match time.
+	startKey, endKey = prefixRange("BidMatch_")
+	bidMatchIterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query BidMatches: "+err.Error())
+	}
+	defer bidMatchIterator.Close()
+	for bidMatchIterator.HasNext() {
+		entry, err := bidMatchIterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to query BidMatches: "+err.Error())
+		}
+		var bidMatch BidMatch
+		err = decodeAsset(entry.Value, &bidMatch)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal BidMatch: "+err.Error())
+		}
+		if !inPeriod(bidMatch.BidMatchTms) {
+			continue
+		}
+		trade := StatementTrade{
+			BidMatchID:     bidMatch.ID,
+			BidMatchTms:    bidMatch.BidMatchTms,
+			DeliveredUnits: bidMatch.DeliveredBidUnits,
+			SlotID:         bidMatch.BidSlot,
+			Status:         EnergyBidStatusString(bidMatch.BidStatus),
+			UnitPrice:      bidMatch.BidUnitPrice,
+			Value:          roundCents(bidMatch.DeliveredBidUnits * float64(bidMatch.BidUnitPrice)),
+		}
+		if bidMatch.BuyerUserId == userID {
+			trade.Side = ActionString(Buy)
+			statement.Trades = append(statement.Trades, trade)
+		}
+		if bidMatch.SellerUserId == userID {
+			trade.Side = ActionString(Sell)
+			statement.Trades = append(statement.Trades, trade)
+		}
+	}
+
+	// Payments before the period make up the opening balance, the rest are listed.
+	startKey, endKey = prefixRange("Payment_")
+	paymentIterator, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to query Payments: "+err.Error())
+	}
+	defer paymentIterator.Close()
+	for paymentIterator.HasNext() {
+		entry, err := paymentIterator.Next()
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to query Payments: "+err.Error())
+		}
+		var payment Payment
+		err = decodeAsset(entry.Value, &payment)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal payment: "+err.Error())
+		}
+		if payment.CreatedOn >= periodEnd {
+			continue
+		}
+		line, ok, err := statementPayment(stub, payment, userID)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		if !ok {
+			continue
+		}
+		if payment.CreatedOn < periodStart {
+			statement.OpeningBalance += line.Amount
+			continue
+		}
+		statement.Payments = append(statement.Payments, line)
+		statement.ClosingBalance += line.Amount
+		statement.Fees += line.Fee
+		statement.Penalties += line.Penalty
+		statement.Refunds += line.Refund
+	}
+
+	statement.OpeningBalance = roundCents(statement.OpeningBalance)
+	statement.ClosingBalance = roundCents(statement.OpeningBalance + statement.ClosingBalance)
+	statement.Fees = roundCents(statement.Fees)
+	statement.Penalties = roundCents(statement.Penalties)
+	statement.Refunds = roundCents(statement.Refunds)
+
+	// Entries are listed chronologically, ties broken by ID, so the document is stable across peers.
+	sort.SliceStable(statement.Orders, func(i, j int) bool {
+		a, b := statement.Orders[i], statement.Orders[j]
+		return a.CreatedOn < b.CreatedOn || (a.CreatedOn == b.CreatedOn && a.ID < b.ID)
+	})
+	sort.SliceStable(statement.Trades, func(i, j int) bool {
+		a, b := statement.Trades[i], statement.Trades[j]
+		return a.BidMatchTms < b.BidMatchTms || (a.BidMatchTms == b.BidMatchTms && a.BidMatchID < b.BidMatchID)
+	})
+	sort.SliceStable(statement.Payments, func(i, j int) bool {
+		a, b := statement.Payments[i], statement.Payments[j]
+		return a.CreatedOn < b.CreatedOn || (a.CreatedOn == b.CreatedOn && a.ID < b.ID)
+	})
+
+	statementAsBytes, _ := json.Marshal(statement)
+	return shim.Success(statementAsBytes)
+}
@@ -0,0 +1,102 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateStatement(t *testing.T) {
+	stub := shimtest.NewMockStub("testingStub", new(SimpleChaincode))
+	setCreator(t, stub, "Org1MSP", "admin")
+
+	for _, user := range [][]string{
+		{"3", "Consumer", "Location 3", "MeterId 3", "Battery"},
+		{"4", "Prosumer", "Location 4", "MeterId 4", "Solar"},
+	} {
+		registerUser(t, stub, user)
+	}
+	seedOrder(t, stub, Order{ID: 6, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Buy, UserID: 3})
+	seedOrder(t, stub, Order{ID: 7, BidStatus: BidCreated, RemainingQuantity: 1000, SlotID: "Slot1", TotalQuantity: 1000, UserAction: Sell, UserID: 4})
+	response := stub.MockInvoke("2", [][]byte{[]byte("ProposeFeeSchedule"), []byte(`{"percentFee":2}`)})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	response = stub.MockInvoke("3", [][]byte{[]byte("ApproveFeeSchedule"), []byte("1")})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// A wallet recharge last month makes up the opening balance.
+	now := time.Now().UTC()
+	period := now.Format("200601")
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Add(-time.Hour).Unix()
+	rechargeAsBytes, _ := json.Marshal(Payment{CreatedOn: lastMonth, ID: "P0", PaymentDetailId: 1, PaymentType: WalletRecharge, TotalAmount: 1000, UserID: 3})
+	stub.MockTransactionStart("seedPayment")
+	_ = stub.PutState("Payment_P0", rechargeAsBytes)
+	stub.MockTransactionEnd("seedPayment")
+
+	response = stub.MockInvoke("4", [][]byte{
+		[]byte("ProcessBidMatch"),
+		[]byte(strconv.FormatInt(now.Unix(), 10)), // bidMatchTms
+		[]byte("Slot1"),                           // bidSlot
+		[]byte("3"),                               // bidStatus
+		[]byte("5"),                               // bidUnitPrice
+		[]byte("3"),                               // buyerUserId
+		[]byte("100"),                             // deliveredBidUnits
+		[]byte("1"),                               // ID
+		[]byte("100"),                             // originalBidUnits
+		[]byte("4"),                               // sellerUserId
+		[]byte("6"),                               // transactionBuyID
+		[]byte("7"),                               // transactionSellID
+	})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+	response = stub.MockInvoke("5", [][]byte{[]byte("RecordPayment"), []byte("P1"), []byte("Buyer - Energy Purchased"),
+		[]byte("510"), []byte("3"), []byte("3"), []byte("platform"), []byte("500"), []byte("0"), []byte("0"),
+		[]byte("5"), []byte("0"), []byte("0")})
+	assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+	// Test Case 1: The buyer's statement balances payments against the opening balance
+	t.Run("Buyer Statement", func(t *testing.T) {
+		response := stub.MockInvoke("6", [][]byte{[]byte("GenerateStatement"), []byte("3"), []byte(period)})
+		assert.Equal(t, int32(shim.OK), response.GetStatus(), fmt.Sprintf("Unexpected error: %s", response.GetMessage()))
+
+		var statement Statement
+		err := json.Unmarshal(response.GetPayload(), &statement)
+		assert.NoError(t, err, "Error unmarshalling statement")
+		assert.Equal(t, 1000.0, statement.OpeningBalance, "OpeningBalance mismatch")
+		assert.Equal(t, 490.0, statement.ClosingBalance, "ClosingBalance mismatch")
+		assert.Equal(t, 10.0, statement.Fees, "Fees mismatch")
+		assert.Equal(t, 5.0, statement.Refunds, "Refunds mismatch")
+		assert.Len(t, statement.Payments, 1, "Unexpected number of payments")
+		assert.Equal(t, -510.0, statement.Payments[0].Amount, "Payment amount mismatch")
+		assert.Len(t, statement.Trades, 1, "Unexpected number of trades")
+		assert.Equal(t, "Buy", statement.Trades[0].Side, "Side mismatch")
+		assert.Equal(t, 500.0, statement.Trades[0].Value, "Trade value mismatch")
+
+		// The same period always renders the same document.
+		again := stub.MockInvoke("7", [][]byte{[]byte("GenerateStatement"), []byte("3"), []byte(period)})
+		assert.Equal(t, response.GetPayload(), again.GetPayload(), "Statement is not stable")
+	})
+
+	// Test Case 2: The seller sees the trade from the other side
+	t.Run("Seller Statement", func(t *testing.T) {
+		response := stub.MockInvoke("8", [][]byte{[]byte("GenerateStatement"), []byte("4"), []byte(period)})
+		var statement Statement
+		err := json.Unmarshal(response.GetPayload(), &statement)
+		assert.NoError(t, err, "Error unmarshalling statement")
+		assert.Equal(t, 0.0, statement.ClosingBalance, "ClosingBalance mismatch")
+		assert.Len(t, statement.Payments, 0, "Unexpected number of payments")
+		assert.Len(t, statement.Trades, 1, "Unexpected number of trades")
+		assert.Equal(t, "Sell", statement.Trades[0].Side, "Side mismatch")
+	})
+
+	// Test Case 3: Malformed periods are rejected
+	t.Run("Invalid Period", func(t *testing.T) {
+		response := stub.MockInvoke("9", [][]byte{[]byte("GenerateStatement"), []byte("3"), []byte("2024-10")})
+		assert.Equal(t, int32(shim.ERROR), response.GetStatus(), "Function unexpectedly succeeded")
+		assert.Contains(t, response.GetMessage(), "YYYYMM")
+	})
+}
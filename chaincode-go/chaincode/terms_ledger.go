@@ -0,0 +1,218 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+const platformTermsActiveVersionKey = "PlatformTermsActiveVersion"
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func getPlatformTerms(stub shim.ChaincodeStubInterface, version string) (PlatformTerms, error) {
+	var terms PlatformTerms
+	termsAsBytes, err := stub.GetState("PlatformTerms_" + version)
+	if err != nil {
+		return terms, errors.New("Error accessing state: " + err.Error())
+	}
+	if termsAsBytes == nil {
+		return terms, errcode.New(errcode.NotFound, "PlatformTerms version "+version+" not found.")
+	}
+	err = decodeAsset(termsAsBytes, &terms)
+	if err != nil {
+		return terms, errors.New("Failed to unmarshal platform terms: " + err.Error())
+	}
+	return terms, nil
+}
+
+// getActivePlatformTerms returns the terms in force, or false when none have been published.
+func getActivePlatformTerms(stub shim.ChaincodeStubInterface) (PlatformTerms, bool, error) {
+	versionAsBytes, err := stub.GetState(platformTermsActiveVersionKey)
+	if err != nil {
+		return PlatformTerms{}, false, errors.New("Error accessing state: " + err.Error())
+	}
+	if versionAsBytes == nil {
+		return PlatformTerms{}, false, nil
+	}
+	terms, err := getPlatformTerms(stub, string(versionAsBytes))
+	return terms, err == nil, err
+}
+
+// assertTermsAccepted checks that a user holds an unrevoked contract for the
+// active terms. Nothing is required until the first terms are published.
+func assertTermsAccepted(stub shim.ChaincodeStubInterface, userID int64) error {
+	terms, found, err := getActivePlatformTerms(stub)
+	if err != nil || !found {
+		return err
+	}
+
+	contractAsBytes, err := stub.GetState("PlatformContract_" + strconv.FormatInt(userID, 10))
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	if contractAsBytes == nil {
+		return errcode.New(errcode.StateViolation, "User "+strconv.FormatInt(userID, 10)+" has not signed the platform terms")
+	}
+	var contract PlatformContract
+	err = decodeAsset(contractAsBytes, &contract)
+	if err != nil {
+		return errors.New("Failed to unmarshal platform contract: " + err.Error())
+	}
+	if contract.Revoked {
+		return errcode.New(errcode.StateViolation, "User "+strconv.FormatInt(userID, 10)+" has revoked the platform terms")
+	}
+	if contract.AcceptedVersion != terms.Version {
+		return errcode.New(errcode.StateViolation, "User "+strconv.FormatInt(userID, 10)+" has not accepted platform terms version "+terms.Version)
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              Terms Write Methods                           */
+/* -------------------------------------------------------------------------- */
+
+// parsePlatformTermsRequest reads the positional arguments of PublishPlatformTerms.
+func parsePlatformTermsRequest(args []string) (PlatformTermsRequest, error) {
+	var request PlatformTermsRequest
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+	request.Version = args[0]
+	request.DocumentHash = args[1]
+	request.DocumentURI = args[2]
+	return request, validateRequest(&request)
+}
+
+func (request *PlatformTermsRequest) validate() []FieldError {
+	return nonEmpty(nil, map[string]string{
+		"documentHash": request.DocumentHash,
+		"documentUri":  request.DocumentURI,
+		"version":      request.Version,
+	})
+}
+
+// ============================================================================================================================
+// PublishPlatformTerms() - platform admin publishes a new terms version, which becomes active immediately
+//
+// Inputs - Array of strings
+//                                                0
+//                                             payload
+//  "{"version": "2.0", "documentHash": "9f86d0...", "documentUri": "https://example.org/terms-2.0.pdf"}"
+// The positional form (version, documentHash, documentURI) is still accepted.
+// ============================================================================================================================
+func PublishPlatformTerms(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request PlatformTermsRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 3 {
+		request, err = parsePlatformTermsRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 3.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	identity, err := callerIdentity(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	existingTermsAsBytes, err := stub.GetState("PlatformTerms_" + request.Version)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	if existingTermsAsBytes != nil {
+		return errorResponse(errcode.Conflict, "PlatformTerms version "+request.Version+" already exists.")
+	}
+
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	terms := PlatformTerms{
+		CreatedOn:    now,
+		DocumentHash: request.DocumentHash,
+		DocumentURI:  request.DocumentURI,
+		PublishedBy:  identity,
+		Version:      request.Version,
+	}
+
+	termsAsBytes, err := encodeAsset(&terms)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal platform terms: "+err.Error())
+	}
+	err = stub.PutState("PlatformTerms_"+terms.Version, termsAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store platform terms: "+err.Error())
+	}
+	err = stub.PutState(platformTermsActiveVersionKey, []byte(terms.Version))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store active platform terms version: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              Terms Read Methods                            */
+/* -------------------------------------------------------------------------- */
+
+func ReadPlatformTerms(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect 1 argument: the version, or "active" for the terms in force.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	var terms PlatformTerms
+	var err error
+	if args[0] == "active" {
+		var found bool
+		terms, found, err = getActivePlatformTerms(stub)
+		if err == nil && !found {
+			return errorResponse(errcode.NotFound, "No active PlatformTerms found.")
+		}
+	} else {
+		terms, err = getPlatformTerms(stub, args[0])
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	termsAsBytes, _ := json.Marshal(terms)
+	return shim.Success(termsAsBytes)
+}
@@ -0,0 +1,1408 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package chaincode
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	//	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+func parseUserCategory(categoryStr string) (UserCategory, error) {
+	if val, ok := userCategoryMap[categoryStr]; ok {
+		return val, nil
+	}
+	return 0, errcode.New(errcode.InvalidArgument, "unknown user category")
+}
+
+func parseEnergySource(sourceStr string) (EnergySource, error) {
+	if val, ok := energySourceMap[sourceStr]; ok {
+		return val, nil
+	}
+	return 0, errcode.New(errcode.InvalidArgument, "unknown energy source")
+}
+
+// parseUserRequest reads the positional arguments (userID, category, source) of UpdateUserProfile.
+func parseUserRequest(args []string) (UserRequest, error) {
+	var request UserRequest
+	var err error
+
+	request.ID, err = strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to convert user ID: "+err.Error())
+	}
+	request.Category, err = parseUserCategory(args[1])
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid user category: "+err.Error())
+	}
+	request.Source, err = parseEnergySource(args[2])
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid energy source: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *UserRequest) validate() []FieldError {
+	var fieldErrors []FieldError
+	if request.ID <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "id", Message: "must be positive"})
+	}
+	return fieldErrors
+}
+
+// parseEnergyBidStatus accepts a status name such as "BidCreated" or its legacy integer value.
+func parseEnergyBidStatus(statusStr string) (EnergyBidStatus, error) {
+	var status EnergyBidStatus
+	err := status.UnmarshalText([]byte(statusStr))
+	return status, err
+}
+
+// parseAction accepts "Buy" or "Sell", or their legacy integer values.
+func parseAction(actionStr string) (Action, error) {
+	var action Action
+	err := action.UnmarshalText([]byte(actionStr))
+	return action, err
+}
+
+// getUser loads a user profile stored by UpdateUserProfile.
+func getUser(stub shim.ChaincodeStubInterface, userID int64) (User, error) {
+	var user User
+	userAsBytes, err := stub.GetState(strconv.FormatInt(userID, 10))
+	if err != nil {
+		return user, errors.New("Error accessing state: " + err.Error())
+	}
+	if userAsBytes == nil {
+		return user, errcode.New(errcode.NotFound, "User with ID "+strconv.FormatInt(userID, 10)+" not found")
+	}
+	err = decodeAsset(userAsBytes, &user)
+	if err != nil {
+		return user, errors.New("Failed to unmarshal user: " + err.Error())
+	}
+	return user, nil
+}
+
+// assertUserCaller checks that the transaction was submitted by the identity bound to a user profile.
+func assertUserCaller(stub shim.ChaincodeStubInterface, userID int64) error {
+	user, err := getUser(stub, userID)
+	if err != nil {
+		return err
+	}
+	identity, err := callerIdentity(stub)
+	if err != nil {
+		return err
+	}
+	if user.Identity == "" || user.Identity != identity {
+		return errcode.New(errcode.Forbidden, "Caller "+identity+" is not the owner of User "+strconv.FormatInt(userID, 10))
+	}
+	return nil
+}
+
+func getOrder(stub shim.ChaincodeStubInterface, orderID string) (Order, error) {
+	var order Order
+	orderAsBytes, err := stub.GetState("Order_" + orderID)
+	if err != nil {
+		return order, errors.New("Error accessing state: " + err.Error())
+	}
+	if orderAsBytes == nil {
+		return order, errcode.New(errcode.NotFound, "Order with ID "+orderID+" not found.")
+	}
+	err = decodeAsset(orderAsBytes, &order)
+	if err != nil {
+		return order, errors.New("Failed to unmarshal order: " + err.Error())
+	}
+	return order, nil
+}
+
+func putOrder(stub shim.ChaincodeStubInterface, order Order) error {
+	orderAsBytes, err := encodeAsset(&order)
+	if err != nil {
+		return err
+	}
+	return stub.PutState("Order_"+strconv.FormatInt(order.ID, 10), orderAsBytes)
+}
+
+func getBidMatch(stub shim.ChaincodeStubInterface, bidMatchID string) (BidMatch, error) {
+	var bidMatch BidMatch
+	bidMatchAsBytes, err := stub.GetState("BidMatch_" + bidMatchID)
+	if err != nil {
+		return bidMatch, errors.New("Error accessing state: " + err.Error())
+	}
+	if bidMatchAsBytes == nil {
+		return bidMatch, errcode.New(errcode.NotFound, "BidMatch with ID "+bidMatchID+" not found.")
+	}
+	err = decodeAsset(bidMatchAsBytes, &bidMatch)
+	if err != nil {
+		return bidMatch, errors.New("Failed to unmarshal BidMatch: " + err.Error())
+	}
+	return bidMatch, nil
+}
+
+// putPayment stores a new Payment together with its PaymentDetail; neither is ever overwritten.
+func putPayment(stub shim.ChaincodeStubInterface, p Payment, pd PaymentDetail) error {
+	existingPaymentAsBytes, err := stub.GetState("Payment_" + p.ID)
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	if existingPaymentAsBytes != nil {
+		return errcode.New(errcode.Conflict, "Payment with ID "+p.ID+" already exists.")
+	}
+	detailKey := "PaymentDetail_" + strconv.FormatInt(pd.ID, 10)
+	existingDetailAsBytes, err := stub.GetState(detailKey)
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	if existingDetailAsBytes != nil {
+		return errcode.New(errcode.Conflict, "PaymentDetail with ID "+strconv.FormatInt(pd.ID, 10)+" already exists.")
+	}
+
+	pdAsBytes, err := encodeAsset(&pd)
+	if err != nil {
+		return errors.New("Failed to marshal payment detail: " + err.Error())
+	}
+	err = stub.PutState(detailKey, pdAsBytes)
+	if err != nil {
+		return errors.New("Could not store payment detail: " + err.Error())
+	}
+	p.PaymentDetailId = pd.ID
+	pAsBytes, err := encodeAsset(&p)
+	if err != nil {
+		return errors.New("Failed to marshal payment: " + err.Error())
+	}
+	err = stub.PutState("Payment_"+p.ID, pAsBytes)
+	if err != nil {
+		return errors.New("Could not store payment: " + err.Error())
+	}
+	return nil
+}
+
+// putBidMatchSlotIndex keeps the slot index of a BidMatch in step with its BidSlot. The
+// current entry is always written, so matches stored before the index existed are
+// indexed on their next update.
+func putBidMatchSlotIndex(stub shim.ChaincodeStubInterface, previous *BidMatch, bidMatch BidMatch) error {
+	bidMatchID := strconv.FormatInt(bidMatch.ID, 10)
+	if previous != nil && previous.BidSlot != bidMatch.BidSlot {
+		indexKey, err := stub.CreateCompositeKey(BidMatchSlotIndex, []string{previous.BidSlot, bidMatchID})
+		if err != nil {
+			return err
+		}
+		err = stub.DelState(indexKey)
+		if err != nil {
+			return err
+		}
+	}
+	indexKey, err := stub.CreateCompositeKey(BidMatchSlotIndex, []string{bidMatch.BidSlot, bidMatchID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(indexKey, []byte{0x00})
+}
+
+// isOrderOpen reports whether an order can still be amended, cancelled or filled.
+func isOrderOpen(status EnergyBidStatus) bool {
+	return status == BidCreated || status == BidAccepted || status == BidPartiallyFilled
+}
+
+// fillContribution is the quantity a BidMatch fills on each of its orders;
+// rejected or terminated matches release what they had filled.
+func fillContribution(bidMatch BidMatch) float64 {
+	if bidMatch.BidStatus == BidCreated || bidMatch.BidStatus == BidAccepted || bidMatch.BidStatus == BidExecuted {
+		return bidMatch.OriginalBidUnits
+	}
+	return 0
+}
+
+// applyOrderFill adds delta to an order's filled quantity and moves it between
+// PartiallyFilled and Filled. Orders that are not on the ledger are skipped.
+func applyOrderFill(stub shim.ChaincodeStubInterface, orderID int64, side Action, delta float64) error {
+	orderAsBytes, err := stub.GetState("Order_" + strconv.FormatInt(orderID, 10))
+	if err != nil {
+		return errors.New("Error accessing state: " + err.Error())
+	}
+	if orderAsBytes == nil {
+		return nil
+	}
+	var order Order
+	err = decodeAsset(orderAsBytes, &order)
+	if err != nil {
+		return errors.New("Failed to unmarshal order: " + err.Error())
+	}
+
+	if order.UserAction != side {
+		return errcode.New(errcode.InvalidArgument, "Order with ID "+strconv.FormatInt(orderID, 10)+" is not a "+ActionString(side)+" order")
+	}
+	if delta > 0 && !isOrderOpen(order.BidStatus) && order.BidStatus != BidFilled {
+		return errcode.New(errcode.StateViolation, "Order with ID "+strconv.FormatInt(orderID, 10)+" is "+EnergyBidStatusString(order.BidStatus)+" and cannot be filled")
+	}
+	if order.FilledQuantity+delta > float64(order.TotalQuantity) {
+		return errcode.New(errcode.StateViolation, "Order with ID "+strconv.FormatInt(orderID, 10)+" would be filled beyond its total quantity")
+	}
+
+	order.FilledQuantity += delta
+	if order.FilledQuantity < 0 {
+		order.FilledQuantity = 0
+	}
+	order.RemainingQuantity = float64(order.TotalQuantity) - order.FilledQuantity
+	if isOrderOpen(order.BidStatus) || order.BidStatus == BidFilled {
+		if order.RemainingQuantity <= 0 {
+			order.BidStatus = BidFilled
+		} else if order.FilledQuantity > 0 {
+			order.BidStatus = BidPartiallyFilled
+		} else if order.BidStatus == BidPartiallyFilled || order.BidStatus == BidFilled {
+			order.BidStatus = BidAccepted
+		}
+	}
+	order.UpdatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return err
+	}
+	return putOrder(stub, order)
+}
+
+// assertBidMatchOrders checks that a BidMatch pairs a buy order of its buyer with
+// a sell order of its seller, both placed in the match's slot.
+func assertBidMatchOrders(stub shim.ChaincodeStubInterface, bidMatch BidMatch) error {
+	sides := []struct {
+		orderID int64
+		side    Action
+		userID  int64
+	}{
+		{bidMatch.TransactionBuyID, Buy, bidMatch.BuyerUserId},
+		{bidMatch.TransactionSellID, Sell, bidMatch.SellerUserId},
+	}
+	for _, expected := range sides {
+		orderID := strconv.FormatInt(expected.orderID, 10)
+		order, err := getOrder(stub, orderID)
+		if err != nil {
+			return err
+		}
+		if order.UserAction != expected.side {
+			return errcode.New(errcode.InvalidArgument, "Order with ID "+orderID+" is not a "+ActionString(expected.side)+" order")
+		}
+		if order.UserID != expected.userID {
+			return errcode.New(errcode.InvalidArgument, "Order with ID "+orderID+" does not belong to User "+strconv.FormatInt(expected.userID, 10))
+		}
+		if order.SlotID != bidMatch.BidSlot {
+			return errcode.New(errcode.InvalidArgument, "Order with ID "+orderID+" is not in slot "+bidMatch.BidSlot)
+		}
+	}
+	return nil
+}
+
+// updateOrderFills moves a BidMatch's fill from the orders of its previous
+// version to the orders of its new version, and keeps the fill index in step.
+// Deltas are summed per order first because a transaction does not read its own writes.
+func updateOrderFills(stub shim.ChaincodeStubInterface, previous *BidMatch, bidMatch BidMatch) error {
+	type orderSide struct {
+		orderID int64
+		side    Action
+	}
+	deltas := map[orderSide]float64{}
+	sides := []orderSide{}
+	addDelta := func(orderID int64, side Action, delta float64) {
+		key := orderSide{orderID, side}
+		if _, ok := deltas[key]; !ok {
+			sides = append(sides, key)
+		}
+		deltas[key] += delta
+	}
+
+	if previous != nil && fillContribution(*previous) > 0 {
+		addDelta(previous.TransactionBuyID, Buy, -fillContribution(*previous))
+		addDelta(previous.TransactionSellID, Sell, -fillContribution(*previous))
+	}
+	if fillContribution(bidMatch) > 0 {
+		addDelta(bidMatch.TransactionBuyID, Buy, fillContribution(bidMatch))
+		addDelta(bidMatch.TransactionSellID, Sell, fillContribution(bidMatch))
+	}
+	for _, key := range sides {
+		if deltas[key] == 0 {
+			continue
+		}
+		err := applyOrderFill(stub, key.orderID, key.side, deltas[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	bidMatchID := strconv.FormatInt(bidMatch.ID, 10)
+	if previous != nil && fillContribution(*previous) > 0 {
+		for _, orderID := range []int64{previous.TransactionBuyID, previous.TransactionSellID} {
+			indexKey, err := stub.CreateCompositeKey(OrderFillIndex, []string{strconv.FormatInt(orderID, 10), bidMatchID})
+			if err != nil {
+				return err
+			}
+			err = stub.DelState(indexKey)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if fillContribution(bidMatch) > 0 {
+		for _, orderID := range []int64{bidMatch.TransactionBuyID, bidMatch.TransactionSellID} {
+			indexKey, err := stub.CreateCompositeKey(OrderFillIndex, []string{strconv.FormatInt(orderID, 10), bidMatchID})
+			if err != nil {
+				return err
+			}
+			err = stub.PutState(indexKey, []byte{0x00})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                             User Write Methods                             */
+/* -------------------------------------------------------------------------- */
+
+// ============================================================================================================================
+// UpdateUserProfile() - create or update a user; Location and MeterId go to the caller org's private collection
+//
+// Inputs - Array of strings
+//                             0
+//                          payload
+//  "{"id": 12345, "category": "Prosumer", "source": "Solar"}"
+// with transient "user_pii" = {"location": "...", "meterId": "...", "salt": "<secret, at least 16 characters>"},
+// optional when updating. The positional form (userID, category, source) is still accepted; Location and MeterId
+// are never taken from the arguments, which are public on the channel. A new profile is bound to the caller's
+// identity; afterwards only that identity can update it, or the platform admin while it has none.
+// ============================================================================================================================
+func UpdateUserProfile(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if !isJSONPayload(args) && len(args) != 3 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 3, with Location and MeterId in transient "+userPIITransientKey)
+	}
+
+	pii, hasPII, err := userPIIFromTransient(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	var request UserRequest
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+	} else {
+		err = sanitize_arguments(args)
+		if err != nil {
+			return errorResponse(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+		}
+		request, err = parseUserRequest(args)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+	}
+
+	// The caller's org owns the collection the PII is written to.
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to read caller MSP: "+err.Error())
+	}
+
+	userID := strconv.FormatInt(request.ID, 10)
+	existingUserAsBytes, err := stub.GetState(userID)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	now, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	var user User
+	if existingUserAsBytes == nil {
+		// New user creation
+		if !hasPII {
+			return errorResponse(errcode.InvalidArgument, "Location and MeterId are required for a new user")
+		}
+		user.CreatedOn = now
+		user.UpdatedOn = user.CreatedOn
+		user.OrgMSP = mspID
+		// Bind the profile to the identity that created it.
+		user.Identity, err = callerIdentity(stub)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+	} else {
+		// Existing user update
+		err = decodeAsset(existingUserAsBytes, &user)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal user: "+err.Error())
+		}
+		if user.OrgMSP == "" {
+			// Profiles written before PII moved off-chain are adopted by the platform admin's org,
+			// which must resubmit their Location and MeterId with a salt.
+			err = assertPlatformAdmin(stub)
+			if err != nil {
+				return errorResponseFrom(err)
+			}
+			if !hasPII && user.Location != "" {
+				return errorResponse(errcode.InvalidArgument, "Transient "+userPIITransientKey+" is required to move the Location and MeterId of User "+userID+" off-chain")
+			}
+			user.OrgMSP = mspID
+		}
+		if user.OrgMSP != mspID {
+			return errorResponse(errcode.Forbidden, "Caller from "+mspID+" cannot update User "+userID+" owned by "+user.OrgMSP)
+		}
+		// Category and Source drive fees, certificates and emissions, so only the bound owner changes
+		// them; profiles without an identity are left to the platform admin until one is bound.
+		if user.Identity != "" {
+			err = assertUserCaller(stub, user.ID)
+		} else {
+			err = assertPlatformAdmin(stub)
+		}
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		user.UpdatedOn = now
+	}
+
+	user.ID = request.ID
+	user.Category = request.Category
+	user.Source = request.Source
+
+	if hasPII {
+		err = putUserPII(stub, &user, pii)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+	}
+
+	// Store the user in ledger
+	userAsBytes, err := encodeAsset(&user)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal user: "+err.Error())
+	}
+	err = stub.PutState(strconv.Itoa(int(user.ID)), userAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store user: "+err.Error())
+	}
+
+	if existingUserAsBytes == nil {
+		newTxLogger(stub).Info("created user", logFields{"userId": user.ID})
+	} else {
+		newTxLogger(stub).Info("updated user", logFields{"userId": user.ID})
+	}
+	return shim.Success(nil)
+}
+
+// ============================================================================================================================
+// BindUserIdentity() - platform admin binds a client identity to a user created before profiles carried one
+//
+// Without an identity the user cannot sign the platform terms, place orders or move certificates.
+// Users that are already bound keep their identity.
+//
+// Inputs - Array of strings
+//    0    ,        1
+//  userID ,     identity
+//   "3"   , "Org2MSP:user3"
+// ============================================================================================================================
+func BindUserIdentity(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 2 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 2.")
+	}
+
+	err := sanitize_arguments(args)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Invalid argument: "+err.Error())
+	}
+
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+	if !strings.Contains(args[1], ":") {
+		return errorResponse(errcode.InvalidArgument, "Identity must be given as <MSP ID>:<certificate common name>")
+	}
+	user, err := getUser(stub, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if user.Identity != "" {
+		return errorResponse(errcode.Conflict, "User "+args[0]+" is already bound to "+user.Identity)
+	}
+
+	user.Identity = args[1]
+	user.UpdatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	userAsBytes, err := encodeAsset(&user)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal user: "+err.Error())
+	}
+	err = stub.PutState(strconv.FormatInt(user.ID, 10), userAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store user: "+err.Error())
+	}
+
+	newTxLogger(stub).Info("bound user identity", logFields{"userId": user.ID})
+	return shim.Success(nil)
+}
+
+func SignPlatformContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We expect the user ID, optionally followed by the terms version being accepted.
+	if len(args) != 1 && len(args) != 2 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (UserID) or 2 (UserID, TermsVersion)")
+	}
+
+	// Check if user exists.
+	userID := args[0]
+	existingUserAsBytes, err := stub.GetState(userID)
+	if err != nil || existingUserAsBytes == nil {
+		return errorResponse(errcode.NotFound, "User with ID "+userID+" not found")
+	}
+
+	// Creating a new platform contract for the user.
+	var contract PlatformContract
+	contract.UserID, err = strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to convert user ID: "+err.Error())
+	}
+
+	// Only the user's own identity can sign, and only the active terms.
+	err = assertUserCaller(stub, contract.UserID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	terms, found, err := getActivePlatformTerms(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if !found {
+		return errorResponse(errcode.StateViolation, "No platform terms have been published")
+	}
+	if len(args) == 2 && args[1] != terms.Version {
+		return errorResponse(errcode.StateViolation, "Terms version "+args[1]+" is not the active version "+terms.Version)
+	}
+	contract.AcceptedVersion = terms.Version
+	contract.DocumentHash = terms.DocumentHash
+	contract.SignedBy, _ = callerIdentity(stub)
+	contract.CreatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	contract.UpdatedOn = contract.CreatedOn
+
+	// Store the contract in the ledger using a composite key for uniqueness.
+	// This will use "PlatformContract" as a prefix followed by the user ID.
+	contractKey := "PlatformContract_" + strconv.FormatInt(contract.UserID, 10)
+
+	contractAsBytes, err := encodeAsset(&contract)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal platform contract: "+err.Error())
+	}
+	err = stub.PutState(contractKey, contractAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store platform contract: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+func RevokePlatformContract(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	// We are assuming that the only argument is the user ID.
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (UserID)")
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return errorResponse(errcode.InvalidArgument, "Failed to parse User ID: "+err.Error())
+	}
+	err = assertUserCaller(stub, userID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	contractKey := "PlatformContract_" + strconv.FormatInt(userID, 10)
+	contractAsBytes, err := stub.GetState(contractKey)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+	if contractAsBytes == nil {
+		return errorResponse(errcode.NotFound, "Platform Contract for User with ID "+args[0]+" does not exist.")
+	}
+	var contract PlatformContract
+	err = decodeAsset(contractAsBytes, &contract)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to unmarshal platform contract: "+err.Error())
+	}
+	if contract.Revoked {
+		return errorResponse(errcode.StateViolation, "Platform Contract for User with ID "+args[0]+" is already revoked.")
+	}
+
+	contract.Revoked = true
+	contract.RevokedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	contract.UpdatedOn = contract.RevokedOn
+	contractAsBytes, err = encodeAsset(&contract)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal platform contract: "+err.Error())
+	}
+	err = stub.PutState(contractKey, contractAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store platform contract: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                              Payment Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// parsePaymentRequest reads the positional arguments of RecordPayment. Empty
+// amounts are taken as zero; anything else must parse.
+func parsePaymentRequest(args []string) (PaymentRequest, error) {
+	var request PaymentRequest
+	var err error
+
+	request.ID = args[0]
+	paymentType, ok := paymentTypeMap[args[1]]
+	if !ok {
+		return request, errcode.New(errcode.InvalidArgument, "Invalid payment type provided.")
+	}
+	request.PaymentType = paymentType
+	request.TotalAmount, err = strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse total amount: "+err.Error())
+	}
+	request.UserID, err = strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse user ID: "+err.Error())
+	}
+	request.DebitedFrom = args[4]
+	request.CreditedTo = args[5]
+	request.TotalUnitCost, err = parseOptionalFloat(args[6], "total unit cost")
+	if err != nil {
+		return request, err
+	}
+	// args[7] used to carry the platform fee; it is now computed from the active FeeSchedule.
+	request.TokenAmount, err = parseOptionalFloat(args[8], "token amount")
+	if err != nil {
+		return request, err
+	}
+	request.BidRefundAmount, err = parseOptionalFloat(args[9], "bid refund amount")
+	if err != nil {
+		return request, err
+	}
+	request.PlatformFeeRefundAmount, err = parseOptionalFloat(args[10], "platform fee refund amount")
+	if err != nil {
+		return request, err
+	}
+	request.PenaltyFromSeller, err = parseOptionalFloat(args[11], "penalty from seller")
+	if err != nil {
+		return request, err
+	}
+	if len(args) == 13 {
+		request.BidMatchID, err = strconv.ParseInt(args[12], 10, 64)
+		if err != nil {
+			return request, errcode.New(errcode.InvalidArgument, "Failed to parse BidMatch ID: "+err.Error())
+		}
+	}
+	return request, validateRequest(&request)
+}
+
+// Payment ID prefixes written only by SettleSlot and dispute resolution.
+var reservedPaymentIDPrefixes = []string{"Settlement_", "Dispute_"}
+
+func (request *PaymentRequest) validate() []FieldError {
+	var fieldErrors []FieldError
+	if request.ID == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "id", Message: "must not be empty"})
+	}
+	for _, prefix := range reservedPaymentIDPrefixes {
+		if strings.HasPrefix(request.ID, prefix) {
+			fieldErrors = append(fieldErrors, FieldError{Field: "id", Message: "must not start with " + prefix})
+		}
+	}
+	return nonNegative(fieldErrors, map[string]float64{
+		"bidRefundAmount":         request.BidRefundAmount,
+		"penaltyFromSeller":       request.PenaltyFromSeller,
+		"platformFeeRefundAmount": request.PlatformFeeRefundAmount,
+		"tokenAmount":             request.TokenAmount,
+		"totalAmount":             request.TotalAmount,
+		"totalUnitCost":           request.TotalUnitCost,
+	})
+}
+
+// ============================================================================================================================
+// RecordPayment() - record a Payment and its PaymentDetail, with the platform fee of the active FeeSchedule
+//
+// Payments with a totalUnitCost are refused with STATE_VIOLATION until a FeeSchedule has been proposed and
+// approved; the fee is no longer taken from the caller. An existing payment ID is refused with CONFLICT, and
+// IDs starting with Settlement_ or Dispute_ are reserved for SettleSlot and dispute resolution.
+//
+// Inputs - Array of strings
+//                                                                   0
+//                                                                payload
+//  "{"id": "P1", "paymentType": "Buyer - Energy Purchased", "totalAmount": 510, "userId": 3, "debitedFrom": "3", "creditedTo": "platform", "totalUnitCost": 500}"
+// The positional form (paymentID, paymentType, totalAmount, userID, debitedFrom, creditedTo, totalUnitCost, -, tokenAmount,
+// bidRefundAmount, platformFeeRefundAmount, penaltyFromSeller[, bidMatchID]) is still accepted.
+// ============================================================================================================================
+func RecordPayment(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request PaymentRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 12 || len(args) == 13 {
+		request, err = parsePaymentRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload), 12 or 13.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	paymentID := request.ID
+	userID := request.UserID
+	totalUnitCost := request.TotalUnitCost
+
+	// A BidMatch under dispute cannot be paid until the dispute is resolved.
+	if request.BidMatchID != 0 {
+		err = assertNotDisputed(stub, request.BidMatchID)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+	}
+
+	// Payments for energy carry the platform fee of the schedule in force.
+	var platformFee float64
+	var feeScheduleVersion int64
+	if totalUnitCost > 0 {
+		schedule, found, err := getActiveFeeSchedule(stub)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to read fee schedule: "+err.Error())
+		}
+		if !found {
+			return errorResponse(errcode.StateViolation, "No active fee schedule to compute the platform fee.")
+		}
+		platformFee, err = computePlatformFee(stub, schedule, userID, totalUnitCost)
+		if err != nil {
+			return wrapErrorResponse("Failed to compute platform fee: ", err)
+		}
+		feeScheduleVersion = schedule.Version
+	}
+
+	createdOn, err := txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	pd := PaymentDetail{
+		ID:                      txSequenceID(stub, 0),
+		DebitedFrom:             request.DebitedFrom,
+		CreditedTo:              request.CreditedTo,
+		TotalUnitCost:           totalUnitCost,
+		PlatformFee:             platformFee,
+		TokenAmount:             request.TokenAmount,
+		BidRefundAmount:         request.BidRefundAmount,
+		PlatformFeeRefundAmount: request.PlatformFeeRefundAmount,
+		TokenAmountRefund:       request.PenaltyFromSeller,
+		PenaltyFromSeller:       request.PenaltyFromSeller,
+		FeeScheduleVersion:      feeScheduleVersion,
+	}
+	p := Payment{
+		BidMatchID:  request.BidMatchID,
+		CreatedOn:   createdOn,
+		ID:          paymentID,
+		PaymentType: request.PaymentType,
+		TotalAmount: request.TotalAmount,
+		UserID:      userID,
+	}
+	// A payment is recorded once; it is never overwritten.
+	err = putPayment(stub, p, pd)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	return shim.Success(nil)
+}
+
+/* -------------------------------------------------------------------------- */
+/*                            Energy Bid  Methods                             */
+/* -------------------------------------------------------------------------- */
+
+// parseOrderRequest reads the positional arguments of RegisterOrder.
+func parseOrderRequest(args []string) (OrderRequest, error) {
+	var request OrderRequest
+	var err error
+
+	request.ID, err = strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse order ID: "+err.Error())
+	}
+	request.BidMatchID, err = strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse BidMatchID: "+err.Error())
+	}
+	request.BidStatus, err = parseEnergyBidStatus(args[1])
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse BidStatus: "+err.Error())
+	}
+
+	// args[3] used to carry the market price; it now comes from the oracle.
+
+	// args[4] used to carry the order cost; it is now unitCost times totalQuantity.
+	_, err = strconv.ParseFloat(args[4], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse OrderCost: "+err.Error())
+	}
+	request.PaymentID, err = strconv.ParseInt(args[5], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse PaymentID: "+err.Error())
+	}
+	request.SlotID = args[6]
+	request.TotalQuantity, err = strconv.ParseInt(args[7], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse TotalQuantity: "+err.Error())
+	}
+	request.UnitCost, err = strconv.ParseFloat(args[8], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse UnitCost: "+err.Error())
+	}
+	request.UserID, err = strconv.ParseInt(args[9], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse UserID: "+err.Error())
+	}
+
+	// args[10] used to carry the SlotExecDate; it is now the slot's start time.
+	_, err = strconv.ParseInt(args[10], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse SlotExecDate: "+err.Error())
+	}
+
+	request.UserAction, err = parseAction(args[11])
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse action: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *OrderRequest) validate() []FieldError {
+	var fieldErrors []FieldError
+	if request.ID <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "id", Message: "must be positive"})
+	}
+	// The cost is derived from the quantity and unit cost; a supplied one may only restate it.
+	orderCost := request.UnitCost * float64(request.TotalQuantity)
+	if request.OrderCost != 0 && request.OrderCost != orderCost {
+		fieldErrors = append(fieldErrors, FieldError{Field: "orderCost", Message: "must equal totalQuantity times unitCost (" + strconv.FormatFloat(orderCost, 'f', -1, 64) + "), got " + strconv.FormatFloat(request.OrderCost, 'f', -1, 64)})
+	}
+	return nonNegative(fieldErrors, map[string]float64{
+		"orderCost":     request.OrderCost,
+		"totalQuantity": float64(request.TotalQuantity),
+		"unitCost":      request.UnitCost,
+	})
+}
+
+// prepareOrder validates a new order request against the ledger and returns
+// the Order to store, without writing it. Existing orders are changed only
+// through AmendOrder and CancelOrder, which check their owner and status.
+func prepareOrder(stub shim.ChaincodeStubInterface, request OrderRequest, aggregator bool) (Order, error) {
+	var order Order
+
+	// Check if order with given ID already exists.
+	existingOrderAsBytes, err := stub.GetState("Order_" + strconv.FormatInt(request.ID, 10))
+	if err != nil {
+		return order, errors.New("Error accessing state: " + err.Error())
+	}
+	if existingOrderAsBytes != nil {
+		return order, errcode.New(errcode.Conflict, "Order with ID "+strconv.FormatInt(request.ID, 10)+" already exists; use AmendOrder or CancelOrder to change it")
+	}
+
+	// The ID may also be held by a sealed bid.
+	_, sealed, err := getSealedOrder(stub, strconv.FormatInt(request.ID, 10))
+	if err != nil {
+		return order, err
+	}
+	if sealed {
+		return order, errcode.New(errcode.StateViolation, "Order with ID "+strconv.FormatInt(request.ID, 10)+" is sealed until revealed")
+	}
+	order.ID = request.ID
+
+	// BidStatus check
+	if request.BidStatus != BidCreated && request.BidStatus != BidAccepted {
+		return order, errcode.New(errcode.InvalidArgument, "Invalid BidStatus provided for new Order. It should be BidCreated or BidAccepted.")
+	}
+
+	// Users place their own orders, or an aggregator places them, and trade only under the
+	// platform terms currently in force.
+	if aggregator {
+		_, err = getUser(stub, request.UserID)
+	} else {
+		err = assertUserCaller(stub, request.UserID)
+	}
+	if err != nil {
+		return order, err
+	}
+	err = assertTermsAccepted(stub, request.UserID)
+	if err != nil {
+		return order, err
+	}
+
+	// Orders must target a known slot that is still before gate closure; the
+	// slot's start time is the execution date.
+	slot, err := getOpenTradingSlot(stub, request.SlotID)
+	if err != nil {
+		return order, err
+	}
+
+	// The market price of the slot is taken from the oracle, not the caller.
+	marketPrice, err := getMarketPrice(stub, request.SlotID)
+	if err != nil {
+		return order, err
+	}
+	err = checkPriceBand(stub, marketPrice, request.UnitCost)
+	if err != nil {
+		return order, err
+	}
+	onMarketPrice := strconv.FormatFloat(marketPrice.Price, 'f', -1, 64)
+
+	// Assign parsed values to the order struct
+	order.BidMatchID = request.BidMatchID
+	order.BidStatus = request.BidStatus
+	order.OnMarketPrice = onMarketPrice
+	order.OrderCost = request.UnitCost * float64(request.TotalQuantity)
+	order.PaymentID = request.PaymentID
+	order.SlotID = request.SlotID
+	order.TotalQuantity = request.TotalQuantity
+	order.RemainingQuantity = float64(request.TotalQuantity)
+	order.UnitCost = request.UnitCost
+	order.CreatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return order, err
+	}
+	order.UpdatedOn = order.CreatedOn
+	order.UserID = request.UserID
+	order.SlotExecDate = slot.StartTime
+	order.UserAction = request.UserAction
+	return order, nil
+}
+
+// ============================================================================================================================
+// RegisterOrder() - a user creates an order against an open trading slot; use AmendOrder or CancelOrder to change it
+//
+// Inputs - Array of strings
+//                                                            0
+//                                                         payload
+//  "{"id": 4, "slotId": "slot1", "action": "Buy", "bidStatus": "BidCreated", "totalQuantity": 300, "unitCost": 3.5, "userId": 6}"
+// The positional form (bidMatchID, bidStatus, orderID, -, -, paymentID, slotID, totalQuantity, unitCost, userID,
+// -, action) is still accepted. The order cost is always totalQuantity times unitCost; a payload's orderCost may only restate it.
+// ============================================================================================================================
+func RegisterOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request OrderRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 12 {
+		request, err = parseOrderRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 12.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	order, err := prepareOrder(stub, request, false)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	// Store the order in the ledger.
+	orderAsBytes, err := encodeAsset(&order)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal order: "+err.Error())
+	}
+	err = stub.PutState("Order_"+strconv.FormatInt(order.ID, 10), orderAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store order: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// ============================================================================================================================
+// CancelOrder() - the owning user withdraws an order before its slot's gate closure
+//
+// Inputs - Array of strings
+//     0
+//  orderID
+// ============================================================================================================================
+func CancelOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 1 {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1.")
+	}
+
+	order, err := getOrder(stub, args[0])
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = assertUserCaller(stub, order.UserID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if !isOrderOpen(order.BidStatus) {
+		return errorResponse(errcode.StateViolation, "Order with ID "+args[0]+" is "+EnergyBidStatusString(order.BidStatus)+" and cannot be cancelled")
+	}
+	_, err = getOpenTradingSlot(stub, order.SlotID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	order.BidStatus = BidCancelled
+	order.UpdatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = putOrder(stub, order)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store order: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// parseAmendOrderRequest reads the positional arguments of AmendOrder.
+func parseAmendOrderRequest(args []string) (AmendOrderRequest, error) {
+	var request AmendOrderRequest
+	var err error
+
+	request.ID, err = strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse order ID: "+err.Error())
+	}
+	request.UnitCost, err = strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse UnitCost: "+err.Error())
+	}
+	request.TotalQuantity, err = strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse TotalQuantity: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *AmendOrderRequest) validate() []FieldError {
+	var fieldErrors []FieldError
+	if request.ID <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "id", Message: "must be positive"})
+	}
+	if request.TotalQuantity <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "totalQuantity", Message: "must be positive"})
+	}
+	if request.UnitCost <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "unitCost", Message: "must be positive"})
+	}
+	return fieldErrors
+}
+
+// ============================================================================================================================
+// AmendOrder() - the owning user changes price and quantity before its slot's gate closure
+//
+// Inputs - Array of strings
+//                                 0
+//                              payload
+//  "{"id": 4, "unitCost": 3.6, "totalQuantity": 250}"
+// The positional form (orderID, unitCost, totalQuantity) is still accepted.
+// ============================================================================================================================
+func AmendOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request AmendOrderRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 3 {
+		request, err = parseAmendOrderRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 3.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	orderID := strconv.FormatInt(request.ID, 10)
+	order, err := getOrder(stub, orderID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	unitCost := request.UnitCost
+	totalQuantity := request.TotalQuantity
+
+	err = assertUserCaller(stub, order.UserID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	if !isOrderOpen(order.BidStatus) {
+		return errorResponse(errcode.StateViolation, "Order with ID "+orderID+" is "+EnergyBidStatusString(order.BidStatus)+" and cannot be amended")
+	}
+	if float64(totalQuantity) < order.FilledQuantity {
+		return errorResponse(errcode.StateViolation, "TotalQuantity cannot be less than the quantity already filled.")
+	}
+	_, err = getOpenTradingSlot(stub, order.SlotID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	marketPrice, err := getMarketPrice(stub, order.SlotID)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = checkPriceBand(stub, marketPrice, unitCost)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	order.UnitCost = unitCost
+	order.TotalQuantity = totalQuantity
+	order.RemainingQuantity = float64(totalQuantity) - order.FilledQuantity
+	order.OrderCost = unitCost * float64(totalQuantity)
+	order.Revision++
+	order.UpdatedOn, err = txTimestamp(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = putOrder(stub, order)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store order: "+err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// parseBidMatchRequest reads the positional arguments of ProcessBidMatch.
+func parseBidMatchRequest(args []string) (BidMatchRequest, error) {
+	var request BidMatchRequest
+	var err error
+
+	request.ID, err = strconv.ParseInt(args[6], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse BidMatch ID: "+err.Error())
+	}
+	request.BidMatchTms, err = strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse BidMatchTms: "+err.Error())
+	}
+	request.BidSlot = args[1]
+	request.BidStatus, err = parseEnergyBidStatus(args[2])
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse BidStatus: "+err.Error())
+	}
+	request.BidUnitPrice, err = strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse BidUnitPrice: "+err.Error())
+	}
+	request.BuyerUserId, err = strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse BuyerUserId: "+err.Error())
+	}
+	request.DeliveredBidUnits, err = strconv.ParseFloat(args[5], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse DeliveredBidUnits: "+err.Error())
+	}
+	request.OriginalBidUnits, err = strconv.ParseFloat(args[7], 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse OriginalBidUnits: "+err.Error())
+	}
+	request.SellerUserId, err = strconv.ParseInt(args[8], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse SellerUserId: "+err.Error())
+	}
+	request.TransactionBuyID, err = strconv.ParseInt(args[9], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse TransactionBuyID: "+err.Error())
+	}
+	request.TransactionSellID, err = strconv.ParseInt(args[10], 10, 64)
+	if err != nil {
+		return request, errcode.New(errcode.InvalidArgument, "Failed to parse TransactionSellID: "+err.Error())
+	}
+	return request, validateRequest(&request)
+}
+
+func (request *BidMatchRequest) validate() []FieldError {
+	var fieldErrors []FieldError
+	if request.ID <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "id", Message: "must be positive"})
+	}
+	return nonNegative(fieldErrors, map[string]float64{
+		"bidUnitPrice":      float64(request.BidUnitPrice),
+		"deliveredBidUnits": request.DeliveredBidUnits,
+		"originalBidUnits":  request.OriginalBidUnits,
+	})
+}
+
+// ============================================================================================================================
+// ProcessBidMatch() - create or update a BidMatch and move its units onto the matched orders; platform admin only
+//
+// Inputs - Array of strings
+//                                                                   0
+//                                                                payload
+//  "{"id": 1, "bidSlot": "slot1", "bidStatus": "BidExecuted", "bidUnitPrice": 5, "buyerUserId": 3, "sellerUserId": 4, "originalBidUnits": 100, "deliveredBidUnits": 100}"
+// The positional form (bidMatchTms, bidSlot, bidStatus, bidUnitPrice, buyerUserId, deliveredBidUnits, bidMatchID,
+// originalBidUnits, sellerUserId, transactionBuyID, transactionSellID) is still accepted.
+// ============================================================================================================================
+func ProcessBidMatch(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var request BidMatchRequest
+	var err error
+	if isJSONPayload(args) {
+		err = decodePayload(args[0], &request)
+	} else if len(args) == 11 {
+		request, err = parseBidMatchRequest(args)
+	} else {
+		return errorResponse(errcode.InvalidArgument, "Incorrect number of arguments. Expecting 1 (JSON payload) or 11.")
+	}
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	// Matches fill anyone's orders, so only the matching operator records them.
+	err = assertPlatformAdmin(stub)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	bidMatchID := request.ID
+
+	// Check if BidMatch with the given ID already exists.
+	existingBidMatchAsBytes, err := stub.GetState("BidMatch_" + strconv.FormatInt(bidMatchID, 10))
+	if err != nil {
+		return errorResponse(errcode.Internal, "Error accessing state: "+err.Error())
+	}
+
+	var bidMatch BidMatch
+	var previous *BidMatch
+	if existingBidMatchAsBytes != nil {
+		// BidMatch exists, so we will update it.
+		err = decodeAsset(existingBidMatchAsBytes, &bidMatch)
+		if err != nil {
+			return errorResponse(errcode.Internal, "Failed to unmarshal existing BidMatch: "+err.Error())
+		}
+		// A disputed match stays as it is until the dispute is resolved.
+		err = assertNotDisputed(stub, bidMatchID)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		previousBidMatch := bidMatch
+		previous = &previousBidMatch
+	} else {
+		// BidMatch doesn't exist, so we will create a new one.
+		bidMatch.BidMatchTms, err = txTimestamp(stub)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		bidMatch.ID = bidMatchID
+	}
+
+	// Assign parsed values to bidMatch
+	if request.BidMatchTms != 0 {
+		bidMatch.BidMatchTms = request.BidMatchTms
+	}
+	bidMatch.BidSlot = request.BidSlot
+	bidMatch.BidStatus = request.BidStatus
+	bidMatch.BidUnitPrice = request.BidUnitPrice
+	bidMatch.BuyerUserId = request.BuyerUserId
+	bidMatch.DeliveredBidUnits = request.DeliveredBidUnits
+	bidMatch.OriginalBidUnits = request.OriginalBidUnits
+	bidMatch.SellerUserId = request.SellerUserId
+	bidMatch.TransactionBuyID = request.TransactionBuyID
+	bidMatch.TransactionSellID = request.TransactionSellID
+
+	// The energy keeps the source its seller had when the match was recorded.
+	err = stampEmissionSource(stub, previous, &bidMatch)
+	if err != nil {
+		return wrapErrorResponse("Could not read the seller's energy source: ", err)
+	}
+
+	// Executed matches carry the emissions of the energy actually delivered.
+	if bidMatch.BidStatus == BidExecuted {
+		err = computeBidMatchEmissions(stub, &bidMatch)
+		if err != nil {
+			return wrapErrorResponse("Could not compute emissions: ", err)
+		}
+	}
+
+	// Settled slots are final, for the slot the match leaves as well as the one it joins.
+	if previous != nil {
+		err = assertSlotNotSettled(stub, previous.BidSlot)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+		err = assertBidMatchNotSettled(stub, *previous)
+		if err != nil {
+			return errorResponseFrom(err)
+		}
+	}
+	err = assertSlotNotSettled(stub, bidMatch.BidSlot)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+
+	// Move the matched units onto the buy and sell orders.
+	err = assertBidMatchOrders(stub, bidMatch)
+	if err != nil {
+		return errorResponseFrom(err)
+	}
+	err = updateOrderFills(stub, previous, bidMatch)
+	if err != nil {
+		return wrapErrorResponse("Could not fill orders: ", err)
+	}
+
+	// Store the bidMatch back in the ledger.
+	bidMatchAsBytes, err := encodeAsset(&bidMatch)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Failed to marshal BidMatch: "+err.Error())
+	}
+	err = stub.PutState("BidMatch_"+strconv.FormatInt(bidMatch.ID, 10), bidMatchAsBytes)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not store BidMatch: "+err.Error())
+	}
+	err = putBidMatchSlotIndex(stub, previous, bidMatch)
+	if err != nil {
+		return errorResponse(errcode.Internal, "Could not index BidMatch: "+err.Error())
+	}
+
+	// Certificates follow the units delivered; issueRECs credits only what is new.
+	err = issueRECs(stub, bidMatch)
+	if err != nil {
+		return wrapErrorResponse("Could not issue renewable energy certificates: ", err)
+	}
+	err = recordMonthlyVolume(stub, bidMatch)
+	if err != nil {
+		return wrapErrorResponse("Could not record monthly volume: ", err)
+	}
+
+	return shim.Success(nil)
+}
@@ -0,0 +1,524 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// Transient map keys read by the chaincode.
+const (
+	transientSealedBid = "sealed_bid"
+	transientUserPII   = "user_pii"
+)
+
+func formatInt(value int64) string {
+	return strconv.FormatInt(value, 10)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func transientJSON(key string, value interface{}) (map[string][]byte, error) {
+	valueAsBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.New("Failed to marshal transient " + key + ": " + err.Error())
+	}
+	return map[string][]byte{key: valueAsBytes}, nil
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Users and Platform Terms                          */
+/* -------------------------------------------------------------------------- */
+
+// UpdateUserProfile creates or updates a user. pii is required when creating a user
+// and is sent in the transient map so it never reaches the public ledger. A random
+// salt is generated when pii carries none.
+func (c *Client) UpdateUserProfile(ctx context.Context, request chaincode.UserRequest, pii *chaincode.UserPII) error {
+	requestPayload, err := payload(request)
+	if err != nil {
+		return err
+	}
+	var transient map[string][]byte
+	if pii != nil {
+		salted := *pii
+		if salted.Salt == "" {
+			salt := make([]byte, 16)
+			_, err = rand.Read(salt)
+			if err != nil {
+				return errors.New("Failed to generate PII salt: " + err.Error())
+			}
+			salted.Salt = hex.EncodeToString(salt)
+		}
+		transient, err = transientJSON(transientUserPII, salted)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = c.submitWithTransient(ctx, "UpdateUserProfile", transient, requestPayload)
+	return err
+}
+
+func (c *Client) ReadUserProfile(ctx context.Context, userID int64) (chaincode.User, error) {
+	var user chaincode.User
+	err := c.evaluateInto(ctx, &user, "ReadUserProfile", formatInt(userID))
+	return user, err
+}
+
+// ReadUserPII returns a user's personal data; the peer must belong to the user's org.
+func (c *Client) ReadUserPII(ctx context.Context, userID int64) (chaincode.UserPII, error) {
+	var pii chaincode.UserPII
+	err := c.evaluateInto(ctx, &pii, "ReadUserPII", formatInt(userID))
+	return pii, err
+}
+
+// BindUserIdentity binds an "MSP:CN" identity to a user created before profiles
+// carried one; only the platform admin may call it.
+func (c *Client) BindUserIdentity(ctx context.Context, userID int64, identity string) error {
+	_, err := c.submit(ctx, "BindUserIdentity", formatInt(userID), identity)
+	return err
+}
+
+// SignPlatformContract accepts a terms version on behalf of a user; an empty
+// termsVersion accepts the active terms.
+func (c *Client) SignPlatformContract(ctx context.Context, userID int64, termsVersion string) error {
+	args := []string{formatInt(userID)}
+	if termsVersion != "" {
+		args = append(args, termsVersion)
+	}
+	_, err := c.submit(ctx, "SignPlatformContract", args...)
+	return err
+}
+
+func (c *Client) RevokePlatformContract(ctx context.Context, userID int64) error {
+	_, err := c.submit(ctx, "RevokePlatformContract", formatInt(userID))
+	return err
+}
+
+func (c *Client) ReadPlatformContract(ctx context.Context, userID int64) (chaincode.PlatformContract, error) {
+	var contract chaincode.PlatformContract
+	err := c.evaluateInto(ctx, &contract, "ReadPlatformContract", formatInt(userID))
+	return contract, err
+}
+
+func (c *Client) PublishPlatformTerms(ctx context.Context, version string, documentHash string, documentURI string) error {
+	_, err := c.submit(ctx, "PublishPlatformTerms", version, documentHash, documentURI)
+	return err
+}
+
+// ReadPlatformTerms returns a terms version, or the terms in force for "active".
+func (c *Client) ReadPlatformTerms(ctx context.Context, version string) (chaincode.PlatformTerms, error) {
+	var terms chaincode.PlatformTerms
+	err := c.evaluateInto(ctx, &terms, "ReadPlatformTerms", version)
+	return terms, err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                   Orders                                   */
+/* -------------------------------------------------------------------------- */
+
+func (c *Client) RegisterOrder(ctx context.Context, request chaincode.OrderRequest) error {
+	requestPayload, err := payload(request)
+	if err != nil {
+		return err
+	}
+	_, err = c.submit(ctx, "RegisterOrder", requestPayload)
+	return err
+}
+
+// RegisterOrders stores every order or none of them and returns the stored order IDs.
+// A rejected batch is an error whose details name each refused order and the code it failed with.
+func (c *Client) RegisterOrders(ctx context.Context, requests []chaincode.OrderRequest) ([]int64, error) {
+	requestsPayload, err := payload(requests)
+	if err != nil {
+		return nil, err
+	}
+	var orderIDs []int64
+	err = c.submitInto(ctx, &orderIDs, "RegisterOrders", requestsPayload)
+	return orderIDs, err
+}
+
+// SetOrderAggregators replaces the "<MSP ID>:<common name>" identities allowed to batch orders of other users.
+func (c *Client) SetOrderAggregators(ctx context.Context, aggregators ...string) error {
+	_, err := c.submit(ctx, "SetOrderAggregators", aggregators...)
+	return err
+}
+
+func (c *Client) CancelOrder(ctx context.Context, orderID int64) error {
+	_, err := c.submit(ctx, "CancelOrder", formatInt(orderID))
+	return err
+}
+
+func (c *Client) AmendOrder(ctx context.Context, orderID int64, unitCost float64, totalQuantity int64) error {
+	_, err := c.submit(ctx, "AmendOrder", formatInt(orderID), formatFloat(unitCost), formatInt(totalQuantity))
+	return err
+}
+
+func (c *Client) ReadOrder(ctx context.Context, orderID int64) (chaincode.Order, error) {
+	var order chaincode.Order
+	err := c.evaluateInto(ctx, &order, "ReadOrder", formatInt(orderID))
+	return order, err
+}
+
+// ReadOrderFills returns the BidMatches that filled an order.
+func (c *Client) ReadOrderFills(ctx context.Context, orderID int64) ([]chaincode.BidMatch, error) {
+	var fills []chaincode.BidMatch
+	err := c.evaluateInto(ctx, &fills, "ReadOrderFills", formatInt(orderID))
+	return fills, err
+}
+
+// CommitOrder commits to a hidden order. When bid is given it is checked against the
+// commitment and kept in the user's org collection, so RevealOrder can omit it.
+// The bid's salt must be a secret of at least 16 characters.
+func (c *Client) CommitOrder(ctx context.Context, orderID int64, slotID string, userID int64, action chaincode.Action, commitment string, bid *chaincode.SealedBid) error {
+	var transient map[string][]byte
+	var err error
+	if bid != nil {
+		transient, err = transientJSON(transientSealedBid, bid)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = c.submitWithTransient(ctx, "CommitOrder", transient, formatInt(orderID), slotID, formatInt(userID), action.String(), commitment)
+	return err
+}
+
+// RevealOrder opens a commitment after gate closure; bid may be nil when it was given to CommitOrder.
+func (c *Client) RevealOrder(ctx context.Context, orderID int64, bid *chaincode.SealedBid) error {
+	var transient map[string][]byte
+	var err error
+	if bid != nil {
+		transient, err = transientJSON(transientSealedBid, bid)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = c.submitWithTransient(ctx, "RevealOrder", transient, formatInt(orderID))
+	return err
+}
+
+func (c *Client) ReadSealedOrder(ctx context.Context, orderID int64) (chaincode.SealedOrder, error) {
+	var sealed chaincode.SealedOrder
+	err := c.evaluateInto(ctx, &sealed, "ReadSealedOrder", formatInt(orderID))
+	return sealed, err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                          Bid Matches and Payments                          */
+/* -------------------------------------------------------------------------- */
+
+func (c *Client) ProcessBidMatch(ctx context.Context, request chaincode.BidMatchRequest) error {
+	requestPayload, err := payload(request)
+	if err != nil {
+		return err
+	}
+	_, err = c.submit(ctx, "ProcessBidMatch", requestPayload)
+	return err
+}
+
+func (c *Client) ReadBidMatch(ctx context.Context, bidMatchID int64) (chaincode.BidMatch, error) {
+	var bidMatch chaincode.BidMatch
+	err := c.evaluateInto(ctx, &bidMatch, "ReadBidMatch", formatInt(bidMatchID))
+	return bidMatch, err
+}
+
+func (c *Client) RecordPayment(ctx context.Context, request chaincode.PaymentRequest) error {
+	requestPayload, err := payload(request)
+	if err != nil {
+		return err
+	}
+	_, err = c.submit(ctx, "RecordPayment", requestPayload)
+	return err
+}
+
+func (c *Client) ReadPayment(ctx context.Context, paymentID string) (chaincode.Payment, error) {
+	var payment chaincode.Payment
+	err := c.evaluateInto(ctx, &payment, "ReadPayment", paymentID)
+	return payment, err
+}
+
+func (c *Client) ReadPaymentDetail(ctx context.Context, paymentDetailID int64) (chaincode.PaymentDetail, error) {
+	var detail chaincode.PaymentDetail
+	err := c.evaluateInto(ctx, &detail, "ReadPaymentDetail", formatInt(paymentDetailID))
+	return detail, err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Trading Slots                                */
+/* -------------------------------------------------------------------------- */
+
+// CreateTradingSlot opens a single slot; times are Unix seconds.
+func (c *Client) CreateTradingSlot(ctx context.Context, slotID string, startTime int64, endTime int64, gateClosure int64) error {
+	_, err := c.submit(ctx, "CreateTradingSlot", slotID, formatInt(startTime), formatInt(endTime), formatInt(gateClosure))
+	return err
+}
+
+// GenerateTradingSlots opens count consecutive slots and returns their IDs.
+func (c *Client) GenerateTradingSlots(ctx context.Context, firstStart int64, durationSec int64, count int64, gateClosureLeadSec int64) ([]string, error) {
+	var slotIDs []string
+	err := c.submitInto(ctx, &slotIDs, "GenerateTradingSlots", formatInt(firstStart), formatInt(durationSec), formatInt(count), formatInt(gateClosureLeadSec))
+	return slotIDs, err
+}
+
+func (c *Client) UpdateTradingSlotStatus(ctx context.Context, slotID string, status chaincode.SlotStatus) error {
+	_, err := c.submit(ctx, "UpdateTradingSlotStatus", slotID, status.String())
+	return err
+}
+
+func (c *Client) ReadTradingSlot(ctx context.Context, slotID string) (chaincode.TradingSlot, error) {
+	var slot chaincode.TradingSlot
+	err := c.evaluateInto(ctx, &slot, "ReadTradingSlot", slotID)
+	return slot, err
+}
+
+// QueryTradingSlots returns the slots starting within [fromStart, toStart], ordered by start time.
+func (c *Client) QueryTradingSlots(ctx context.Context, fromStart int64, toStart int64) ([]chaincode.TradingSlot, error) {
+	var slots []chaincode.TradingSlot
+	err := c.evaluateInto(ctx, &slots, "QueryTradingSlots", formatInt(fromStart), formatInt(toStart))
+	return slots, err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                        Market Prices and Settlement                        */
+/* -------------------------------------------------------------------------- */
+
+// SetMarketOracleConfig sets the price band and the "<MSP ID>:<common name>" identities allowed to publish prices.
+func (c *Client) SetMarketOracleConfig(ctx context.Context, priceBandPct float64, oracles ...string) error {
+	_, err := c.submit(ctx, "SetMarketOracleConfig", append([]string{formatFloat(priceBandPct)}, oracles...)...)
+	return err
+}
+
+func (c *Client) ReadMarketOracleConfig(ctx context.Context) (chaincode.MarketOracleConfig, error) {
+	var config chaincode.MarketOracleConfig
+	err := c.evaluateInto(ctx, &config, "ReadMarketOracleConfig")
+	return config, err
+}
+
+func (c *Client) PublishMarketPrice(ctx context.Context, slotID string, price float64, source string, timestamp int64) error {
+	_, err := c.submit(ctx, "PublishMarketPrice", slotID, formatFloat(price), source, formatInt(timestamp))
+	return err
+}
+
+func (c *Client) ReadMarketPrice(ctx context.Context, slotID string) (chaincode.MarketPrice, error) {
+	var price chaincode.MarketPrice
+	err := c.evaluateInto(ctx, &price, "ReadMarketPrice", slotID)
+	return price, err
+}
+
+func (c *Client) SetSettlementConfig(ctx context.Context, netPerUser bool, penaltyPct float64) error {
+	_, err := c.submit(ctx, "SetSettlementConfig", strconv.FormatBool(netPerUser), formatFloat(penaltyPct))
+	return err
+}
+
+// SettleSlot turns every executed BidMatch of a closed slot into payments.
+func (c *Client) SettleSlot(ctx context.Context, slotID string) (chaincode.SlotSettlement, error) {
+	var settlement chaincode.SlotSettlement
+	err := c.submitInto(ctx, &settlement, "SettleSlot", slotID)
+	return settlement, err
+}
+
+func (c *Client) ReadSlotSettlement(ctx context.Context, slotID string) (chaincode.SlotSettlement, error) {
+	var settlement chaincode.SlotSettlement
+	err := c.evaluateInto(ctx, &settlement, "ReadSlotSettlement", slotID)
+	return settlement, err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Fee Schedules                                */
+/* -------------------------------------------------------------------------- */
+
+// ProposeFeeSchedule proposes the next fee schedule and returns its version.
+func (c *Client) ProposeFeeSchedule(ctx context.Context, schedule chaincode.FeeScheduleRequest) (int64, error) {
+	schedulePayload, err := payload(schedule)
+	if err != nil {
+		return 0, err
+	}
+	result, err := c.submit(ctx, "ProposeFeeSchedule", schedulePayload)
+	if err != nil {
+		return 0, err
+	}
+	version, err := strconv.ParseInt(string(result), 10, 64)
+	if err != nil {
+		return 0, errors.New("Failed to decode ProposeFeeSchedule result: " + err.Error())
+	}
+	return version, nil
+}
+
+func (c *Client) ApproveFeeSchedule(ctx context.Context, version int64) error {
+	_, err := c.submit(ctx, "ApproveFeeSchedule", formatInt(version))
+	return err
+}
+
+// ReadFeeSchedule returns a schedule version, or the schedule in force for "active".
+func (c *Client) ReadFeeSchedule(ctx context.Context, version string) (chaincode.FeeSchedule, error) {
+	var schedule chaincode.FeeSchedule
+	err := c.evaluateInto(ctx, &schedule, "ReadFeeSchedule", version)
+	return schedule, err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Disputes                                  */
+/* -------------------------------------------------------------------------- */
+
+func (c *Client) OpenDispute(ctx context.Context, disputeID string, bidMatchID int64, userID int64, disputeType chaincode.DisputeType, reason string) error {
+	_, err := c.submit(ctx, "OpenDispute", disputeID, formatInt(bidMatchID), formatInt(userID), disputeType.String(), reason)
+	return err
+}
+
+func (c *Client) SubmitEvidence(ctx context.Context, disputeID string, userID int64, documentHash string, description string) error {
+	_, err := c.submit(ctx, "SubmitEvidence", disputeID, formatInt(userID), documentHash, description)
+	return err
+}
+
+// ResolveDispute closes a dispute as Upheld or Rejected; an upheld dispute with a
+// positive adjustment records a Payment to the user who opened it.
+func (c *Client) ResolveDispute(ctx context.Context, disputeID string, outcome chaincode.DisputeStatus, adjustmentAmount float64, resolution string) error {
+	_, err := c.submit(ctx, "ResolveDispute", disputeID, outcome.String(), formatFloat(adjustmentAmount), resolution)
+	return err
+}
+
+// SetDisputeArbitrators replaces the "<MSP ID>:<common name>" identities allowed to resolve disputes.
+func (c *Client) SetDisputeArbitrators(ctx context.Context, arbitrators ...string) error {
+	_, err := c.submit(ctx, "SetDisputeArbitrators", arbitrators...)
+	return err
+}
+
+func (c *Client) ReadDispute(ctx context.Context, disputeID string) (chaincode.Dispute, error) {
+	var dispute chaincode.Dispute
+	err := c.evaluateInto(ctx, &dispute, "ReadDispute", disputeID)
+	return dispute, err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                           RECs and Emissions                               */
+/* -------------------------------------------------------------------------- */
+
+func (c *Client) TransferREC(ctx context.Context, recID string, fromUserID int64, toUserID int64) error {
+	_, err := c.submit(ctx, "TransferREC", recID, formatInt(fromUserID), formatInt(toUserID))
+	return err
+}
+
+func (c *Client) RetireREC(ctx context.Context, recID string, userID int64, note string) error {
+	_, err := c.submit(ctx, "RetireREC", recID, formatInt(userID), note)
+	return err
+}
+
+func (c *Client) ReadREC(ctx context.Context, recID string) (chaincode.REC, error) {
+	var rec chaincode.REC
+	err := c.evaluateInto(ctx, &rec, "ReadREC", recID)
+	return rec, err
+}
+
+func (c *Client) QueryRECsByOwner(ctx context.Context, userID int64) ([]chaincode.REC, error) {
+	var recs []chaincode.REC
+	err := c.evaluateInto(ctx, &recs, "QueryRECsByOwner", formatInt(userID))
+	return recs, err
+}
+
+func (c *Client) QueryRECsByVintage(ctx context.Context, vintage string) ([]chaincode.REC, error) {
+	var recs []chaincode.REC
+	err := c.evaluateInto(ctx, &recs, "QueryRECsByVintage", vintage)
+	return recs, err
+}
+
+func (c *Client) SetEmissionFactor(ctx context.Context, source chaincode.EnergySource, kgCO2ePerKWh float64) error {
+	_, err := c.submit(ctx, "SetEmissionFactor", source.String(), formatFloat(kgCO2ePerKWh))
+	return err
+}
+
+func (c *Client) ReadEmissionFactor(ctx context.Context, source chaincode.EnergySource) (chaincode.EmissionFactor, error) {
+	var factor chaincode.EmissionFactor
+	err := c.evaluateInto(ctx, &factor, "ReadEmissionFactor", source.String())
+	return factor, err
+}
+
+func (c *Client) QueryEmissionsSummary(ctx context.Context, userID int64, startTms int64, endTms int64) (chaincode.EmissionsSummary, error) {
+	var summary chaincode.EmissionsSummary
+	err := c.evaluateInto(ctx, &summary, "QueryEmissionsSummary", formatInt(userID), formatInt(startTms), formatInt(endTms))
+	return summary, err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                         Analytics and Maintenance                          */
+/* -------------------------------------------------------------------------- */
+
+func (c *Client) QuerySlotStatistics(ctx context.Context, slotID string) (chaincode.SlotStatistics, error) {
+	var stats chaincode.SlotStatistics
+	err := c.evaluateInto(ctx, &stats, "QuerySlotStatistics", slotID)
+	return stats, err
+}
+
+func (c *Client) QuerySlotStatisticsByRange(ctx context.Context, fromExecDate int64, toExecDate int64) ([]chaincode.SlotStatistics, error) {
+	var series []chaincode.SlotStatistics
+	err := c.evaluateInto(ctx, &series, "QuerySlotStatisticsByRange", formatInt(fromExecDate), formatInt(toExecDate))
+	return series, err
+}
+
+// GenerateStatement returns a user's statement for a calendar month given as "YYYYMM".
+func (c *Client) GenerateStatement(ctx context.Context, userID int64, period string) (chaincode.Statement, error) {
+	var statement chaincode.Statement
+	err := c.evaluateInto(ctx, &statement, "GenerateStatement", formatInt(userID), period)
+	return statement, err
+}
+
+// ReadAsset returns any stored record by its kind, e.g. "Order", and ID, upgraded
+// to the current schema. The ID is empty for singleton kinds such as "SettlementConfig".
+func (c *Client) ReadAsset(ctx context.Context, kind string, id string) (json.RawMessage, error) {
+	args := []string{kind}
+	if id != "" {
+		args = append(args, id)
+	}
+	return c.evaluate(ctx, "ReadAsset", args...)
+}
+
+// ReadAssetHistory returns every committed write of a record, oldest first.
+func (c *Client) ReadAssetHistory(ctx context.Context, kind string, id string) ([]chaincode.AssetHistoryEntry, error) {
+	args := []string{kind}
+	if id != "" {
+		args = append(args, id)
+	}
+	var history []chaincode.AssetHistoryEntry
+	err := c.evaluateInto(ctx, &history, "ReadAssetHistory", args...)
+	return history, err
+}
+
+// QueryAssets returns one page of the current records of an asset kind; pass the
+// returned bookmark back in until it comes back empty.
+func (c *Client) QueryAssets(ctx context.Context, kind string, pageSize int64, bookmark string) (chaincode.AssetPage, error) {
+	var page chaincode.AssetPage
+	err := c.evaluateInto(ctx, &page, "QueryAssets", kind, formatInt(pageSize), bookmark)
+	return page, err
+}
+
+// MigrateAssets rewrites one page of an asset kind at the current schema version;
+// pass the returned bookmark back in until it comes back empty.
+func (c *Client) MigrateAssets(ctx context.Context, kind string, pageSize int64, bookmark string) (chaincode.MigrationPage, error) {
+	var page chaincode.MigrationPage
+	err := c.submitInto(ctx, &page, "MigrateAssets", kind, formatInt(pageSize), bookmark)
+	return page, err
+}
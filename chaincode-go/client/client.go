@@ -0,0 +1,159 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package client is a typed Go API for the energy trading chaincode. A Client
+// turns method calls into chaincode invocations and decodes their results; how
+// the invocations reach a ledger is up to its Transport:
+//
+//	gateway := client.NewGatewayTransport(network.GetContract("basic"))
+//	api := client.New(gateway)
+//	order, err := api.ReadOrder(ctx, 4)
+//	if errcode.Is(err, errcode.NotFound) {
+//		...
+//	}
+//
+// NewMockTransport runs the chaincode in process on a shimtest.MockStub instead,
+// for tests and dry runs.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// Transport carries chaincode invocations to a ledger.
+type Transport interface {
+	// Submit endorses a transaction and waits for it to commit, returning its result.
+	Submit(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error)
+	// Evaluate runs a query on a peer without submitting it for commit.
+	Evaluate(ctx context.Context, function string, args []string) ([]byte, error)
+}
+
+// Client calls the chaincode through a Transport. Errors raised by the chaincode
+// are returned as *errcode.Error; other errors come from the transport.
+type Client struct {
+	transport Transport
+}
+
+// CommitError reports a transaction that was endorsed but invalidated at commit,
+// typically by an MVCC read conflict with a concurrent transaction.
+type CommitError struct {
+	Code          peer.TxValidationCode
+	TransactionID string
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("transaction %s failed to commit with status code %d (%s)", e.TransactionID, int32(e.Code), e.Code.String())
+}
+
+// New returns a Client that invokes the chaincode through transport.
+func New(transport Transport) *Client {
+	return &Client{transport: transport}
+}
+
+// Invoke submits a call to any chaincode function and returns its raw result; it
+// serves functions that have no typed method.
+func (c *Client) Invoke(ctx context.Context, function string, args ...string) ([]byte, error) {
+	return c.submit(ctx, function, args...)
+}
+
+// Query evaluates a call to any chaincode function and returns its raw result.
+func (c *Client) Query(ctx context.Context, function string, args ...string) ([]byte, error) {
+	return c.evaluate(ctx, function, args...)
+}
+
+// IsMVCCConflict reports whether err is a commit failure caused by a concurrent
+// transaction; retrying the transaction usually succeeds.
+func IsMVCCConflict(err error) bool {
+	var commitErr *CommitError
+	return errors.As(err, &commitErr) && commitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT
+}
+
+/* -------------------------------------------------------------------------- */
+/*                               Helper Methods                               */
+/* -------------------------------------------------------------------------- */
+
+// chaincodeError returns the chaincode error carried by err, or err itself.
+func chaincodeError(err error) error {
+	if parsed := errcode.FromError(err); parsed.Code != errcode.Unknown {
+		return parsed
+	}
+	return err
+}
+
+func (c *Client) submit(ctx context.Context, function string, args ...string) ([]byte, error) {
+	return c.submitWithTransient(ctx, function, nil, args...)
+}
+
+func (c *Client) submitWithTransient(ctx context.Context, function string, transient map[string][]byte, args ...string) ([]byte, error) {
+	result, err := c.transport.Submit(ctx, function, args, transient)
+	if err != nil {
+		return nil, chaincodeError(err)
+	}
+	return result, nil
+}
+
+func (c *Client) evaluate(ctx context.Context, function string, args ...string) ([]byte, error) {
+	result, err := c.transport.Evaluate(ctx, function, args)
+	if err != nil {
+		return nil, chaincodeError(err)
+	}
+	return result, nil
+}
+
+// evaluateInto runs a query and decodes its JSON result into target.
+func (c *Client) evaluateInto(ctx context.Context, target interface{}, function string, args ...string) error {
+	result, err := c.evaluate(ctx, function, args...)
+	if err != nil {
+		return err
+	}
+	return decodeResult(function, result, target)
+}
+
+// submitInto submits a transaction and decodes its JSON result into target.
+func (c *Client) submitInto(ctx context.Context, target interface{}, function string, args ...string) error {
+	result, err := c.submit(ctx, function, args...)
+	if err != nil {
+		return err
+	}
+	return decodeResult(function, result, target)
+}
+
+func decodeResult(function string, result []byte, target interface{}) error {
+	err := json.Unmarshal(result, target)
+	if err != nil {
+		return errors.New("Failed to decode " + function + " result: " + err.Error())
+	}
+	return nil
+}
+
+// payload marshals a request for the chaincode's JSON payload form.
+func payload(request interface{}) (string, error) {
+	requestAsBytes, err := json.Marshal(request)
+	if err != nil {
+		return "", errors.New("Failed to marshal request: " + err.Error())
+	}
+	return string(requestAsBytes), nil
+}
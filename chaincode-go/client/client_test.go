@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// newMockClient returns a Client on an empty mock ledger with an open, priced slot
+// "slot1", invoking as the platform admin, who is also the price oracle.
+func newMockClient(t *testing.T) *Client {
+	transport := NewMockTransport("client", new(chaincode.SimpleChaincode))
+	require.NoError(t, transport.SetIdentity("Org1MSP", "admin"))
+	api := New(transport)
+
+	start := time.Now().Add(2 * time.Hour).Unix()
+	err := api.CreateTradingSlot(context.Background(), "slot1", start, start+900, start-3600)
+	require.NoError(t, err)
+	require.NoError(t, api.SetMarketOracleConfig(context.Background(), 20, "Org1MSP:admin"))
+	require.NoError(t, api.PublishMarketPrice(context.Background(), "slot1", 3.5, "test", time.Now().Unix()))
+	return api
+}
+
+// newMockUser creates a user bound to the client's identity, who can then amend
+// and cancel their orders.
+func newMockUser(t *testing.T, api *Client, userID int64) {
+	request := chaincode.UserRequest{ID: userID, Category: chaincode.Consumer, Source: chaincode.Battery}
+	require.NoError(t, api.UpdateUserProfile(context.Background(), request, &chaincode.UserPII{Location: "Location", MeterId: "Meter"}))
+}
+
+func TestClientOrders(t *testing.T) {
+	ctx := context.Background()
+	api := newMockClient(t)
+	for _, userID := range []int64{6, 7, 8} {
+		newMockUser(t, api, userID)
+	}
+
+	t.Run("Register and read back an order", func(t *testing.T) {
+		err := api.RegisterOrder(ctx, chaincode.OrderRequest{ID: 4, SlotID: "slot1", TotalQuantity: 300, UnitCost: 3.5, UserAction: chaincode.Buy, UserID: 6})
+		require.NoError(t, err)
+
+		order, err := api.ReadOrder(ctx, 4)
+		require.NoError(t, err)
+		assert.Equal(t, "slot1", order.SlotID)
+		assert.Equal(t, chaincode.Buy, order.UserAction)
+		assert.Equal(t, int64(300), order.TotalQuantity)
+	})
+
+	t.Run("Register a batch of orders", func(t *testing.T) {
+		orderIDs, err := api.RegisterOrders(ctx, []chaincode.OrderRequest{
+			{ID: 5, SlotID: "slot1", TotalQuantity: 100, UnitCost: 3.4, UserAction: chaincode.Sell, UserID: 7},
+			{ID: 6, SlotID: "slot1", TotalQuantity: 200, UnitCost: 3.6, UserAction: chaincode.Buy, UserID: 8},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{5, 6}, orderIDs)
+	})
+
+	t.Run("Rejected batch carries the chaincode error", func(t *testing.T) {
+		_, err := api.RegisterOrders(ctx, []chaincode.OrderRequest{
+			{ID: 9, SlotID: "slot1", TotalQuantity: 100, UnitCost: 3.4, UserAction: chaincode.Sell, UserID: 7},
+			{ID: 10, SlotID: "missing", TotalQuantity: 100, UnitCost: 3.4, UserAction: chaincode.Sell, UserID: 7},
+		})
+		require.Error(t, err)
+		assert.True(t, errcode.Is(err, errcode.NotFound), "unexpected error: %v", err)
+		require.Len(t, errcode.FromError(err).Details, 1)
+		assert.Equal(t, "[1]", errcode.FromError(err).Details[0].Field)
+		assert.Equal(t, errcode.NotFound, errcode.FromError(err).Details[0].Code)
+	})
+
+	t.Run("Read back a user", func(t *testing.T) {
+		user, err := api.ReadUserProfile(ctx, 6)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), user.ID)
+		assert.Equal(t, chaincode.Consumer, user.Category)
+		assert.Equal(t, chaincode.Battery, user.Source)
+		assert.Equal(t, "Org1MSP:admin", user.Identity)
+	})
+
+	t.Run("Missing order is NOT_FOUND", func(t *testing.T) {
+		_, err := api.ReadOrder(ctx, 404)
+		assert.True(t, errcode.Is(err, errcode.NotFound), "unexpected error: %v", err)
+	})
+}
+
+func TestClientForbidden(t *testing.T) {
+	ctx := context.Background()
+	transport := NewMockTransport("client", new(chaincode.SimpleChaincode))
+	require.NoError(t, transport.SetIdentity("Org2MSP", "operator"))
+
+	err := New(transport).CreateTradingSlot(ctx, "slot1", 1700003600, 1700004500, 1700000000)
+	assert.True(t, errcode.Is(err, errcode.Forbidden), "unexpected error: %v", err)
+}
+
+func TestIsMVCCConflict(t *testing.T) {
+	conflict := &CommitError{Code: peer.TxValidationCode_MVCC_READ_CONFLICT, TransactionID: "tx1"}
+	assert.True(t, IsMVCCConflict(conflict))
+	assert.True(t, IsMVCCConflict(fmt.Errorf("submit RegisterOrder: %w", conflict)))
+	assert.False(t, IsMVCCConflict(&CommitError{Code: peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE, TransactionID: "tx2"}))
+	assert.False(t, IsMVCCConflict(errcode.New(errcode.Conflict, "Order 4 already exists")))
+	assert.Contains(t, conflict.Error(), "MVCC_READ_CONFLICT")
+}
+
+func TestMockTransportHistory(t *testing.T) {
+	ctx := context.Background()
+	api := newMockClient(t)
+	newMockUser(t, api, 6)
+
+	require.NoError(t, api.RegisterOrder(ctx, chaincode.OrderRequest{ID: 4, SlotID: "slot1", TotalQuantity: 300, UnitCost: 3.5, UserAction: chaincode.Buy, UserID: 6}))
+	require.NoError(t, api.AmendOrder(ctx, 4, 3.6, 250))
+
+	history, err := api.ReadAssetHistory(ctx, "Order", "4")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.NotEqual(t, history[0].TxID, history[1].TxID)
+	assert.Contains(t, string(history[1].Value), `"totalQuantity":250`)
+
+	order, err := api.ReadAsset(ctx, "Order", "4")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(history[1].Value), string(order))
+}
+
+func TestMockTransportLedgerFile(t *testing.T) {
+	ctx := context.Background()
+	api := newMockClient(t)
+	newMockUser(t, api, 6)
+	require.NoError(t, api.RegisterOrder(ctx, chaincode.OrderRequest{ID: 4, SlotID: "slot1", TotalQuantity: 300, UnitCost: 3.5, UserAction: chaincode.Buy, UserID: 6}))
+
+	var saved bytes.Buffer
+	require.NoError(t, api.transport.(*MockTransport).SaveLedger(&saved))
+
+	restored := NewMockTransport("client", new(chaincode.SimpleChaincode))
+	require.NoError(t, restored.LoadLedger(&saved))
+	order, err := New(restored).ReadOrder(ctx, 4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), order.TotalQuantity)
+
+	history, err := New(restored).ReadAssetHistory(ctx, "Order", "4")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestMockTransportBlocks(t *testing.T) {
+	ctx := context.Background()
+	api := newMockClient(t)
+	for _, userID := range []int64{6, 7, 8} {
+		newMockUser(t, api, userID)
+	}
+	transport := api.transport.(*MockTransport)
+	order := chaincode.OrderRequest{ID: 4, SlotID: "slot1", TotalQuantity: 300, UnitCost: 3.5, UserAction: chaincode.Buy, UserID: 6}
+
+	t.Run("Evaluated writes are discarded", func(t *testing.T) {
+		payload, err := payload(order)
+		require.NoError(t, err)
+		_, err = api.Query(ctx, "RegisterOrder", payload)
+		require.NoError(t, err)
+		_, err = api.ReadOrder(ctx, 4)
+		assert.True(t, errcode.Is(err, errcode.NotFound), "unexpected error: %v", err)
+	})
+
+	t.Run("Reading a key written earlier in the block conflicts", func(t *testing.T) {
+		transport.SetBlockSize(3)
+		require.NoError(t, api.RegisterOrder(ctx, order))
+		require.NoError(t, api.RegisterOrder(ctx, chaincode.OrderRequest{ID: 5, SlotID: "slot1", TotalQuantity: 100, UnitCost: 3.5, UserAction: chaincode.Sell, UserID: 7}))
+
+		err := api.AmendOrder(ctx, 4, 3.5, 250)
+		assert.True(t, IsMVCCConflict(err), "unexpected error: %v", err)
+		stored, err := api.ReadOrder(ctx, 4)
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), stored.TotalQuantity, "conflicting write was kept")
+
+		// The block is full, so the retry lands in the next one.
+		require.NoError(t, api.AmendOrder(ctx, 4, 3.5, 250))
+		stored, err = api.ReadOrder(ctx, 4)
+		require.NoError(t, err)
+		assert.Equal(t, int64(250), stored.TotalQuantity)
+	})
+
+	t.Run("Transactions carry the transport clock", func(t *testing.T) {
+		transport.SetBlockSize(1)
+		now := time.Now().Add(3 * time.Hour)
+		transport.SetClock(func() time.Time { return now })
+		err := api.RegisterOrder(ctx, chaincode.OrderRequest{ID: 6, SlotID: "slot1", TotalQuantity: 100, UnitCost: 3.5, UserAction: chaincode.Buy, UserID: 8})
+		assert.True(t, errcode.Is(err, errcode.StateViolation), "order accepted after gate closure: %v", err)
+	})
+}
@@ -0,0 +1,135 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// GatewayProfile holds what is needed to reach the Fabric Gateway of a peer as one
+// user. KeyPath may name a keystore directory, whose first file is used.
+type GatewayProfile struct {
+	CertPath      string `json:"certPath"`
+	KeyPath       string `json:"keyPath"`
+	MspID         string `json:"mspId"`
+	PeerEndpoint  string `json:"peerEndpoint"`
+	PeerHostAlias string `json:"peerHostAlias"`
+	TLSCertPath   string `json:"tlsCertPath"`
+}
+
+// Default gateway timeouts, as in the sample application.
+const (
+	evaluateTimeout     = 5 * time.Second
+	endorseTimeout      = 15 * time.Second
+	submitTimeout       = 5 * time.Second
+	commitStatusTimeout = time.Minute
+)
+
+// Dial connects to the gateway of the profile's peer, signing as the profile's
+// user. close releases the gateway and its connection.
+func Dial(profile GatewayProfile) (gateway *client.Gateway, close func() error, err error) {
+	tlsCertPEM, err := ioutil.ReadFile(profile.TLSCertPath)
+	if err != nil {
+		return nil, nil, errors.New("Failed to read peer TLS certificate: " + err.Error())
+	}
+	tlsCert, err := identity.CertificateFromPEM(tlsCertPEM)
+	if err != nil {
+		return nil, nil, errors.New("Failed to parse peer TLS certificate: " + err.Error())
+	}
+	certPool := x509.NewCertPool()
+	certPool.AddCert(tlsCert)
+	connection, err := grpc.Dial(profile.PeerEndpoint, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, profile.PeerHostAlias)))
+	if err != nil {
+		return nil, nil, errors.New("Failed to dial " + profile.PeerEndpoint + ": " + err.Error())
+	}
+
+	id, sign, err := signingIdentity(profile)
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+	gateway, err = client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(connection),
+		client.WithEvaluateTimeout(evaluateTimeout),
+		client.WithEndorseTimeout(endorseTimeout),
+		client.WithSubmitTimeout(submitTimeout),
+		client.WithCommitStatusTimeout(commitStatusTimeout),
+	)
+	if err != nil {
+		connection.Close()
+		return nil, nil, errors.New("Failed to connect to the gateway: " + err.Error())
+	}
+
+	close = func() error {
+		gateway.Close()
+		return connection.Close()
+	}
+	return gateway, close, nil
+}
+
+// signingIdentity loads the X.509 identity and private key of the profile's user.
+func signingIdentity(profile GatewayProfile) (*identity.X509Identity, identity.Sign, error) {
+	certPEM, err := ioutil.ReadFile(profile.CertPath)
+	if err != nil {
+		return nil, nil, errors.New("Failed to read certificate: " + err.Error())
+	}
+	cert, err := identity.CertificateFromPEM(certPEM)
+	if err != nil {
+		return nil, nil, errors.New("Failed to parse certificate: " + err.Error())
+	}
+	id, err := identity.NewX509Identity(profile.MspID, cert)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyPath := profile.KeyPath
+	if info, err := os.Stat(keyPath); err == nil && info.IsDir() {
+		files, err := ioutil.ReadDir(keyPath)
+		if err != nil || len(files) == 0 {
+			return nil, nil, errors.New("No private key found in " + keyPath)
+		}
+		keyPath = filepath.Join(keyPath, files[0].Name())
+	}
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, errors.New("Failed to read private key: " + err.Error())
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, nil, errors.New("Failed to parse private key: " + err.Error())
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, sign, nil
+}
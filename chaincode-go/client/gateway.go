@@ -0,0 +1,76 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// GatewayTransport invokes the chaincode on a Fabric network through the Fabric
+// Gateway of a peer.
+type GatewayTransport struct {
+	contract *client.Contract
+}
+
+// NewGatewayTransport returns a Transport for a contract obtained from a connected
+// gateway, e.g. gateway.GetNetwork("mychannel").GetContract("basic").
+func NewGatewayTransport(contract *client.Contract) *GatewayTransport {
+	return &GatewayTransport{contract: contract}
+}
+
+// Submit endorses the transaction, submits it to the orderer and waits for its commit
+// status; a transaction invalidated at commit is reported as a *CommitError.
+func (t *GatewayTransport) Submit(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	options := []client.ProposalOption{client.WithArguments(args...)}
+	if len(transient) > 0 {
+		options = append(options, client.WithTransient(transient))
+	}
+	proposal, err := t.contract.NewProposal(function, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := transaction.SubmitWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status, err := commit.StatusWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Successful {
+		return nil, &CommitError{Code: status.Code, TransactionID: status.TransactionID}
+	}
+	return transaction.Result(), nil
+}
+
+func (t *GatewayTransport) Evaluate(ctx context.Context, function string, args []string) ([]byte, error) {
+	proposal, err := t.contract.NewProposal(function, client.WithArguments(args...))
+	if err != nil {
+		return nil, err
+	}
+	return proposal.EvaluateWithContext(ctx)
+}
@@ -0,0 +1,398 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// MockTransport runs the chaincode in process on a shimtest.MockStub, one invocation
+// at a time. Like a peer, it discards the writes of evaluated and failed transactions
+// and records the history of every key, which the mock stub does not implement.
+//
+// Submitted transactions are grouped into blocks of SetBlockSize transactions. A
+// transaction that reads a key written by an earlier transaction of its block fails
+// with an MVCC read conflict, as it would have been endorsed against the state
+// before the block. Only point reads count; range and composite key queries are
+// not checked for phantoms.
+type MockTransport struct {
+	blockSize   int
+	blockTxs    int
+	blockWrites map[string]bool
+	cc          shim.Chaincode
+	clock       func() time.Time
+	history     map[string][]*queryresult.KeyModification
+	mutex       sync.Mutex
+	stub        *shimtest.MockStub
+	txSeq       int64
+}
+
+// txStub is the stub handed to the chaincode on each invocation: the shared
+// MockStub with the invocation's arguments, tracking what the transaction reads
+// and the values its writes replace.
+type txStub struct {
+	*shimtest.MockStub
+	args    [][]byte
+	history map[string][]*queryresult.KeyModification
+	keys    []string
+	prior   map[string][]byte // nil for keys that did not exist
+	reads   map[string]bool
+	writes  []*queryresult.KeyModification
+}
+
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+}
+
+// mockLedger is the file format of SaveLedger and LoadLedger.
+type mockLedger struct {
+	History map[string][]*queryresult.KeyModification `json:"history"`
+	State   map[string][]byte                         `json:"state"`
+	TxSeq   int64                                     `json:"txSeq"`
+}
+
+// NewMockTransport returns a Transport on an empty ledger for cc, typically
+// new(chaincode.SimpleChaincode). Call SetIdentity before invoking functions that
+// check the caller.
+func NewMockTransport(name string, cc shim.Chaincode) *MockTransport {
+	return &MockTransport{
+		blockSize:   1,
+		blockWrites: map[string]bool{},
+		cc:          cc,
+		clock:       time.Now,
+		history:     map[string][]*queryresult.KeyModification{},
+		stub:        shimtest.NewMockStub(name, cc),
+	}
+}
+
+// Stub returns the underlying mock stub, e.g. to inspect its state directly.
+func (t *MockTransport) Stub() *shimtest.MockStub {
+	return t.stub
+}
+
+// SetIdentity makes later invocations come from a freshly issued certificate with
+// commonName in the org mspID.
+func (t *MockTransport) SetIdentity(mspID string, commonName string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.New("Failed to generate key: " + err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return errors.New("Failed to create certificate: " + err.Error())
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+	})
+	if err != nil {
+		return errors.New("Failed to marshal identity: " + err.Error())
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.stub.Creator = creator
+	return nil
+}
+
+// SetClock makes transactions carry the time returned by now instead of the wall
+// clock, e.g. to replay a simulation deterministically.
+func (t *MockTransport) SetClock(now func() time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.clock = now
+}
+
+// SetBlockSize sets how many submitted transactions make up a block; the default
+// of 1 never conflicts. The current block is cut.
+func (t *MockTransport) SetBlockSize(size int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if size < 1 {
+		size = 1
+	}
+	t.blockSize = size
+	t.cutBlock()
+}
+
+// CutBlock ends the current block early, as the orderer does when its batch timeout
+// expires; the next submitted transaction starts a new block.
+func (t *MockTransport) CutBlock() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.cutBlock()
+}
+
+func (t *MockTransport) cutBlock() {
+	t.blockTxs = 0
+	t.blockWrites = map[string]bool{}
+}
+
+// SaveLedger writes the world state and key history as JSON, so a later LoadLedger
+// can continue from them.
+func (t *MockTransport) SaveLedger(w io.Writer) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(mockLedger{History: t.history, State: t.stub.State, TxSeq: t.txSeq})
+}
+
+// LoadLedger replaces the world state and key history with those saved by SaveLedger.
+func (t *MockTransport) LoadLedger(r io.Reader) error {
+	var ledger mockLedger
+	err := json.NewDecoder(r).Decode(&ledger)
+	if err != nil {
+		return errors.New("Failed to decode mock ledger: " + err.Error())
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.stub.State = map[string][]byte{}
+	t.stub.Keys.Init()
+	t.stub.MockTransactionStart("load-ledger")
+	defer t.stub.MockTransactionEnd("load-ledger")
+	for key, value := range ledger.State {
+		err = t.stub.PutState(key, value)
+		if err != nil {
+			return errors.New("Failed to load " + key + ": " + err.Error())
+		}
+	}
+	t.cutBlock()
+	t.history = ledger.History
+	if t.history == nil {
+		t.history = map[string][]*queryresult.KeyModification{}
+	}
+	t.txSeq = ledger.TxSeq
+	return nil
+}
+
+func (t *MockTransport) Submit(ctx context.Context, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	return t.invoke(ctx, function, args, transient, true)
+}
+
+func (t *MockTransport) Evaluate(ctx context.Context, function string, args []string) ([]byte, error) {
+	return t.invoke(ctx, function, args, nil, false)
+}
+
+func (t *MockTransport) invoke(ctx context.Context, function string, args []string, transient map[string][]byte, submit bool) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	invokeArgs := make([][]byte, 0, len(args)+1)
+	invokeArgs = append(invokeArgs, []byte(function))
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+
+	t.txSeq++
+	txID := "mock-tx-" + strconv.FormatInt(t.txSeq, 10)
+	t.stub.TransientMap = transient
+	t.stub.MockTransactionStart(txID)
+	t.stub.TxTimestamp = timestamppb.New(t.clock())
+	stub := &txStub{MockStub: t.stub, args: invokeArgs, history: t.history, prior: map[string][]byte{}, reads: map[string]bool{}}
+	response := t.cc.Invoke(stub)
+
+	var err error
+	if response.Status >= shim.ERRORTHRESHOLD {
+		if parsed, ok := errcode.Parse(response.Message); ok {
+			err = parsed
+		} else {
+			err = errcode.New(errcode.Unknown, response.Message)
+		}
+	} else if submit && t.conflicts(stub) {
+		err = &CommitError{Code: peer.TxValidationCode_MVCC_READ_CONFLICT, TransactionID: txID}
+	}
+
+	if err != nil || !submit {
+		stub.rollback()
+	} else {
+		t.commit(stub)
+	}
+	t.stub.MockTransactionEnd(txID)
+	t.stub.TransientMap = nil
+
+	// Endorsement failures never reach the orderer; conflicting transactions take their place in the block.
+	if submit && (err == nil || IsMVCCConflict(err)) {
+		t.blockTxs++
+		if t.blockTxs >= t.blockSize {
+			t.cutBlock()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response.Payload, nil
+}
+
+// conflicts reports whether the transaction read a key written earlier in its block.
+func (t *MockTransport) conflicts(stub *txStub) bool {
+	for key := range stub.reads {
+		if t.blockWrites[key] {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *MockTransport) commit(stub *txStub) {
+	for i, write := range stub.writes {
+		key := stub.keys[i]
+		t.history[key] = append(t.history[key], write)
+		if t.blockSize > 1 {
+			t.blockWrites[key] = true
+		}
+	}
+}
+
+func (stub *txStub) GetArgs() [][]byte {
+	return stub.args
+}
+
+func (stub *txStub) GetStringArgs() []string {
+	args := make([]string, 0, len(stub.args))
+	for _, arg := range stub.args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+func (stub *txStub) GetFunctionAndParameters() (string, []string) {
+	args := stub.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (stub *txStub) GetState(key string) ([]byte, error) {
+	stub.reads[key] = true
+	return stub.MockStub.GetState(key)
+}
+
+func (stub *txStub) PutState(key string, value []byte) error {
+	stub.remember(key)
+	err := stub.MockStub.PutState(key, value)
+	if err == nil {
+		stub.record(key, value, false)
+	}
+	return err
+}
+
+func (stub *txStub) DelState(key string) error {
+	stub.remember(key)
+	err := stub.MockStub.DelState(key)
+	if err == nil {
+		stub.record(key, nil, true)
+	}
+	return err
+}
+
+// SetEvent drops chaincode events: the mock stub's event channel is never read and
+// would block the chaincode once full.
+func (stub *txStub) SetEvent(name string, payload []byte) error {
+	return nil
+}
+
+func (stub *txStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	modifications := append([]*queryresult.KeyModification(nil), stub.history[key]...)
+	return &historyIterator{modifications: modifications}, nil
+}
+
+// remember keeps the value a key had before the transaction first wrote it.
+func (stub *txStub) remember(key string) {
+	if _, ok := stub.prior[key]; ok {
+		return
+	}
+	stub.prior[key] = stub.MockStub.State[key]
+}
+
+func (stub *txStub) record(key string, value []byte, isDelete bool) {
+	stub.keys = append(stub.keys, key)
+	stub.writes = append(stub.writes, &queryresult.KeyModification{
+		IsDelete:  isDelete,
+		Timestamp: stub.TxTimestamp,
+		TxId:      stub.TxID,
+		Value:     value,
+	})
+}
+
+// rollback restores every key the transaction wrote.
+func (stub *txStub) rollback() {
+	for key, value := range stub.prior {
+		if value == nil {
+			stub.MockStub.DelState(key)
+		} else {
+			stub.MockStub.PutState(key, value)
+		}
+	}
+}
+
+func (iterator *historyIterator) HasNext() bool {
+	return len(iterator.modifications) > 0
+}
+
+func (iterator *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if len(iterator.modifications) == 0 {
+		return nil, errors.New("No more key modifications")
+	}
+	modification := iterator.modifications[0]
+	iterator.modifications = iterator.modifications[1:]
+	return modification, nil
+}
+
+func (iterator *historyIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,401 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command marketctl lets operators drive the energy trading chaincode: register
+// users, sign contracts, place and cancel orders, process matches, record payments
+// and inspect any asset or its history.
+//
+//	marketctl [-profile file] [-output table|json|csv] [-dry-run] <command> [flags] [args]
+//
+// Connection settings come from a profile file (see Profile), named by -profile or
+// MARKETCTL_PROFILE. With -dry-run the commands run against an in-process mock
+// ledger instead of the network.
+package main
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	marketclient "github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/errcode"
+)
+
+// action runs a parsed command and returns its JSON result, or nil for no output.
+type action func(ctx context.Context, api *marketclient.Client) ([]byte, error)
+
+// command is one marketctl subcommand. setup declares its flags and returns the
+// action to run once flags and arguments are parsed.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	setup   func(flags *flag.FlagSet) func(args []string) (action, error)
+}
+
+// textFlag adapts an enum, which reads and writes its name, to a flag.
+type textFlag struct {
+	value interface {
+		encoding.TextUnmarshaler
+		fmt.Stringer
+	}
+}
+
+func (f textFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return f.value.String()
+}
+
+func (f textFlag) Set(text string) error {
+	return f.value.UnmarshalText([]byte(text))
+}
+
+var commands = []command{
+	{"user register", "-id N -category Prosumer|Consumer -source S [-location L -meter-id M]", "create or update a user profile", userRegister},
+	{"contract sign", "-user N [-terms VERSION]", "accept the platform terms for a user", contractSign},
+	{"order place", "-id N -slot S -action Buy|Sell -quantity Q -unit-cost C -user N", "register an order", orderPlace},
+	{"order cancel", "-id N", "withdraw an order before gate closure", orderCancel},
+	{"match process", "-id N -slot S -price P -buyer N -seller N -units U [-delivered D]", "create or update a bid match", matchProcess},
+	{"payment record", "-id ID -type T -amount A -user N -from F -to T", "record a payment", paymentRecord},
+	{"get", "KIND [ID]", "show any asset, e.g. get Order 4", assetGet},
+	{"history", "KIND [ID]", "show every committed write of an asset", assetHistory},
+	{"invoke", "FUNCTION [ARG...]", "submit any chaincode function", rawInvoke},
+	{"query", "FUNCTION [ARG...]", "evaluate any chaincode function", rawQuery},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes one marketctl invocation and returns its exit status.
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	global := flag.NewFlagSet("marketctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	profilePath := global.String("profile", os.Getenv("MARKETCTL_PROFILE"), "connection profile `file`")
+	output := global.String("output", formatTable, "output `format`: table, json or csv")
+	dryRun := global.Bool("dry-run", false, "run against an in-process mock ledger")
+	global.Usage = func() { usage(global) }
+	if global.Parse(args) != nil {
+		return 2
+	}
+
+	cmd, rest := findCommand(global.Args())
+	if cmd == nil {
+		usage(global)
+		return 2
+	}
+	flags := flag.NewFlagSet("marketctl "+cmd.name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: marketctl %s %s\n", cmd.name, cmd.usage)
+		flags.PrintDefaults()
+	}
+	prepare := cmd.setup(flags)
+	if flags.Parse(rest) != nil {
+		return 2
+	}
+	act, err := prepare(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		flags.Usage()
+		return 2
+	}
+
+	profile, err := loadProfile(*profilePath)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	api, closeConnection, err := connect(profile, *dryRun)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	result, err := act(context.Background(), api)
+	if closeErr := closeConnection(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+	if len(result) == 0 {
+		return 0
+	}
+	if !json.Valid(result) {
+		fmt.Fprintln(stdout, string(result))
+		return 0
+	}
+	err = render(stdout, *output, result)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+func usage(global *flag.FlagSet) {
+	w := global.Output()
+	fmt.Fprintln(w, "Usage: marketctl [flags] <command> [command flags] [args]")
+	fmt.Fprintln(w, "\nFlags:")
+	global.PrintDefaults()
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-15s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// findCommand matches the leading words of args against the command names.
+func findCommand(args []string) (*command, []string) {
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == commands[i].name {
+			return &commands[i], args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+// printError writes an error, with the code and field details of chaincode errors.
+func printError(w io.Writer, err error) {
+	var chaincodeErr *errcode.Error
+	if !errors.As(err, &chaincodeErr) {
+		fmt.Fprintln(w, "Error:", err.Error())
+		return
+	}
+	fmt.Fprintf(w, "Error [%s]: %s\n", chaincodeErr.Code, chaincodeErr.Message)
+	for _, detail := range chaincodeErr.Details {
+		if detail.Code != "" {
+			fmt.Fprintf(w, "  %s [%s]: %s\n", detail.Field, detail.Code, detail.Message)
+			continue
+		}
+		fmt.Fprintf(w, "  %s: %s\n", detail.Field, detail.Message)
+	}
+}
+
+func noArgs(args []string) error {
+	if len(args) != 0 {
+		return errors.New("unexpected arguments " + strings.Join(args, " "))
+	}
+	return nil
+}
+
+// kindAndID reads the KIND [ID] arguments of get and history.
+func kindAndID(args []string) (string, string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return "", "", errors.New("expecting KIND [ID]")
+	}
+	if len(args) == 1 {
+		return args[0], "", nil
+	}
+	return args[0], args[1], nil
+}
+
+// readBack returns the stored asset after a write, for the command output.
+func readBack(ctx context.Context, api *marketclient.Client, kind string, id string) ([]byte, error) {
+	asset, err := api.ReadAsset(ctx, kind, id)
+	return []byte(asset), err
+}
+
+/* -------------------------------------------------------------------------- */
+/*                                  Commands                                  */
+/* -------------------------------------------------------------------------- */
+
+func userRegister(flags *flag.FlagSet) func([]string) (action, error) {
+	var request chaincode.UserRequest
+	flags.Int64Var(&request.ID, "id", 0, "user ID")
+	flags.Var(textFlag{&request.Category}, "category", "Prosumer or Consumer")
+	flags.Var(textFlag{&request.Source}, "source", "energy source: Solar, Wind, DG Set or Battery")
+	location := flags.String("location", "", "location, kept in the org's private collection")
+	meterID := flags.String("meter-id", "", "meter ID, kept in the org's private collection")
+
+	return func(args []string) (action, error) {
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			var pii *chaincode.UserPII
+			if *location != "" || *meterID != "" {
+				pii = &chaincode.UserPII{Location: *location, MeterId: *meterID}
+			}
+			err := api.UpdateUserProfile(ctx, request, pii)
+			if err != nil {
+				return nil, err
+			}
+			return readBack(ctx, api, "User", strconv.FormatInt(request.ID, 10))
+		}, noArgs(args)
+	}
+}
+
+func contractSign(flags *flag.FlagSet) func([]string) (action, error) {
+	userID := flags.Int64("user", 0, "user ID")
+	terms := flags.String("terms", "", "terms version, the active terms when omitted")
+
+	return func(args []string) (action, error) {
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			err := api.SignPlatformContract(ctx, *userID, *terms)
+			if err != nil {
+				return nil, err
+			}
+			return readBack(ctx, api, "PlatformContract", strconv.FormatInt(*userID, 10))
+		}, noArgs(args)
+	}
+}
+
+func orderPlace(flags *flag.FlagSet) func([]string) (action, error) {
+	var request chaincode.OrderRequest
+	flags.Int64Var(&request.ID, "id", 0, "order ID")
+	flags.StringVar(&request.SlotID, "slot", "", "trading slot ID")
+	flags.Var(textFlag{&request.UserAction}, "action", "Buy or Sell")
+	flags.Int64Var(&request.TotalQuantity, "quantity", 0, "quantity in kWh")
+	flags.Float64Var(&request.UnitCost, "unit-cost", 0, "price per kWh")
+	flags.Int64Var(&request.UserID, "user", 0, "user ID")
+	flags.Float64Var(&request.OrderCost, "cost", 0, "order cost, quantity times unit cost when omitted")
+
+	return func(args []string) (action, error) {
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			if request.OrderCost == 0 {
+				request.OrderCost = request.UnitCost * float64(request.TotalQuantity)
+			}
+			err := api.RegisterOrder(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			return readBack(ctx, api, "Order", strconv.FormatInt(request.ID, 10))
+		}, noArgs(args)
+	}
+}
+
+func orderCancel(flags *flag.FlagSet) func([]string) (action, error) {
+	orderID := flags.Int64("id", 0, "order ID")
+
+	return func(args []string) (action, error) {
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			err := api.CancelOrder(ctx, *orderID)
+			if err != nil {
+				return nil, err
+			}
+			return readBack(ctx, api, "Order", strconv.FormatInt(*orderID, 10))
+		}, noArgs(args)
+	}
+}
+
+func matchProcess(flags *flag.FlagSet) func([]string) (action, error) {
+	request := chaincode.BidMatchRequest{BidStatus: chaincode.BidExecuted}
+	flags.Int64Var(&request.ID, "id", 0, "bid match ID")
+	flags.StringVar(&request.BidSlot, "slot", "", "trading slot ID")
+	flags.Var(textFlag{&request.BidStatus}, "status", "bid status")
+	flags.Int64Var(&request.BidUnitPrice, "price", 0, "matched price per kWh")
+	flags.Int64Var(&request.BuyerUserId, "buyer", 0, "buyer user ID")
+	flags.Int64Var(&request.SellerUserId, "seller", 0, "seller user ID")
+	flags.Float64Var(&request.OriginalBidUnits, "units", 0, "matched units")
+	flags.Float64Var(&request.DeliveredBidUnits, "delivered", 0, "delivered units")
+	flags.Int64Var(&request.TransactionBuyID, "buy-order", 0, "ID of the buy order it fills")
+	flags.Int64Var(&request.TransactionSellID, "sell-order", 0, "ID of the sell order it fills")
+
+	return func(args []string) (action, error) {
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			err := api.ProcessBidMatch(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			return readBack(ctx, api, "BidMatch", strconv.FormatInt(request.ID, 10))
+		}, noArgs(args)
+	}
+}
+
+func paymentRecord(flags *flag.FlagSet) func([]string) (action, error) {
+	var request chaincode.PaymentRequest
+	flags.StringVar(&request.ID, "id", "", "payment ID")
+	flags.Var(textFlag{&request.PaymentType}, "type", `payment type, e.g. "Buyer - Energy Purchased"`)
+	flags.Float64Var(&request.TotalAmount, "amount", 0, "total amount")
+	flags.Int64Var(&request.UserID, "user", 0, "user ID")
+	flags.StringVar(&request.DebitedFrom, "from", "", "debited account")
+	flags.StringVar(&request.CreditedTo, "to", "", "credited account")
+	flags.Float64Var(&request.TotalUnitCost, "unit-cost", 0, "total unit cost")
+	flags.Float64Var(&request.TokenAmount, "token", 0, "token amount")
+	flags.Float64Var(&request.BidRefundAmount, "bid-refund", 0, "bid refund amount")
+	flags.Float64Var(&request.PlatformFeeRefundAmount, "fee-refund", 0, "platform fee refund amount")
+	flags.Float64Var(&request.PenaltyFromSeller, "penalty", 0, "penalty charged to the seller")
+	flags.Int64Var(&request.BidMatchID, "bid-match", 0, "ID of the bid match it settles")
+
+	return func(args []string) (action, error) {
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			err := api.RecordPayment(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			return readBack(ctx, api, "Payment", request.ID)
+		}, noArgs(args)
+	}
+}
+
+func assetGet(flags *flag.FlagSet) func([]string) (action, error) {
+	return func(args []string) (action, error) {
+		kind, id, err := kindAndID(args)
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			return readBack(ctx, api, kind, id)
+		}, err
+	}
+}
+
+func assetHistory(flags *flag.FlagSet) func([]string) (action, error) {
+	return func(args []string) (action, error) {
+		kind, id, err := kindAndID(args)
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			history, err := api.ReadAssetHistory(ctx, kind, id)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(history)
+		}, err
+	}
+}
+
+func rawInvoke(flags *flag.FlagSet) func([]string) (action, error) {
+	return func(args []string) (action, error) {
+		if len(args) == 0 {
+			return nil, errors.New("expecting FUNCTION [ARG...]")
+		}
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			return api.Invoke(ctx, args[0], args[1:]...)
+		}, nil
+	}
+}
+
+func rawQuery(flags *flag.FlagSet) func([]string) (action, error) {
+	return func(args []string) (action, error) {
+		if len(args) == 0 {
+			return nil, errors.New("expecting FUNCTION [ARG...]")
+		}
+		return func(ctx context.Context, api *marketclient.Client) ([]byte, error) {
+			return api.Query(ctx, args[0], args[1:]...)
+		}, nil
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// marketctl runs one dry-run invocation against the ledger file of profile.
+func marketctl(t *testing.T, profile string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	status := run(append([]string{"-profile", profile, "-dry-run"}, args...), &stdout, &stderr)
+	return stdout.String(), stderr.String(), status
+}
+
+func TestDryRun(t *testing.T) {
+	t.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+	dir := t.TempDir()
+	profile := filepath.Join(dir, "profile.json")
+	require.NoError(t, ioutil.WriteFile(profile, []byte(`{"mspId":"Org1MSP","mock":{"commonName":"admin","ledgerFile":"ledger.json"}}`), 0600))
+
+	// The ledger file carries the state from one invocation to the next.
+	start := time.Now().Add(2 * time.Hour).Unix()
+	for _, args := range [][]string{
+		{"invoke", "CreateTradingSlot", "slot1", strconv.FormatInt(start, 10), strconv.FormatInt(start+900, 10), strconv.FormatInt(start-3600, 10)},
+		{"invoke", "SetMarketOracleConfig", "20", "Org1MSP:admin"},
+		{"invoke", "PublishMarketPrice", "slot1", "3.5", "test", strconv.FormatInt(time.Now().Unix(), 10)},
+		{"user", "register", "-id", "6", "-category", "Prosumer", "-source", "Solar", "-location", "Pune", "-meter-id", "M6"},
+		{"order", "place", "-id", "4", "-slot", "slot1", "-action", "Buy", "-quantity", "300", "-unit-cost", "3.5", "-user", "6"},
+		{"order", "cancel", "-id", "4"},
+	} {
+		_, stderr, status := marketctl(t, profile, args...)
+		require.Equal(t, 0, status, "%v failed: %s", args, stderr)
+	}
+	assert.FileExists(t, filepath.Join(dir, "ledger.json"))
+
+	t.Run("Get As JSON", func(t *testing.T) {
+		stdout, stderr, status := marketctl(t, profile, "-output", "json", "get", "Order", "4")
+		require.Equal(t, 0, status, stderr)
+		var order chaincode.Order
+		require.NoError(t, json.Unmarshal([]byte(stdout), &order))
+		assert.Equal(t, chaincode.BidCancelled, order.BidStatus)
+		assert.Equal(t, 1050.0, order.OrderCost)
+	})
+
+	t.Run("History As CSV", func(t *testing.T) {
+		stdout, stderr, status := marketctl(t, profile, "-output", "csv", "history", "Order", "4")
+		require.Equal(t, 0, status, stderr)
+		assert.Contains(t, stdout, "isDelete,timestamp,txId,value\n")
+		assert.Contains(t, stdout, "BidCreated")
+		assert.Contains(t, stdout, "BidCancelled")
+	})
+
+	t.Run("Chaincode Errors Show Their Code", func(t *testing.T) {
+		_, stderr, status := marketctl(t, profile, "get", "Order", "99")
+		assert.Equal(t, 1, status)
+		assert.Contains(t, stderr, "Error [NOT_FOUND]")
+
+		_, stderr, status = marketctl(t, profile, "order", "place", "-id", "0", "-slot", "slot1")
+		assert.Equal(t, 1, status)
+		assert.Contains(t, stderr, "id: must be positive")
+	})
+
+	t.Run("Usage Errors", func(t *testing.T) {
+		_, _, status := marketctl(t, profile, "order", "refund")
+		assert.Equal(t, 2, status)
+		_, _, status = marketctl(t, profile, "order", "place", "-action", "Hold")
+		assert.Equal(t, 2, status)
+		_, _, status = marketctl(t, profile, "get")
+		assert.Equal(t, 2, status)
+	})
+}
@@ -0,0 +1,158 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats of marketctl.
+const (
+	formatCSV   = "csv"
+	formatJSON  = "json"
+	formatTable = "table"
+)
+
+// render writes a JSON chaincode result in the given format. An array renders as
+// one row per element; an object as a single row, or as field/value lines in a table.
+// Nested values are written as compact JSON.
+func render(w io.Writer, format string, result []byte) error {
+	if format == formatJSON {
+		var indented bytes.Buffer
+		err := json.Indent(&indented, result, "", "  ")
+		if err != nil {
+			return errors.New("Failed to format result: " + err.Error())
+		}
+		indented.WriteByte('\n')
+		_, err = indented.WriteTo(w)
+		return err
+	}
+	if format != formatTable && format != formatCSV {
+		return errors.New("Unknown output format " + format + ". Expecting table, json or csv.")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return errors.New("Failed to decode result: " + err.Error())
+	}
+
+	var records []map[string]interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if format == formatTable {
+			return renderFields(w, v)
+		}
+		records = []map[string]interface{}{v}
+	case []interface{}:
+		for _, element := range v {
+			record, ok := element.(map[string]interface{})
+			if !ok {
+				record = map[string]interface{}{"value": element}
+			}
+			records = append(records, record)
+		}
+	default:
+		records = []map[string]interface{}{{"value": v}}
+	}
+
+	columns := recordColumns(records)
+	rows := make([][]string, 0, len(records))
+	for _, record := range records {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = cell(record[column])
+		}
+		rows = append(rows, row)
+	}
+
+	if format == formatCSV {
+		writer := csv.NewWriter(w)
+		writer.Write(columns)
+		writer.WriteAll(rows)
+		return writer.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(headings(columns), "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	return table.Flush()
+}
+
+// renderFields writes one object as a two-column table of its fields.
+func renderFields(w io.Writer, record map[string]interface{}) error {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "FIELD\tVALUE")
+	for _, column := range recordColumns([]map[string]interface{}{record}) {
+		fmt.Fprintln(table, column+"\t"+cell(record[column]))
+	}
+	return table.Flush()
+}
+
+// recordColumns is the sorted union of the records' fields.
+func recordColumns(records []map[string]interface{}) []string {
+	seen := map[string]bool{}
+	columns := []string{}
+	for _, record := range records {
+		for column := range record {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func headings(columns []string) []string {
+	headings := make([]string, len(columns))
+	for i, column := range columns {
+		headings[i] = strings.ToUpper(column)
+	}
+	return headings
+}
+
+func cell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		valueAsBytes, _ := json.Marshal(v)
+		return string(valueAsBytes)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	result := []byte(`[{"id":4,"slotId":"slot1","unitCost":3.5,"fills":[1,2]},{"id":5,"slotId":"slot2","unitCost":12345678.25}]`)
+
+	t.Run("Table", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, render(&out, formatTable, result))
+		assert.Equal(t, "FILLS  ID  SLOTID  UNITCOST\n[1,2]  4   slot1   3.5\n       5   slot2   12345678.25\n", out.String())
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, render(&out, formatCSV, result))
+		assert.Equal(t, "fills,id,slotId,unitCost\n\"[1,2]\",4,slot1,3.5\n,5,slot2,12345678.25\n", out.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, render(&out, formatJSON, []byte(`{"id":4}`)))
+		assert.Equal(t, "{\n  \"id\": 4\n}\n", out.String())
+	})
+
+	t.Run("Single Object Table", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, render(&out, formatTable, []byte(`{"id":4,"slotId":"slot1"}`)))
+		assert.Equal(t, "FIELD   VALUE\nid      4\nslotId  slot1\n", out.String())
+	})
+
+	t.Run("Unknown Format", func(t *testing.T) {
+		assert.Error(t, render(&bytes.Buffer{}, "xml", result))
+	})
+}
@@ -0,0 +1,165 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	marketclient "github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// Profile holds the connection settings of marketctl, read from a JSON file:
+//
+//	{
+//	  "channel": "mychannel",
+//	  "chaincode": "basic",
+//	  "mspId": "Org1MSP",
+//	  "peerEndpoint": "localhost:7051",
+//	  "peerHostAlias": "peer0.org1.example.com",
+//	  "tlsCertPath": "organizations/peerOrganizations/org1.example.com/peers/peer0.org1.example.com/tls/ca.crt",
+//	  "certPath": "organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp/signcerts/cert.pem",
+//	  "keyPath": "organizations/peerOrganizations/org1.example.com/users/User1@org1.example.com/msp/keystore",
+//	  "mock": {"commonName": "admin", "ledgerFile": "marketctl-ledger.json"}
+//	}
+//
+// Relative paths are resolved against the directory of the profile. keyPath may
+// name a keystore directory, whose first file is used. The mock settings apply
+// to dry runs only.
+type Profile struct {
+	marketclient.GatewayProfile
+	Chaincode string      `json:"chaincode"`
+	Channel   string      `json:"channel"`
+	Mock      MockProfile `json:"mock"`
+}
+
+// MockProfile configures the in-process ledger of dry runs. Without a ledgerFile
+// every dry run starts from an empty ledger; with one, the ledger is loaded from
+// and saved back to that file.
+type MockProfile struct {
+	CommonName string `json:"commonName"`
+	LedgerFile string `json:"ledgerFile"`
+}
+
+func defaultProfile() Profile {
+	return Profile{
+		GatewayProfile: marketclient.GatewayProfile{
+			MspID:         "Org1MSP",
+			PeerEndpoint:  "localhost:7051",
+			PeerHostAlias: "peer0.org1.example.com",
+		},
+		Chaincode: "basic",
+		Channel:   "mychannel",
+		Mock:      MockProfile{CommonName: "admin"},
+	}
+}
+
+// loadProfile reads a profile over the defaults. An empty path returns the defaults.
+func loadProfile(path string) (Profile, error) {
+	profile := defaultProfile()
+	if path == "" {
+		return profile, nil
+	}
+	profileAsBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return profile, errors.New("Failed to read profile: " + err.Error())
+	}
+	err = json.Unmarshal(profileAsBytes, &profile)
+	if err != nil {
+		return profile, errors.New("Failed to parse profile " + path + ": " + err.Error())
+	}
+
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&profile.CertPath, &profile.KeyPath, &profile.TLSCertPath, &profile.Mock.LedgerFile} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	return profile, nil
+}
+
+// connect returns a client for the profile's network, or for an in-process mock
+// ledger when dryRun is set. close releases the connection and, for a dry run
+// with a ledger file, saves the ledger.
+func connect(profile Profile, dryRun bool) (api *marketclient.Client, close func() error, err error) {
+	if dryRun {
+		return connectMock(profile)
+	}
+	return connectGateway(profile)
+}
+
+func connectMock(profile Profile) (*marketclient.Client, func() error, error) {
+	// Chaincode logs would interleave with the command output.
+	chaincode.SetLogOutput(io.Discard)
+	// The user PII collection is only written by peers of the caller's org.
+	if os.Getenv("CORE_PEER_LOCALMSPID") == "" {
+		os.Setenv("CORE_PEER_LOCALMSPID", profile.MspID)
+	}
+
+	transport := marketclient.NewMockTransport(profile.Chaincode, new(chaincode.SimpleChaincode))
+	err := transport.SetIdentity(profile.MspID, profile.Mock.CommonName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ledgerFile := profile.Mock.LedgerFile
+	if ledgerFile != "" {
+		file, err := os.Open(ledgerFile)
+		if err == nil {
+			err = transport.LoadLedger(file)
+			file.Close()
+		} else if os.IsNotExist(err) {
+			err = nil
+		}
+		if err != nil {
+			return nil, nil, errors.New("Failed to load mock ledger " + ledgerFile + ": " + err.Error())
+		}
+	}
+
+	close := func() error {
+		if ledgerFile == "" {
+			return nil
+		}
+		file, err := os.Create(ledgerFile)
+		if err != nil {
+			return errors.New("Failed to save mock ledger: " + err.Error())
+		}
+		err = transport.SaveLedger(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+	return marketclient.New(transport), close, nil
+}
+
+func connectGateway(profile Profile) (*marketclient.Client, func() error, error) {
+	gateway, close, err := marketclient.Dial(profile.GatewayProfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	contract := gateway.GetNetwork(profile.Channel).GetContract(profile.Chaincode)
+	return marketclient.New(marketclient.NewGatewayTransport(contract)), close, nil
+}
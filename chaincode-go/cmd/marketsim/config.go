@@ -0,0 +1,106 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"time"
+)
+
+// Config describes one simulation run. Every random draw of the run comes from
+// Seed, so the same Config always produces the same report. Prices are in minor
+// currency units per kWh, as BidMatch prices are whole numbers.
+type Config struct {
+	BasePrice          float64   `json:"basePrice"`          // reference price around which the oracle publishes
+	BlockSize          int       `json:"blockSize"`          // transactions per block, for MVCC conflicts
+	Consumers          int       `json:"consumers"`          // users who only draw load
+	FeePct             float64   `json:"feePct"`             // platform fee on the buyer's purchases
+	GateClosureLeadSec int64     `json:"gateClosureLeadSec"` // how long before its start a slot stops taking orders
+	MaxRetries         int       `json:"maxRetries"`         // resubmissions of a transaction that hit an MVCC conflict
+	PenaltyPct         float64   `json:"penaltyPct"`         // charged to sellers on undelivered value
+	PriceBandPct       float64   `json:"priceBandPct"`       // how far order prices may stray from the reference price
+	Prosumers          int       `json:"prosumers"`          // users with rooftop solar or small wind
+	Seed               int64     `json:"seed"`
+	ShortfallPct       float64   `json:"shortfallPct"` // chance that a seller under-delivers a match
+	SlotMinutes        int64     `json:"slotMinutes"`
+	Slots              int       `json:"slots"`
+	Start              time.Time `json:"start"` // start of the first slot
+}
+
+func defaultConfig() Config {
+	return Config{
+		BasePrice:          500,
+		BlockSize:          10,
+		Consumers:          25,
+		FeePct:             2,
+		GateClosureLeadSec: 900,
+		MaxRetries:         5,
+		PenaltyPct:         10,
+		PriceBandPct:       20,
+		Prosumers:          35,
+		Seed:               1,
+		ShortfallPct:       10,
+		SlotMinutes:        60,
+		Slots:              24,
+		Start:              time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// loadConfig reads a JSON config over the defaults. An empty path returns the defaults.
+func loadConfig(path string) (Config, error) {
+	config := defaultConfig()
+	if path == "" {
+		return config, nil
+	}
+	configAsBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, errors.New("Failed to read config: " + err.Error())
+	}
+	err = json.Unmarshal(configAsBytes, &config)
+	if err != nil {
+		return config, errors.New("Failed to parse config " + path + ": " + err.Error())
+	}
+	return config, nil
+}
+
+func (config Config) validate() error {
+	if config.Prosumers < 1 || config.Consumers < 1 {
+		return errors.New("the population needs at least one prosumer and one consumer")
+	}
+	if config.Slots < 1 || config.SlotMinutes < 1 {
+		return errors.New("slots and slotMinutes must be positive")
+	}
+	if config.BasePrice <= 0 {
+		return errors.New("basePrice must be positive")
+	}
+	if config.PriceBandPct <= 0 || config.PriceBandPct >= 100 {
+		return errors.New("priceBandPct must be between 0 and 100")
+	}
+	if config.GateClosureLeadSec < 0 || config.MaxRetries < 0 {
+		return errors.New("gateClosureLeadSec and maxRetries must not be negative")
+	}
+	return nil
+}
+
+func (config Config) slotDuration() time.Duration {
+	return time.Duration(config.SlotMinutes) * time.Minute
+}
@@ -0,0 +1,92 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// match pairs part of a buy order with part of a sell order.
+type match struct {
+	BuyOrder  chaincode.OrderRequest
+	SellOrder chaincode.OrderRequest
+	Units     int64
+}
+
+// clearMarket runs a uniform-price double auction: buy orders from the highest bid,
+// sell orders from the lowest ask, matched while the bid covers the ask. Every match
+// trades at the midpoint of the last matched bid and ask, rounded to a whole price.
+// The orders are not modified.
+func clearMarket(orders []chaincode.OrderRequest) ([]match, int64) {
+	var buys, sells []chaincode.OrderRequest
+	for _, order := range orders {
+		if order.UserAction == chaincode.Buy {
+			buys = append(buys, order)
+		} else {
+			sells = append(sells, order)
+		}
+	}
+	sort.SliceStable(buys, func(i, j int) bool {
+		if buys[i].UnitCost != buys[j].UnitCost {
+			return buys[i].UnitCost > buys[j].UnitCost
+		}
+		return buys[i].ID < buys[j].ID
+	})
+	sort.SliceStable(sells, func(i, j int) bool {
+		if sells[i].UnitCost != sells[j].UnitCost {
+			return sells[i].UnitCost < sells[j].UnitCost
+		}
+		return sells[i].ID < sells[j].ID
+	})
+
+	var matches []match
+	var lastBid, lastAsk float64
+	b, s := 0, 0
+	buyLeft, sellLeft := int64(0), int64(0)
+	for b < len(buys) && s < len(sells) && buys[b].UnitCost >= sells[s].UnitCost {
+		if buyLeft == 0 {
+			buyLeft = buys[b].TotalQuantity
+		}
+		if sellLeft == 0 {
+			sellLeft = sells[s].TotalQuantity
+		}
+		units := buyLeft
+		if sellLeft < units {
+			units = sellLeft
+		}
+		matches = append(matches, match{BuyOrder: buys[b], SellOrder: sells[s], Units: units})
+		lastBid, lastAsk = buys[b].UnitCost, sells[s].UnitCost
+		buyLeft -= units
+		sellLeft -= units
+		if buyLeft == 0 {
+			b++
+		}
+		if sellLeft == 0 {
+			s++
+		}
+	}
+	if len(matches) == 0 {
+		return nil, 0
+	}
+	return matches, int64(math.Round((lastBid + lastAsk) / 2))
+}
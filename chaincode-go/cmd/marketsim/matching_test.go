@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+func TestClearMarket(t *testing.T) {
+	order := func(id int64, action chaincode.Action, unitCost float64, quantity int64) chaincode.OrderRequest {
+		return chaincode.OrderRequest{ID: id, TotalQuantity: quantity, UnitCost: unitCost, UserAction: action, UserID: id}
+	}
+
+	// Test Case 1: Highest bids meet lowest asks until the prices no longer cross
+	t.Run("Crossing Book", func(t *testing.T) {
+		matches, price := clearMarket([]chaincode.OrderRequest{
+			order(1, chaincode.Buy, 520, 5),
+			order(2, chaincode.Sell, 470, 3),
+			order(3, chaincode.Buy, 500, 4),
+			order(4, chaincode.Sell, 490, 4),
+			order(5, chaincode.Sell, 530, 9),
+			order(6, chaincode.Buy, 480, 2),
+		})
+		var pairs [][3]int64
+		for _, m := range matches {
+			pairs = append(pairs, [3]int64{m.BuyOrder.ID, m.SellOrder.ID, m.Units})
+		}
+		assert.Equal(t, [][3]int64{{1, 2, 3}, {1, 4, 2}, {3, 4, 2}}, pairs)
+		assert.Equal(t, int64(495), price, "Clearing price is the midpoint of the last matched pair")
+	})
+
+	// Test Case 2: Equal prices are matched in order ID order
+	t.Run("Ties", func(t *testing.T) {
+		matches, _ := clearMarket([]chaincode.OrderRequest{
+			order(9, chaincode.Sell, 500, 1),
+			order(7, chaincode.Sell, 500, 1),
+			order(8, chaincode.Buy, 500, 1),
+		})
+		if assert.Len(t, matches, 1) {
+			assert.Equal(t, int64(7), matches[0].SellOrder.ID)
+		}
+	})
+
+	// Test Case 3: A book that does not cross clears nothing
+	t.Run("No Cross", func(t *testing.T) {
+		matches, price := clearMarket([]chaincode.OrderRequest{
+			order(1, chaincode.Buy, 450, 5),
+			order(2, chaincode.Sell, 460, 5),
+		})
+		assert.Empty(t, matches)
+		assert.Equal(t, int64(0), price)
+	})
+}
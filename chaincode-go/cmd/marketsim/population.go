@@ -0,0 +1,119 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// participant is one simulated user. Prosumers generate from their own source and
+// sell their surplus; everybody buys what they cannot cover.
+type participant struct {
+	BaseLoadKW  float64 // mean draw, shaped by the daily load curve
+	Category    chaincode.UserCategory
+	CapacityKW  float64 // peak generation, zero for consumers
+	ID          int64
+	PriceMargin float64 // how far from the reference price the participant usually bids, in percent
+	Source      chaincode.EnergySource
+}
+
+// weather is the generation conditions shared by every participant in one slot.
+type weather struct {
+	SolarFactor float64 // share of clear-sky irradiance reaching the panels
+	WindFactor  float64 // share of rated wind output
+}
+
+func newPopulation(config Config, rng *rand.Rand) []participant {
+	population := make([]participant, 0, config.Prosumers+config.Consumers)
+	for i := 0; i < config.Prosumers; i++ {
+		p := participant{
+			BaseLoadKW:  0.5 + 1.5*rng.Float64(),
+			Category:    chaincode.Prosumer,
+			CapacityKW:  3 + 7*rng.Float64(),
+			ID:          int64(len(population) + 1),
+			PriceMargin: config.PriceBandPct / 2 * rng.Float64(),
+			Source:      chaincode.Solar,
+		}
+		// One in five prosumers runs a small wind turbine instead of panels.
+		if rng.Intn(5) == 0 {
+			p.Source = chaincode.Wind
+			p.CapacityKW = 2 + 4*rng.Float64()
+		}
+		population = append(population, p)
+	}
+	for i := 0; i < config.Consumers; i++ {
+		population = append(population, participant{
+			BaseLoadKW:  1 + 3*rng.Float64(),
+			Category:    chaincode.Consumer,
+			ID:          int64(len(population) + 1),
+			PriceMargin: config.PriceBandPct / 2 * rng.Float64(),
+			Source:      chaincode.DGSet,
+		})
+	}
+	return population
+}
+
+func newWeather(rng *rand.Rand) weather {
+	return weather{SolarFactor: 0.4 + 0.6*rng.Float64(), WindFactor: 0.1 + 0.7*rng.Float64()}
+}
+
+// solarShape is the clear-sky output at an hour of the day, 0 at night and 1 at noon.
+func solarShape(hour float64) float64 {
+	if hour < 6 || hour > 18 {
+		return 0
+	}
+	return math.Sin(math.Pi * (hour - 6) / 12)
+}
+
+// loadShape is the daily load curve, with a morning and a larger evening peak.
+func loadShape(hour float64) float64 {
+	morning := math.Exp(-math.Pow(hour-8, 2) / 4)
+	evening := math.Exp(-math.Pow(hour-20, 2) / 6)
+	return 0.6 + 0.5*morning + 0.8*evening
+}
+
+// netEnergy is what a participant has to sell (positive) or buy (negative) in a slot, in kWh.
+func (p participant) netEnergy(hour float64, hours float64, conditions weather, rng *rand.Rand) float64 {
+	var generation float64
+	switch p.Source {
+	case chaincode.Solar:
+		generation = p.CapacityKW * solarShape(hour) * conditions.SolarFactor
+	case chaincode.Wind:
+		generation = p.CapacityKW * conditions.WindFactor
+	}
+	if p.Category == chaincode.Consumer {
+		generation = 0
+	}
+	load := p.BaseLoadKW * loadShape(hour) * (0.9 + 0.2*rng.Float64())
+	return (generation - load) * hours
+}
+
+func (p participant) userRequest() chaincode.UserRequest {
+	return chaincode.UserRequest{Category: p.Category, ID: p.ID, Source: p.Source}
+}
+
+func (p participant) pii() *chaincode.UserPII {
+	id := strconv.FormatInt(p.ID, 10)
+	return &chaincode.UserPII{Location: "Sim-" + id, MeterId: "SIM-METER-" + id}
+}
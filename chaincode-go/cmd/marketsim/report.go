@@ -0,0 +1,195 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// Output formats of marketsim.
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+)
+
+// Report is the outcome of one run: the config it ran with, the KPIs of every
+// slot in the order the slots settled, and the totals over the run.
+type Report struct {
+	Config Config       `json:"config"`
+	Slots  []SlotReport `json:"slots"`
+	Totals Totals       `json:"totals"`
+}
+
+// SlotReport holds the KPIs of one slot. Volumes are in kWh and amounts in the
+// currency of the prices. Fill rates are the share of the ordered volume matched.
+type SlotReport struct {
+	Abandoned       int64     `json:"abandoned"` // transactions given up after MaxRetries conflicts
+	BuyFillRate     float64   `json:"buyFillRate"`
+	BuyOrders       int64     `json:"buyOrders"`
+	ClearingPrice   float64   `json:"clearingPrice"`
+	Conflicts       int64     `json:"conflicts"`
+	DeliveredVolume float64   `json:"deliveredVolume"`
+	Fees            float64   `json:"fees"`
+	Matches         int64     `json:"matches"`
+	Penalties       float64   `json:"penalties"`
+	ReferencePrice  float64   `json:"referencePrice"`
+	Retries         int64     `json:"retries"`
+	SellFillRate    float64   `json:"sellFillRate"`
+	SellOrders      int64     `json:"sellOrders"`
+	SlotID          string    `json:"slotId"`
+	Start           time.Time `json:"start"`
+	TradedVolume    float64   `json:"tradedVolume"`
+	Value           float64   `json:"value"` // delivered value paid by buyers, before fees
+
+	unmatchedBuyVolume  float64
+	unmatchedSellVolume float64
+}
+
+// Totals sums the slot KPIs. ClearingPrice is weighted by traded volume and the
+// fill rates are over the volume of the whole run.
+type Totals struct {
+	Abandoned       int64   `json:"abandoned"`
+	BuyFillRate     float64 `json:"buyFillRate"`
+	ClearingPrice   float64 `json:"clearingPrice"`
+	Conflicts       int64   `json:"conflicts"`
+	DeliveredVolume float64 `json:"deliveredVolume"`
+	Fees            float64 `json:"fees"`
+	Matches         int64   `json:"matches"`
+	Orders          int64   `json:"orders"`
+	Penalties       float64 `json:"penalties"`
+	Retries         int64   `json:"retries"`
+	SellFillRate    float64 `json:"sellFillRate"`
+	TradedVolume    float64 `json:"tradedVolume"`
+	Value           float64 `json:"value"`
+}
+
+// record takes the slot's market KPIs from the chaincode's own statistics and settlement.
+func (r *SlotReport) record(statistics chaincode.SlotStatistics, settlement chaincode.SlotSettlement) {
+	r.BuyFillRate = fillRate(statistics.TradedVolume, statistics.UnmatchedBuyVolume)
+	r.ClearingPrice = statistics.ClearingPrice
+	r.DeliveredVolume = statistics.DeliveredVolume
+	r.Fees = settlement.TotalFees
+	r.Matches = statistics.Matches
+	r.Penalties = settlement.TotalPenalties
+	r.SellFillRate = fillRate(statistics.TradedVolume, statistics.UnmatchedSellVolume)
+	r.TradedVolume = statistics.TradedVolume
+	r.Value = settlement.TotalValue
+	r.unmatchedBuyVolume = statistics.UnmatchedBuyVolume
+	r.unmatchedSellVolume = statistics.UnmatchedSellVolume
+}
+
+func (r *Report) summarize() {
+	var totals Totals
+	var unmatchedBuy, unmatchedSell, tradedValue float64
+	for _, slot := range r.Slots {
+		totals.Abandoned += slot.Abandoned
+		totals.Conflicts += slot.Conflicts
+		totals.DeliveredVolume += slot.DeliveredVolume
+		totals.Fees += slot.Fees
+		totals.Matches += slot.Matches
+		totals.Orders += slot.BuyOrders + slot.SellOrders
+		totals.Penalties += slot.Penalties
+		totals.Retries += slot.Retries
+		totals.TradedVolume += slot.TradedVolume
+		totals.Value += slot.Value
+		tradedValue += slot.ClearingPrice * slot.TradedVolume
+		unmatchedBuy += slot.unmatchedBuyVolume
+		unmatchedSell += slot.unmatchedSellVolume
+	}
+	if totals.TradedVolume > 0 {
+		totals.ClearingPrice = round4(tradedValue / totals.TradedVolume)
+	}
+	totals.BuyFillRate = fillRate(totals.TradedVolume, unmatchedBuy)
+	totals.SellFillRate = fillRate(totals.TradedVolume, unmatchedSell)
+	totals.Fees = roundCents(totals.Fees)
+	totals.Penalties = roundCents(totals.Penalties)
+	totals.Value = roundCents(totals.Value)
+	r.Totals = totals
+}
+
+// render writes the report as indented JSON, or as a table of slots followed by the totals.
+func (r *Report) render(w io.Writer, format string) error {
+	switch format {
+	case formatJSON:
+		reportAsBytes, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return errors.New("Failed to format report: " + err.Error())
+		}
+		_, err = w.Write(append(reportAsBytes, '\n'))
+		return err
+	case formatTable:
+	default:
+		return errors.New("Unknown output format " + format + ". Expecting table or json.")
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "SLOT\tSTART\tREF\tCLEARING\tBUYS\tSELLS\tMATCHES\tTRADED\tDELIVERED\tBUY FILL\tSELL FILL\tVALUE\tFEES\tPENALTIES\tCONFLICTS\tRETRIES\tABANDONED\t")
+	for _, slot := range r.Slots {
+		fmt.Fprintf(table, "%s\t%s\t%.0f\t%.2f\t%d\t%d\t%d\t%.0f\t%.0f\t%.1f%%\t%.1f%%\t%.2f\t%.2f\t%.2f\t%d\t%d\t%d\t\n",
+			slot.SlotID, slot.Start.UTC().Format("01-02 15:04"), slot.ReferencePrice, slot.ClearingPrice,
+			slot.BuyOrders, slot.SellOrders, slot.Matches, slot.TradedVolume, slot.DeliveredVolume,
+			slot.BuyFillRate*100, slot.SellFillRate*100, slot.Value, slot.Fees, slot.Penalties,
+			slot.Conflicts, slot.Retries, slot.Abandoned)
+	}
+	err := table.Flush()
+	if err != nil {
+		return err
+	}
+
+	t := r.Totals
+	fmt.Fprintln(w)
+	fields := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(fields, "Seed\t%d\n", r.Config.Seed)
+	fmt.Fprintf(fields, "Slots\t%d\n", len(r.Slots))
+	fmt.Fprintf(fields, "Orders\t%d\n", t.Orders)
+	fmt.Fprintf(fields, "Matches\t%d\n", t.Matches)
+	fmt.Fprintf(fields, "Clearing price\t%.2f\n", t.ClearingPrice)
+	fmt.Fprintf(fields, "Traded volume\t%.0f kWh\n", t.TradedVolume)
+	fmt.Fprintf(fields, "Delivered volume\t%.0f kWh\n", t.DeliveredVolume)
+	fmt.Fprintf(fields, "Buy fill rate\t%.1f%%\n", t.BuyFillRate*100)
+	fmt.Fprintf(fields, "Sell fill rate\t%.1f%%\n", t.SellFillRate*100)
+	fmt.Fprintf(fields, "Traded value\t%.2f\n", t.Value)
+	fmt.Fprintf(fields, "Platform revenue\t%.2f fees, %.2f penalties\n", t.Fees, t.Penalties)
+	fmt.Fprintf(fields, "MVCC conflicts\t%d (%d retries, %d abandoned)\n", t.Conflicts, t.Retries, t.Abandoned)
+	return fields.Flush()
+}
+
+func fillRate(traded float64, unmatched float64) float64 {
+	if traded+unmatched == 0 {
+		return 0
+	}
+	return round4(traded / (traded + unmatched))
+}
+
+func round4(value float64) float64 {
+	return math.Round(value*1e4) / 1e4
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,374 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	marketclient "github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// The simulator acts as a single operator identity that is platform admin, market
+// oracle and order gateway for every participant.
+const (
+	operatorMSP        = "Org1MSP"
+	operatorCommonName = "admin"
+	// orderWindow is how long before gate closure the oracle publishes and participants bid.
+	orderWindow = 10 * time.Minute
+)
+
+// Phases of a slot, in the order they run.
+const (
+	phaseOpen = iota
+	phaseClose
+	phaseSettle
+)
+
+// event is one phase of one slot, run at a point of simulated time.
+type event struct {
+	At    time.Time
+	Phase int
+	Slot  int
+}
+
+// slotRun is the simulator's view of a slot while it moves through its phases.
+type slotRun struct {
+	End     time.Time
+	ID      string
+	Matches []chaincode.BidMatchRequest // matches the chaincode accepted
+	Orders  []chaincode.OrderRequest    // orders the chaincode accepted
+	Report  *SlotReport
+	Start   time.Time
+}
+
+type simulator struct {
+	api         *marketclient.Client
+	config      Config
+	now         time.Time // time of the event being run
+	nextMatchID int64
+	nextOrderID int64
+	population  []participant
+	report      *Report
+	rng         *rand.Rand
+	slots       []*slotRun
+	tick        time.Duration
+	transport   *marketclient.MockTransport
+}
+
+// simulate runs a whole market day, or however many slots config asks for, and
+// reports its KPIs. Only the seed decides the outcome: transaction timestamps
+// come from the simulated clock and the population, weather, bids and deliveries
+// from a generator seeded with config.Seed.
+func simulate(ctx context.Context, config Config) (*Report, error) {
+	err := config.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Chaincode logs would drown the report.
+	chaincode.SetLogOutput(io.Discard)
+	// The user PII collection is only written by peers of the caller's org.
+	if os.Getenv("CORE_PEER_LOCALMSPID") == "" {
+		os.Setenv("CORE_PEER_LOCALMSPID", operatorMSP)
+	}
+
+	s := &simulator{
+		config:    config,
+		report:    &Report{Config: config},
+		rng:       rand.New(rand.NewSource(config.Seed)),
+		transport: marketclient.NewMockTransport("marketsim", new(chaincode.SimpleChaincode)),
+	}
+	err = s.transport.SetIdentity(operatorMSP, operatorCommonName)
+	if err != nil {
+		return nil, err
+	}
+	s.transport.SetClock(func() time.Time {
+		s.tick += time.Millisecond
+		return s.now.Add(s.tick)
+	})
+	s.api = marketclient.New(s.transport)
+	s.population = newPopulation(config, s.rng)
+
+	// Setup waits for each transaction to commit; trading fills whole blocks.
+	events, err := s.setup(ctx)
+	if err != nil {
+		return nil, errors.New("Failed to set up the market: " + err.Error())
+	}
+	s.transport.SetBlockSize(config.BlockSize)
+	for _, e := range events {
+		s.now, s.tick = e.At, 0
+		slot := s.slots[e.Slot]
+		switch e.Phase {
+		case phaseOpen:
+			err = s.open(ctx, slot)
+		case phaseClose:
+			err = s.close(ctx, slot)
+		case phaseSettle:
+			err = s.settle(ctx, slot)
+		}
+		if err != nil {
+			return nil, errors.New("Slot " + slot.ID + ": " + err.Error())
+		}
+	}
+
+	s.report.summarize()
+	return s.report, nil
+}
+
+// setup configures the market, registers the population and creates the slots,
+// and returns the phases of every slot in the order they happen.
+func (s *simulator) setup(ctx context.Context) ([]event, error) {
+	lead := time.Duration(s.config.GateClosureLeadSec) * time.Second
+	s.now = s.config.Start.Add(-lead - orderWindow - time.Hour)
+
+	err := s.api.SetMarketOracleConfig(ctx, s.config.PriceBandPct, operatorMSP+":"+operatorCommonName)
+	if err != nil {
+		return nil, err
+	}
+	err = s.api.SetSettlementConfig(ctx, false, s.config.PenaltyPct)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.api.ProposeFeeSchedule(ctx, chaincode.FeeScheduleRequest{PercentFee: s.config.FeePct})
+	if err != nil {
+		return nil, err
+	}
+	err = s.api.ApproveFeeSchedule(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range s.population {
+		err = s.api.UpdateUserProfile(ctx, p.userRequest(), p.pii())
+		if err != nil {
+			return nil, errors.New("Failed to register user " + strconv.FormatInt(p.ID, 10) + ": " + err.Error())
+		}
+	}
+	slotIDs, err := s.api.GenerateTradingSlots(ctx, s.config.Start.Unix(), int64(s.config.slotDuration()/time.Second), int64(s.config.Slots), s.config.GateClosureLeadSec)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []event
+	for i, slotID := range slotIDs {
+		start := s.config.Start.Add(time.Duration(i) * s.config.slotDuration())
+		slot := &slotRun{End: start.Add(s.config.slotDuration()), ID: slotID, Start: start}
+		slot.Report = &SlotReport{SlotID: slotID, Start: start}
+		s.slots = append(s.slots, slot)
+		gateClosure := start.Add(-lead)
+		events = append(events,
+			event{At: gateClosure.Add(-orderWindow), Phase: phaseOpen, Slot: i},
+			event{At: gateClosure.Add(time.Second), Phase: phaseClose, Slot: i},
+			event{At: slot.End.Add(time.Second), Phase: phaseSettle, Slot: i},
+		)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	return events, nil
+}
+
+// open publishes the slot's reference price and places every participant's order.
+func (s *simulator) open(ctx context.Context, slot *slotRun) error {
+	hour := float64(slot.Start.Hour()) + float64(slot.Start.Minute())/60
+	hours := s.config.slotDuration().Hours()
+	conditions := newWeather(s.rng)
+
+	// The price follows demand, with some noise.
+	reference := math.Round(s.config.BasePrice * (0.85 + 0.15*loadShape(hour)) * (0.95 + 0.1*s.rng.Float64()))
+	slot.Report.ReferencePrice = reference
+	err := s.api.PublishMarketPrice(ctx, slot.ID, reference, "marketsim", s.now.Unix())
+	if err != nil {
+		return err
+	}
+	// Participants bid once the price is committed.
+	s.transport.CutBlock()
+
+	var orders []chaincode.OrderRequest
+	for _, p := range s.population {
+		energy := p.netEnergy(hour, hours, conditions, s.rng)
+		quantity := int64(math.Round(math.Abs(energy)))
+		draw := s.rng.Float64()
+		if quantity == 0 {
+			continue
+		}
+		// Buyers bid from half their margin under the reference price to a full margin
+		// over it, sellers ask the mirror image, so most but not all of the book crosses.
+		offset := p.PriceMargin / 100 * (1.5*draw - 0.5)
+		order := chaincode.OrderRequest{
+			BidStatus:     chaincode.BidCreated,
+			SlotID:        slot.ID,
+			TotalQuantity: quantity,
+			UserID:        p.ID,
+		}
+		if energy > 0 {
+			order.UserAction = chaincode.Sell
+			order.UnitCost = math.Round(reference * (1 - offset))
+		} else {
+			order.UserAction = chaincode.Buy
+			order.UnitCost = math.Round(reference * (1 + offset))
+		}
+		s.nextOrderID++
+		order.ID = s.nextOrderID
+		orders = append(orders, order)
+	}
+
+	committed, err := s.submitAll(ctx, slot.Report, len(orders), func(i int) error {
+		return s.api.RegisterOrder(ctx, orders[i])
+	})
+	if err != nil {
+		return errors.New("Failed to place orders: " + err.Error())
+	}
+	for i, order := range orders {
+		if !committed[i] {
+			continue
+		}
+		slot.Orders = append(slot.Orders, order)
+		if order.UserAction == chaincode.Buy {
+			slot.Report.BuyOrders++
+		} else {
+			slot.Report.SellOrders++
+		}
+	}
+	return nil
+}
+
+// close stops trading in the slot, clears the book and records the matches.
+func (s *simulator) close(ctx context.Context, slot *slotRun) error {
+	err := s.api.UpdateTradingSlotStatus(ctx, slot.ID, chaincode.SlotClosed)
+	if err != nil {
+		return err
+	}
+	s.transport.CutBlock()
+
+	matches, price := clearMarket(slot.Orders)
+	requests := make([]chaincode.BidMatchRequest, len(matches))
+	for i, m := range matches {
+		s.nextMatchID++
+		requests[i] = chaincode.BidMatchRequest{
+			BidMatchTms:       s.now.Unix(),
+			BidSlot:           slot.ID,
+			BidStatus:         chaincode.BidAccepted,
+			BidUnitPrice:      price,
+			BuyerUserId:       m.BuyOrder.UserID,
+			ID:                s.nextMatchID,
+			OriginalBidUnits:  float64(m.Units),
+			SellerUserId:      m.SellOrder.UserID,
+			TransactionBuyID:  m.BuyOrder.ID,
+			TransactionSellID: m.SellOrder.ID,
+		}
+	}
+
+	committed, err := s.submitAll(ctx, slot.Report, len(requests), func(i int) error {
+		return s.api.ProcessBidMatch(ctx, requests[i])
+	})
+	if err != nil {
+		return errors.New("Failed to record matches: " + err.Error())
+	}
+	for i, request := range requests {
+		if committed[i] {
+			slot.Matches = append(slot.Matches, request)
+		}
+	}
+
+	err = s.api.UpdateTradingSlotStatus(ctx, slot.ID, chaincode.SlotMatched)
+	if err != nil {
+		return err
+	}
+	s.transport.CutBlock()
+	return nil
+}
+
+// settle records what each seller delivered, settles the slot and collects its KPIs.
+func (s *simulator) settle(ctx context.Context, slot *slotRun) error {
+	requests := make([]chaincode.BidMatchRequest, len(slot.Matches))
+	for i, request := range slot.Matches {
+		request.BidStatus = chaincode.BidExecuted
+		request.DeliveredBidUnits = request.OriginalBidUnits
+		if s.rng.Float64()*100 < s.config.ShortfallPct {
+			request.DeliveredBidUnits = math.Round(request.OriginalBidUnits * (0.5 + 0.45*s.rng.Float64()))
+		}
+		requests[i] = request
+	}
+
+	_, err := s.submitAll(ctx, slot.Report, len(requests), func(i int) error {
+		return s.api.ProcessBidMatch(ctx, requests[i])
+	})
+	if err != nil {
+		return errors.New("Failed to record deliveries: " + err.Error())
+	}
+
+	settlement, err := s.api.SettleSlot(ctx, slot.ID)
+	if err != nil {
+		return err
+	}
+	s.transport.CutBlock()
+	statistics, err := s.api.QuerySlotStatistics(ctx, slot.ID)
+	if err != nil {
+		return err
+	}
+
+	slot.Report.record(statistics, settlement)
+	s.report.Slots = append(s.report.Slots, *slot.Report)
+	return nil
+}
+
+// submitAll submits n transactions of one phase. Those that hit an MVCC conflict
+// are resubmitted in the next block, up to MaxRetries times, after which they are
+// abandoned. Any other failure stops the simulation. It reports which committed.
+func (s *simulator) submitAll(ctx context.Context, report *SlotReport, n int, submit func(i int) error) ([]bool, error) {
+	committed := make([]bool, n)
+	pending := make([]int, n)
+	for i := range pending {
+		pending[i] = i
+	}
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > s.config.MaxRetries {
+			report.Abandoned += int64(len(pending))
+			break
+		}
+		if attempt > 0 {
+			report.Retries += int64(len(pending))
+		}
+		var conflicted []int
+		for _, i := range pending {
+			err := submit(i)
+			if marketclient.IsMVCCConflict(err) {
+				report.Conflicts++
+				conflicted = append(conflicted, i)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			committed[i] = true
+		}
+		s.transport.CutBlock()
+		pending = conflicted
+	}
+	return committed, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smallConfig is a short daytime run, so that the book crosses in every slot.
+func smallConfig(seed int64) Config {
+	config := defaultConfig()
+	config.Consumers = 8
+	config.Prosumers = 12
+	config.Seed = seed
+	config.Slots = 4
+	config.Start = config.Start.Add(10 * time.Hour)
+	return config
+}
+
+func TestSimulate(t *testing.T) {
+	report, err := simulate(context.Background(), smallConfig(1))
+	require.NoError(t, err)
+
+	// Test Case 1: Every slot is settled and reported
+	t.Run("Slots Settled", func(t *testing.T) {
+		require.Len(t, report.Slots, 4)
+		for _, slot := range report.Slots {
+			assert.Greater(t, slot.Matches, int64(0), "Slot %s did not trade", slot.SlotID)
+			assert.InDelta(t, slot.ReferencePrice, slot.ClearingPrice, slot.ReferencePrice*0.2, "Clearing price outside the band")
+			assert.LessOrEqual(t, slot.DeliveredVolume, slot.TradedVolume)
+			assert.InDelta(t, slot.Value*0.02, slot.Fees, 0.005*float64(slot.Matches), "Fees are not 2% of the traded value")
+		}
+		assert.Greater(t, report.Totals.Conflicts, int64(0), "Blocks of 10 transactions should conflict")
+		assert.Equal(t, report.Totals.Conflicts, report.Totals.Retries+report.Totals.Abandoned, "Every conflict is retried or abandoned")
+	})
+
+	// Test Case 2: The same seed reproduces the run exactly
+	t.Run("Reproducible", func(t *testing.T) {
+		again, err := simulate(context.Background(), smallConfig(1))
+		require.NoError(t, err)
+		assert.Equal(t, report, again)
+
+		other, err := simulate(context.Background(), smallConfig(2))
+		require.NoError(t, err)
+		assert.NotEqual(t, report.Totals, other.Totals, "A different seed gave the same run")
+	})
+}
+
+func TestRun(t *testing.T) {
+	config, err := json.Marshal(smallConfig(3))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, ioutil.WriteFile(path, config, 0600))
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-config", path, "-seed", "4", "-output", "json"}, &stdout, &stderr)
+	require.Equal(t, 0, status, stderr.String())
+	var report Report
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	assert.Equal(t, int64(4), report.Config.Seed, "-seed did not override the config")
+	assert.Len(t, report.Slots, 4)
+
+	stdout.Reset()
+	status = run([]string{"-config", path}, &stdout, &stderr)
+	require.Equal(t, 0, status, stderr.String())
+	assert.Contains(t, stdout.String(), "MVCC conflicts")
+
+	assert.Equal(t, 2, run([]string{"-output", "csv"}, &stdout, &stderr))
+}
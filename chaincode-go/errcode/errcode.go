@@ -0,0 +1,147 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package errcode is the error model of the energy trading chaincode. Every
+// failed transaction carries an Error serialized as JSON in its response
+// message; clients use Parse or FromError to get it back and branch on its Code
+// rather than on the wording of the message.
+package errcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go/gateway"
+	"google.golang.org/grpc/status"
+)
+
+// Code classifies why the chaincode refused a transaction.
+type Code string
+
+const (
+	// Conflict: the asset being created already exists.
+	Conflict Code = "CONFLICT"
+	// Forbidden: the caller may not act on the asset.
+	Forbidden Code = "FORBIDDEN"
+	// Internal: the ledger could not be read or written, or holds a record the chaincode cannot use.
+	Internal Code = "INTERNAL"
+	// InvalidArgument: the arguments or payload are malformed; Details names the offending fields when known.
+	InvalidArgument Code = "INVALID_ARGUMENT"
+	// NotFound: an asset the transaction refers to does not exist.
+	NotFound Code = "NOT_FOUND"
+	// StateViolation: the asset exists but its current state does not allow the transaction.
+	StateViolation Code = "STATE_VIOLATION"
+	// Unknown: the failure did not come with a structured error, e.g. a peer or network error.
+	Unknown Code = "UNKNOWN"
+)
+
+// FieldError is a problem with one field of the request. Code is set when the
+// field is an item of a batch, and gives the code that item failed with.
+type FieldError struct {
+	Code    Code   `json:"code,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is the structured error of a failed chaincode transaction.
+type Error struct {
+	Code    Code         `json:"code"`
+	Details []FieldError `json:"details,omitempty"`
+	Message string       `json:"message"`
+}
+
+// New returns an Error with the given code and message.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Error returns the human readable message, so chaincode code can wrap an
+// Error like any other error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// JSON is the response message form of the error.
+func (e *Error) JSON() string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	// Messages quote user input such as "<" verbatim.
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(e)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// Parse extracts an Error from a response message. Peers and gateways prefix
+// the chaincode message with their own text, so the JSON may start anywhere in
+// the message. ok is false when the message carries no structured error.
+func Parse(message string) (*Error, bool) {
+	start := strings.Index(message, `{"code":`)
+	if start < 0 {
+		return nil, false
+	}
+	var parsed Error
+	err := json.NewDecoder(strings.NewReader(message[start:])).Decode(&parsed)
+	if err != nil || parsed.Code == "" {
+		return nil, false
+	}
+	return &parsed, true
+}
+
+// FromError extracts an Error from an error returned by a client: an *Error
+// anywhere in the chain, a fabric-gateway error whose gRPC status details carry
+// the endorsing peers' messages, or an error whose text carries one. Anything
+// else is returned as an Unknown Error with the error's text as message.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var chaincodeErr *Error
+	if errors.As(err, &chaincodeErr) {
+		return chaincodeErr
+	}
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		for _, detail := range grpcErr.GRPCStatus().Details() {
+			if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+				if parsed, ok := Parse(errorDetail.GetMessage()); ok {
+					return parsed
+				}
+			}
+		}
+	}
+	if parsed, ok := Parse(err.Error()); ok {
+		return parsed
+	}
+	return &Error{Code: Unknown, Message: err.Error()}
+}
+
+// CodeOf returns the Code of err, Unknown when it carries none.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	return FromError(err).Code
+}
+
+// Is reports whether err carries the given code.
+func Is(err error, code Code) bool {
+	return err != nil && CodeOf(err) == code
+}
@@ -0,0 +1,69 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/gateway"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParse(t *testing.T) {
+	// Test Case 1: The JSON form round-trips, keeping characters HTML escaping would mangle
+	t.Run("Round Trip", func(t *testing.T) {
+		original := &Error{Code: InvalidArgument, Message: "Invalid payload <x>", Details: []FieldError{{Field: "unitCost", Message: "must be positive"}}}
+		assert.Contains(t, original.JSON(), "<x>")
+
+		parsed, ok := Parse(original.JSON())
+		assert.True(t, ok, "Error not parsed")
+		assert.Equal(t, original, parsed, "Parsed error mismatch")
+	})
+
+	// Test Case 2: Peer and gateway prefixes are skipped
+	t.Run("Prefixed Message", func(t *testing.T) {
+		parsed, ok := Parse(`chaincode response 500, {"code":"NOT_FOUND","message":"Order with ID 4 not found."}`)
+		assert.True(t, ok, "Error not parsed")
+		assert.Equal(t, NotFound, parsed.Code, "Code mismatch")
+		assert.Equal(t, "Order with ID 4 not found.", parsed.Message, "Message mismatch")
+	})
+
+	// Test Case 3: Free-text messages carry no structured error
+	t.Run("Free Text", func(t *testing.T) {
+		_, ok := Parse("transaction returned with failure: timeout")
+		assert.False(t, ok, "Free text parsed")
+	})
+}
+
+func TestFromError(t *testing.T) {
+	// Test Case 1: An Error anywhere in the chain is returned as is
+	t.Run("Wrapped Error", func(t *testing.T) {
+		err := fmt.Errorf("submit failed: %w", New(Conflict, "PlatformTerms version 2 already exists."))
+		assert.Equal(t, Conflict, CodeOf(err), "Code mismatch")
+		assert.True(t, Is(err, Conflict), "Is mismatch")
+	})
+
+	// Test Case 2: Gateway errors carry the chaincode message in their status details
+	t.Run("Gateway Error", func(t *testing.T) {
+		st, err := status.New(codes.Aborted, "failed to endorse transaction, see attached details for more info").WithDetails(&gateway.ErrorDetail{
+			Address: "peer0.org1.example.com:7051",
+			MspId:   "Org1MSP",
+			Message: `chaincode response 500, {"code":"FORBIDDEN","message":"Caller from Org2MSP is not a platform admin"}`,
+		})
+		assert.NoError(t, err, "Error adding status details")
+
+		parsed := FromError(st.Err())
+		assert.Equal(t, Forbidden, parsed.Code, "Code mismatch")
+		assert.Equal(t, "Caller from Org2MSP is not a platform admin", parsed.Message, "Message mismatch")
+	})
+
+	// Test Case 3: Anything else is Unknown
+	t.Run("Unknown Error", func(t *testing.T) {
+		parsed := FromError(errors.New("connection refused"))
+		assert.Equal(t, Unknown, parsed.Code, "Code mismatch")
+		assert.Equal(t, "connection refused", parsed.Message, "Message mismatch")
+		assert.Nil(t, FromError(nil), "nil error mismatch")
+	})
+}
@@ -0,0 +1,70 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package indexer
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// Number of records read per QueryAssets call
+const backfillPageSize = 500
+
+// AssetLister pages through the current records of an asset kind, as the
+// chaincode's QueryAssets does. A *client.Client is one.
+type AssetLister interface {
+	QueryAssets(ctx context.Context, kind string, pageSize int64, bookmark string) (chaincode.AssetPage, error)
+}
+
+// Backfill loads the current ledger records of every indexed kind into store,
+// one page at a time, and returns how many it loaded. It indexes records last
+// written before the chaincode emitted AssetsChanged events, which following
+// the events alone never sees. Events applied afterwards replace the rows of
+// the records they write.
+func Backfill(ctx context.Context, lister AssetLister, store *Store) (int, error) {
+	kinds := make([]string, 0, len(projections))
+	for kind := range projections {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	loaded := 0
+	for _, kind := range kinds {
+		bookmark := ""
+		for {
+			page, err := lister.QueryAssets(ctx, kind, backfillPageSize, bookmark)
+			if err != nil {
+				return loaded, err
+			}
+			err = store.Load(ctx, page.Assets)
+			if err != nil {
+				return loaded, err
+			}
+			loaded += len(page.Assets)
+			if page.Bookmark == "" {
+				break
+			}
+			bookmark = page.Bookmark
+		}
+	}
+	return loaded, nil
+}
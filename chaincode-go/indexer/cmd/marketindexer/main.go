@@ -0,0 +1,230 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command marketindexer keeps a SQLite projection of the marketplace ledger up to
+// date by following the chaincode's AssetsChanged events. It resumes after the
+// last event it indexed and reconnects when the peer connection drops, until it
+// receives SIGINT or SIGTERM.
+//
+//	marketindexer [-profile file] [-db file] [-record file] [-backfill]
+//	marketindexer -replay file [-db file]
+//
+// The profile has the format of marketctl's; only the gateway, channel and
+// chaincode settings are used. -record appends every event received to a file,
+// which -replay later indexes without a network. -backfill first loads the
+// current ledger records, so records last written before the chaincode emitted
+// events are indexed too.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	marketclient "github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/indexer"
+)
+
+// How long to wait before reconnecting to the peer
+const reconnectDelay = 5 * time.Second
+
+// Profile holds the connection settings of marketindexer.
+type Profile struct {
+	marketclient.GatewayProfile
+	Chaincode string `json:"chaincode"`
+	Channel   string `json:"channel"`
+}
+
+func defaultProfile() Profile {
+	return Profile{
+		GatewayProfile: marketclient.GatewayProfile{
+			MspID:         "Org1MSP",
+			PeerEndpoint:  "localhost:7051",
+			PeerHostAlias: "peer0.org1.example.com",
+		},
+		Chaincode: "basic",
+		Channel:   "mychannel",
+	}
+}
+
+// loadProfile reads a profile over the defaults, resolving relative paths against
+// its directory. An empty path returns the defaults.
+func loadProfile(path string) (Profile, error) {
+	profile := defaultProfile()
+	if path == "" {
+		return profile, nil
+	}
+	profileAsBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return profile, errors.New("Failed to read profile: " + err.Error())
+	}
+	err = json.Unmarshal(profileAsBytes, &profile)
+	if err != nil {
+		return profile, errors.New("Failed to parse profile " + path + ": " + err.Error())
+	}
+
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&profile.CertPath, &profile.KeyPath, &profile.TLSCertPath} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	return profile, nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stderr))
+}
+
+// run indexes until ctx is done or, with -replay, the recording is exhausted, and
+// returns the exit status.
+func run(ctx context.Context, args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("marketindexer", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	profilePath := flags.String("profile", os.Getenv("MARKETCTL_PROFILE"), "connection profile `file`")
+	dbPath := flags.String("db", "market.db", "SQLite database `file`")
+	replayPath := flags.String("replay", "", "index the recorded events in `file` instead of following the network")
+	recordPath := flags.String("record", "", "append the events received to `file`")
+	backfill := flags.Bool("backfill", false, "load the current ledger records before following the events")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: marketindexer [-profile file] [-db file] [-record file] [-backfill]")
+		fmt.Fprintln(stderr, "       marketindexer -replay file [-db file]")
+		flags.PrintDefaults()
+	}
+	if flags.Parse(args) != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+	if *backfill && *replayPath != "" {
+		fmt.Fprintln(stderr, "-backfill reads the network and cannot be used with -replay")
+		return 2
+	}
+	logger := log.New(stderr, "marketindexer: ", log.LstdFlags)
+
+	store, err := indexer.OpenStore(*dbPath)
+	if err != nil {
+		logger.Println(err)
+		return 1
+	}
+	defer store.Close()
+
+	var record io.Writer
+	if *recordPath != "" {
+		file, err := os.OpenFile(*recordPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			logger.Println("Failed to open recording:", err)
+			return 1
+		}
+		defer file.Close()
+		record = file
+	}
+
+	if *replayPath != "" {
+		err = replay(ctx, *replayPath, store, record)
+	} else {
+		err = follow(ctx, *profilePath, store, record, *backfill, logger)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		logger.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// replay indexes a recording once.
+func replay(ctx context.Context, path string, store *indexer.Store, record io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.New("Failed to open recording: " + err.Error())
+	}
+	events, err := indexer.ReadEvents(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	return indexer.New(withRecording(indexer.NewReplaySource(events), record), store).Run(ctx)
+}
+
+// follow indexes the network's events until ctx is done, reconnecting whenever
+// the event stream or the connection fails. With backfill, the current ledger
+// records are loaded first, retried on every reconnect until they are.
+func follow(ctx context.Context, profilePath string, store *indexer.Store, record io.Writer, backfill bool, logger *log.Logger) error {
+	profile, err := loadProfile(profilePath)
+	if err != nil {
+		return err
+	}
+	for {
+		err = followOnce(ctx, profile, store, record, &backfill, logger)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			err = errors.New("event stream closed")
+		}
+		logger.Printf("%s; reconnecting in %s", err, reconnectDelay)
+		select {
+		case <-time.After(reconnectDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// followOnce follows the events over one connection. A pending backfill is
+// done first and cleared once it succeeds.
+func followOnce(ctx context.Context, profile Profile, store *indexer.Store, record io.Writer, pendingBackfill *bool, logger *log.Logger) error {
+	gateway, close, err := marketclient.Dial(profile.GatewayProfile)
+	if err != nil {
+		return err
+	}
+	defer close()
+	if *pendingBackfill {
+		contract := gateway.GetNetwork(profile.Channel).GetContract(profile.Chaincode)
+		loaded, err := indexer.Backfill(ctx, marketclient.New(marketclient.NewGatewayTransport(contract)), store)
+		if err != nil {
+			return errors.New("Failed to backfill: " + err.Error())
+		}
+		logger.Printf("backfilled %d records", loaded)
+		*pendingBackfill = false
+	}
+	source := indexer.NewGatewaySource(gateway.GetNetwork(profile.Channel), profile.Chaincode)
+	return indexer.New(withRecording(source, record), store).Run(ctx)
+}
+
+func withRecording(source indexer.Source, record io.Writer) indexer.Source {
+	if record == nil {
+		return source
+	}
+	return indexer.NewRecordingSource(source, record)
+}
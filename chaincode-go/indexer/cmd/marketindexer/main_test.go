@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/indexer"
+)
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	recording := filepath.Join("..", "..", "testdata", "events.jsonl")
+	db := filepath.Join(dir, "market.db")
+	recorded := filepath.Join(dir, "recorded.jsonl")
+
+	var stderr bytes.Buffer
+	status := run(context.Background(), []string{"-replay", recording, "-db", db, "-record", recorded}, &stderr)
+	require.Equal(t, 0, status, stderr.String())
+
+	store, err := indexer.OpenStore(db)
+	require.NoError(t, err)
+	defer store.Close()
+	var orders, payments int
+	require.NoError(t, store.DB().QueryRow("SELECT COUNT(*) FROM orders").Scan(&orders))
+	require.NoError(t, store.DB().QueryRow("SELECT COUNT(*) FROM payments").Scan(&payments))
+	assert.Equal(t, 2, orders)
+	assert.Equal(t, 2, payments)
+
+	original, err := os.ReadFile(recording)
+	require.NoError(t, err)
+	copied, err := os.ReadFile(recorded)
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(copied), "-record did not copy the events")
+
+	// A second replay resumes after the checkpoint and leaves it where it was.
+	before, _, err := store.Checkpoint(context.Background())
+	require.NoError(t, err)
+	status = run(context.Background(), []string{"-replay", recording, "-db", db}, &stderr)
+	require.Equal(t, 0, status, stderr.String())
+	after, _, err := store.Checkpoint(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	assert.Equal(t, 2, run(context.Background(), []string{"extra"}, &stderr))
+	assert.Equal(t, 2, run(context.Background(), []string{"-replay", recording, "-backfill"}, &stderr), "-backfill needs the network")
+}
@@ -0,0 +1,81 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package indexer projects the ledger into a SQLite database for off-chain
+// queries. The chaincode sets an AssetsChanged event on every transaction that
+// writes assets; the indexer follows those events from a Source and keeps Users,
+// Orders, BidMatches and Payments in tables of the same names:
+//
+//	store, err := indexer.OpenStore("market.db")
+//	...
+//	source := indexer.NewGatewaySource(gateway.GetNetwork("mychannel"), "basic")
+//	err = indexer.New(source, store).Run(ctx)
+//
+// Each event is applied together with a checkpoint, so a restarted indexer
+// resumes after the last event it applied. Records last written before the
+// chaincode emitted events are loaded by Backfill, which reads the current
+// ledger through the chaincode's QueryAssets:
+//
+//	_, err = indexer.Backfill(ctx, client.New(client.NewGatewayTransport(contract)), store)
+package indexer
+
+import (
+	"context"
+)
+
+// Indexer applies the events of a Source to a Store.
+type Indexer struct {
+	source Source
+	store  *Store
+}
+
+func New(source Source, store *Store) *Indexer {
+	return &Indexer{source: source, store: store}
+}
+
+// Run applies events from the store's checkpoint onwards until the source closes,
+// returning nil, or ctx is done, returning its error.
+func (ix *Indexer) Run(ctx context.Context) error {
+	checkpoint, found, err := ix.store.Checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	events, err := ix.source.Events(ctx, checkpoint.BlockNumber)
+	if err != nil {
+		return err
+	}
+
+	// The checkpoint block is delivered again from its first event; skip up to
+	// and including the checkpoint transaction.
+	skipping := found
+	for event := range events {
+		if skipping {
+			if event.BlockNumber == checkpoint.BlockNumber {
+				skipping = event.TransactionID != checkpoint.TransactionID
+				continue
+			}
+			skipping = false
+		}
+		err = ix.store.Apply(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
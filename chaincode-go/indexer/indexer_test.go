@@ -0,0 +1,205 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/client"
+)
+
+// recordedEvents are the events of a trading round on the chaincode: two users
+// register, a buy and a sell order are matched, executed short and settled.
+func recordedEvents(t *testing.T) []*Event {
+	file, err := os.Open(filepath.Join("testdata", "events.jsonl"))
+	require.NoError(t, err)
+	defer file.Close()
+	events, err := ReadEvents(file)
+	require.NoError(t, err)
+	require.Len(t, events, 14)
+	return events
+}
+
+func openStore(t *testing.T, path string) *Store {
+	store, err := OpenStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// tableRows queries one row per result, as strings.
+func tableRows(t *testing.T, store *Store, query string) [][]string {
+	rows, err := store.DB().Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+
+	var result [][]string
+	for rows.Next() {
+		values := make([]string, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		require.NoError(t, rows.Scan(pointers...))
+		result = append(result, values)
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+func TestIndexer(t *testing.T) {
+	events := recordedEvents(t)
+	last := events[len(events)-1]
+
+	// Test Case 1: Replaying the events projects the business records
+	t.Run("Replay", func(t *testing.T) {
+		store := openStore(t, ":memory:")
+		require.NoError(t, New(NewReplaySource(events), store).Run(context.Background()))
+
+		assert.Equal(t, [][]string{{"3", "Consumer", "DG Set"}, {"4", "Prosumer", "Solar"}},
+			tableRows(t, store, "SELECT id, category, source FROM users ORDER BY id"))
+		assert.Equal(t, [][]string{{"1", "Buy", "BidFilled", "100", "0"}, {"2", "Sell", "BidPartiallyFilled", "100", "20"}},
+			tableRows(t, store, "SELECT id, action, bid_status, filled_quantity, remaining_quantity FROM orders ORDER BY id"))
+		assert.Equal(t, [][]string{{"1", "BidExecuted", "100", "90", "1", "2"}},
+			tableRows(t, store, "SELECT id, bid_status, original_units, delivered_units, buy_order_id, sell_order_id FROM bid_matches"))
+		assert.Equal(t, [][]string{{"Settlement_slot1_1_Buy", "3", "Buyer - Energy Purchased", "459", "1"}, {"Settlement_slot1_1_Sell", "4", "Seller - Energy Sold plus Token Refund", "445", "1"}},
+			tableRows(t, store, "SELECT id, user_id, payment_type, total_amount, bid_match_id FROM payments ORDER BY id"))
+		assert.Equal(t, [][]string{{"8", events[7].TransactionID}},
+			tableRows(t, store, "SELECT block_number, tx_id FROM orders WHERE id = 2"), "Row provenance is its last change")
+
+		checkpoint, found, err := store.Checkpoint(context.Background())
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, Checkpoint{BlockNumber: last.BlockNumber, TransactionID: last.TransactionID}, checkpoint)
+	})
+
+	// Test Case 2: A restarted indexer resumes after its checkpoint
+	t.Run("Resume", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "market.db")
+		store := openStore(t, path)
+		// Stop in the middle of block 8, after the orders were placed.
+		require.NoError(t, New(NewReplaySource(events[:7]), store).Run(context.Background()))
+		require.NoError(t, store.Close())
+
+		// Events up to the checkpoint are skipped: a different Order 2 in its
+		// transaction must not replace the one already indexed.
+		replayed := append([]*Event{}, events...)
+		tampered := *events[6]
+		tampered.Payload = bytes.Replace(tampered.Payload, []byte(`"totalQuantity":120`), []byte(`"totalQuantity":999`), 1)
+		replayed[6] = &tampered
+
+		store = openStore(t, path)
+		require.NoError(t, New(NewReplaySource(replayed), store).Run(context.Background()))
+		assert.Equal(t, [][]string{{"1", "100"}, {"2", "120"}},
+			tableRows(t, store, "SELECT id, total_quantity FROM orders ORDER BY id"))
+		assert.Len(t, tableRows(t, store, "SELECT id FROM payments"), 2, "Events after the checkpoint were not applied")
+	})
+
+	// Test Case 3: Deleted assets are removed and other events only move the checkpoint
+	t.Run("Delete And Foreign Events", func(t *testing.T) {
+		store := openStore(t, ":memory:")
+		require.NoError(t, New(NewReplaySource(events), store).Run(context.Background()))
+
+		more := []*Event{
+			{BlockNumber: 12, EventName: "AssetsChanged", Payload: []byte(`[{"deleted":true,"id":"1","kind":"BidMatch"}]`), TransactionID: "tx-delete"},
+			{BlockNumber: 12, EventName: "SomethingElse", Payload: []byte(`{}`), TransactionID: "tx-other"},
+		}
+		require.NoError(t, New(NewReplaySource(append(events, more...)), store).Run(context.Background()))
+		assert.Empty(t, tableRows(t, store, "SELECT id FROM bid_matches"))
+		checkpoint, _, err := store.Checkpoint(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, Checkpoint{BlockNumber: 12, TransactionID: "tx-other"}, checkpoint)
+	})
+
+	// Test Case 4: A malformed event stops the indexer before its checkpoint
+	t.Run("Malformed Event", func(t *testing.T) {
+		store := openStore(t, ":memory:")
+		bad := []*Event{{BlockNumber: 1, EventName: "AssetsChanged", Payload: []byte(`{"not":"a list"}`), TransactionID: "tx-bad"}}
+		err := New(NewReplaySource(bad), store).Run(context.Background())
+		assert.Error(t, err)
+		_, found, err := store.Checkpoint(context.Background())
+		require.NoError(t, err)
+		assert.False(t, found, "Checkpoint moved past a failed event")
+	})
+}
+
+func TestRecordingSource(t *testing.T) {
+	events := recordedEvents(t)
+
+	var recording bytes.Buffer
+	source := NewRecordingSource(NewReplaySource(events), &recording)
+	delivered, err := source.Events(context.Background(), 10)
+	require.NoError(t, err)
+	var count int
+	for range delivered {
+		count++
+	}
+	assert.Equal(t, 4, count, "Events before the start block were delivered")
+
+	replayed, err := ReadEvents(&recording)
+	require.NoError(t, err)
+	assert.Equal(t, events[10:], replayed)
+}
+
+func TestBackfill(t *testing.T) {
+	ctx := context.Background()
+	transport := client.NewMockTransport("indexer", new(chaincode.SimpleChaincode))
+	require.NoError(t, transport.SetIdentity("Org1MSP", "admin"))
+	api := client.New(transport)
+
+	start := time.Now().Add(2 * time.Hour).Unix()
+	require.NoError(t, api.CreateTradingSlot(ctx, "slot1", start, start+900, start-3600))
+	require.NoError(t, api.SetMarketOracleConfig(ctx, 20, "Org1MSP:admin"))
+	require.NoError(t, api.PublishMarketPrice(ctx, "slot1", 3.5, "test", time.Now().Unix()))
+	pii := &chaincode.UserPII{Location: "Location", MeterId: "Meter"}
+	require.NoError(t, api.UpdateUserProfile(ctx, chaincode.UserRequest{ID: 6, Category: chaincode.Consumer, Source: chaincode.Battery}, pii))
+	require.NoError(t, api.UpdateUserProfile(ctx, chaincode.UserRequest{ID: 7, Category: chaincode.Prosumer, Source: chaincode.Solar}, pii))
+	require.NoError(t, api.RegisterOrder(ctx, chaincode.OrderRequest{ID: 4, SlotID: "slot1", TotalQuantity: 300, UnitCost: 3.5, UserAction: chaincode.Buy, UserID: 6}))
+	require.NoError(t, api.RegisterOrder(ctx, chaincode.OrderRequest{ID: 5, SlotID: "slot1", TotalQuantity: 100, UnitCost: 3.6, UserAction: chaincode.Sell, UserID: 7}))
+
+	// Test Case 1: Backfilling loads the current records without a checkpoint
+	t.Run("Existing Ledger", func(t *testing.T) {
+		store := openStore(t, ":memory:")
+		loaded, err := Backfill(ctx, api, store)
+		require.NoError(t, err)
+		assert.Equal(t, 4, loaded)
+
+		assert.Equal(t, [][]string{{"6", "Consumer", "Battery", "Org1MSP:admin", "0"}, {"7", "Prosumer", "Solar", "Org1MSP:admin", "0"}},
+			tableRows(t, store, "SELECT id, category, source, identity, block_number FROM users ORDER BY id"))
+		assert.Equal(t, [][]string{{"4", "6", "Buy", "300", ""}, {"5", "7", "Sell", "100", ""}},
+			tableRows(t, store, "SELECT id, user_id, action, total_quantity, tx_id FROM orders ORDER BY id"))
+		_, found, err := store.Checkpoint(ctx)
+		require.NoError(t, err)
+		assert.False(t, found, "Backfill moved the checkpoint")
+	})
+
+	// Test Case 2: Events applied after a backfill replace its rows
+	t.Run("Followed By Events", func(t *testing.T) {
+		store := openStore(t, ":memory:")
+		_, err := Backfill(ctx, api, store)
+		require.NoError(t, err)
+
+		order, err := api.ReadOrder(ctx, 5)
+		require.NoError(t, err)
+		order.TotalQuantity = 120
+		value, err := json.Marshal(order)
+		require.NoError(t, err)
+		payload, err := json.Marshal([]chaincode.AssetChange{{ID: "5", Kind: "Order", Value: value}})
+		require.NoError(t, err)
+		event := &Event{BlockNumber: 9, EventName: chaincode.AssetEventName, Payload: payload, TransactionID: "tx-amend"}
+		require.NoError(t, New(NewReplaySource([]*Event{event}), store).Run(ctx))
+
+		assert.Equal(t, [][]string{{"4", "300", "0"}, {"5", "120", "9"}},
+			tableRows(t, store, "SELECT id, total_quantity, block_number FROM orders ORDER BY id"))
+	})
+}
@@ -0,0 +1,173 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package indexer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Event is a chaincode event of a committed transaction.
+type Event struct {
+	BlockNumber   uint64          `json:"blockNumber"`
+	EventName     string          `json:"eventName"`
+	Payload       json.RawMessage `json:"payload"`
+	TransactionID string          `json:"transactionId"`
+}
+
+// Source delivers the chaincode events of committed transactions in ledger order.
+type Source interface {
+	// Events delivers the events from block startBlock onwards. The channel is
+	// closed once ctx is done or the source has nothing more to deliver.
+	Events(ctx context.Context, startBlock uint64) (<-chan *Event, error)
+}
+
+// GatewaySource listens to the chaincode events of a channel through the Fabric
+// Gateway. Its channel also closes when the connection to the peer is lost.
+type GatewaySource struct {
+	chaincode string
+	network   *client.Network
+}
+
+// NewGatewaySource returns a Source for the events of chaincode on network.
+func NewGatewaySource(network *client.Network, chaincode string) *GatewaySource {
+	return &GatewaySource{chaincode: chaincode, network: network}
+}
+
+func (s *GatewaySource) Events(ctx context.Context, startBlock uint64) (<-chan *Event, error) {
+	chaincodeEvents, err := s.network.ChaincodeEvents(ctx, s.chaincode, client.WithStartBlock(startBlock))
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan *Event)
+	go func() {
+		defer close(events)
+		for chaincodeEvent := range chaincodeEvents {
+			event := &Event{
+				BlockNumber:   chaincodeEvent.BlockNumber,
+				EventName:     chaincodeEvent.EventName,
+				Payload:       chaincodeEvent.Payload,
+				TransactionID: chaincodeEvent.TransactionID,
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// ReplaySource delivers recorded events, as read by ReadEvents, and then closes.
+type ReplaySource struct {
+	events []*Event
+}
+
+func NewReplaySource(events []*Event) *ReplaySource {
+	return &ReplaySource{events: events}
+}
+
+func (s *ReplaySource) Events(ctx context.Context, startBlock uint64) (<-chan *Event, error) {
+	events := make(chan *Event)
+	go func() {
+		defer close(events)
+		for _, event := range s.events {
+			if event.BlockNumber < startBlock {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// ReadEvents reads recorded events, one JSON object per line.
+func ReadEvents(r io.Reader) ([]*Event, error) {
+	var events []*Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		err := json.Unmarshal(scanner.Bytes(), &event)
+		if err != nil {
+			return nil, errors.New("Failed to parse event on line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+		events = append(events, &event)
+	}
+	return events, scanner.Err()
+}
+
+// RecordingSource passes on the events of another Source, writing each one to w
+// in the format of ReadEvents.
+type RecordingSource struct {
+	mutex  sync.Mutex
+	source Source
+	w      io.Writer
+}
+
+func NewRecordingSource(source Source, w io.Writer) *RecordingSource {
+	return &RecordingSource{source: source, w: w}
+}
+
+func (s *RecordingSource) Events(ctx context.Context, startBlock uint64) (<-chan *Event, error) {
+	upstream, err := s.source.Events(ctx, startBlock)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan *Event)
+	go func() {
+		defer close(events)
+		for event := range upstream {
+			s.record(event)
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// record writes one event; a failed write loses the recording, not the event.
+func (s *RecordingSource) record(event *Event) {
+	eventAsBytes, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.w.Write(append(eventAsBytes, '\n'))
+}
@@ -0,0 +1,295 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	// Registers the "sqlite3" database/sql driver.
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/nidish-r/battery-swapping-basic/chaincode-go/chaincode"
+)
+
+// schema is the projection of the ledger. Each row carries the block and
+// transaction of its last change. Enums are stored by name.
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id           INTEGER PRIMARY KEY,
+	category     TEXT NOT NULL,
+	source       TEXT NOT NULL,
+	identity     TEXT NOT NULL,
+	org_msp      TEXT NOT NULL,
+	pii_hash     TEXT NOT NULL,
+	created_on   INTEGER NOT NULL,
+	updated_on   INTEGER NOT NULL,
+	block_number INTEGER NOT NULL,
+	tx_id        TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS orders (
+	id                 INTEGER PRIMARY KEY,
+	user_id            INTEGER NOT NULL,
+	slot_id            TEXT NOT NULL,
+	action             TEXT NOT NULL,
+	bid_status         TEXT NOT NULL,
+	unit_cost          REAL NOT NULL,
+	total_quantity     INTEGER NOT NULL,
+	filled_quantity    REAL NOT NULL,
+	remaining_quantity REAL NOT NULL,
+	order_cost         REAL NOT NULL,
+	on_market_price    TEXT NOT NULL,
+	slot_exec_date     INTEGER NOT NULL,
+	revision           INTEGER NOT NULL,
+	created_on         INTEGER NOT NULL,
+	updated_on         INTEGER NOT NULL,
+	block_number       INTEGER NOT NULL,
+	tx_id              TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS orders_user_id ON orders (user_id);
+CREATE INDEX IF NOT EXISTS orders_slot_id ON orders (slot_id);
+CREATE TABLE IF NOT EXISTS bid_matches (
+	id              INTEGER PRIMARY KEY,
+	slot_id         TEXT NOT NULL,
+	bid_status      TEXT NOT NULL,
+	unit_price      INTEGER NOT NULL,
+	buyer_user_id   INTEGER NOT NULL,
+	seller_user_id  INTEGER NOT NULL,
+	buy_order_id    INTEGER NOT NULL,
+	sell_order_id   INTEGER NOT NULL,
+	original_units  REAL NOT NULL,
+	delivered_units REAL NOT NULL,
+	co2e_kg         REAL NOT NULL,
+	bid_match_tms   INTEGER NOT NULL,
+	block_number    INTEGER NOT NULL,
+	tx_id           TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS bid_matches_slot_id ON bid_matches (slot_id);
+CREATE TABLE IF NOT EXISTS payments (
+	id                TEXT PRIMARY KEY,
+	user_id           INTEGER NOT NULL,
+	payment_type      TEXT NOT NULL,
+	total_amount      REAL NOT NULL,
+	bid_match_id      INTEGER,
+	payment_detail_id INTEGER NOT NULL,
+	created_on        INTEGER NOT NULL,
+	block_number      INTEGER NOT NULL,
+	tx_id             TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS payments_user_id ON payments (user_id);
+CREATE TABLE IF NOT EXISTS checkpoint (
+	id             INTEGER PRIMARY KEY CHECK (id = 0),
+	block_number   INTEGER NOT NULL,
+	transaction_id TEXT NOT NULL
+);
+`
+
+// Checkpoint is the last event a Store has applied.
+type Checkpoint struct {
+	BlockNumber   uint64
+	TransactionID string
+}
+
+// projection writes one asset kind into its table.
+type projection struct {
+	table  string
+	upsert func(tx *sql.Tx, value []byte, event *Event) error
+}
+
+// projections is keyed by AssetChange.Kind. Changes to other kinds are ignored.
+var projections = map[string]projection{
+	"BidMatch": {"bid_matches", upsertBidMatch},
+	"Order":    {"orders", upsertOrder},
+	"Payment":  {"payments", upsertPayment},
+	"User":     {"users", upsertUser},
+}
+
+// Store is the SQLite database the indexer projects the ledger into.
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore opens the SQLite database at path, creating it and its tables as needed.
+func OpenStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, errors.New("Failed to open " + path + ": " + err.Error())
+	}
+	// SQLite allows a single writer; one connection also keeps ":memory:" databases whole.
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(schema)
+	if err != nil {
+		db.Close()
+		return nil, errors.New("Failed to create the index schema: " + err.Error())
+	}
+	return &Store{db: db}, nil
+}
+
+// DB gives read access to the projected tables.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Checkpoint returns the last applied event. found is false for an empty store.
+func (s *Store) Checkpoint(ctx context.Context) (checkpoint Checkpoint, found bool, err error) {
+	err = s.db.QueryRowContext(ctx, "SELECT block_number, transaction_id FROM checkpoint WHERE id = 0").Scan(&checkpoint.BlockNumber, &checkpoint.TransactionID)
+	if err == sql.ErrNoRows {
+		return checkpoint, false, nil
+	}
+	if err != nil {
+		return checkpoint, false, errors.New("Failed to read checkpoint: " + err.Error())
+	}
+	return checkpoint, true, nil
+}
+
+// Apply projects the asset changes of an event and moves the checkpoint to it, in
+// one database transaction. Events other than AssetsChanged only move the checkpoint.
+func (s *Store) Apply(ctx context.Context, event *Event) error {
+	var changes []chaincode.AssetChange
+	if event.EventName == chaincode.AssetEventName {
+		err := json.Unmarshal(event.Payload, &changes)
+		if err != nil {
+			return errors.New("Failed to parse event of transaction " + event.TransactionID + ": " + err.Error())
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = applyChanges(tx, changes, event)
+	if err != nil {
+		return errors.New(err.Error() + " of transaction " + event.TransactionID)
+	}
+
+	_, err = tx.Exec("INSERT OR REPLACE INTO checkpoint (id, block_number, transaction_id) VALUES (0, ?, ?)", event.BlockNumber, event.TransactionID)
+	if err != nil {
+		return errors.New("Failed to store checkpoint: " + err.Error())
+	}
+	return tx.Commit()
+}
+
+// Load projects the current values of ledger records, as read by Backfill, in one
+// database transaction. Their rows carry block 0 and no transaction; the
+// checkpoint is left where it is.
+func (s *Store) Load(ctx context.Context, assets []chaincode.AssetChange) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = applyChanges(tx, assets, &Event{})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func applyChanges(tx *sql.Tx, changes []chaincode.AssetChange, event *Event) error {
+	for _, change := range changes {
+		p, ok := projections[change.Kind]
+		if !ok {
+			continue
+		}
+		var err error
+		if change.Deleted {
+			_, err = tx.Exec("DELETE FROM "+p.table+" WHERE id = ?", change.ID)
+		} else {
+			err = p.upsert(tx, change.Value, event)
+		}
+		if err != nil {
+			return errors.New("Failed to index " + change.Kind + " " + change.ID + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+func upsertUser(tx *sql.Tx, value []byte, event *Event) error {
+	var user chaincode.User
+	err := json.Unmarshal(value, &user)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO users
+		(id, category, source, identity, org_msp, pii_hash, created_on, updated_on, block_number, tx_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		user.ID, user.Category.String(), user.Source.String(), user.Identity, user.OrgMSP, user.PIIHash,
+		user.CreatedOn, user.UpdatedOn, event.BlockNumber, event.TransactionID)
+	return err
+}
+
+func upsertOrder(tx *sql.Tx, value []byte, event *Event) error {
+	var order chaincode.Order
+	err := json.Unmarshal(value, &order)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO orders
+		(id, user_id, slot_id, action, bid_status, unit_cost, total_quantity, filled_quantity, remaining_quantity,
+		 order_cost, on_market_price, slot_exec_date, revision, created_on, updated_on, block_number, tx_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		order.ID, order.UserID, order.SlotID, order.UserAction.String(), order.BidStatus.String(), order.UnitCost,
+		order.TotalQuantity, order.FilledQuantity, order.RemainingQuantity, order.OrderCost, order.OnMarketPrice,
+		order.SlotExecDate, order.Revision, order.CreatedOn, order.UpdatedOn, event.BlockNumber, event.TransactionID)
+	return err
+}
+
+func upsertBidMatch(tx *sql.Tx, value []byte, event *Event) error {
+	var bidMatch chaincode.BidMatch
+	err := json.Unmarshal(value, &bidMatch)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO bid_matches
+		(id, slot_id, bid_status, unit_price, buyer_user_id, seller_user_id, buy_order_id, sell_order_id,
+		 original_units, delivered_units, co2e_kg, bid_match_tms, block_number, tx_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		bidMatch.ID, bidMatch.BidSlot, bidMatch.BidStatus.String(), bidMatch.BidUnitPrice, bidMatch.BuyerUserId,
+		bidMatch.SellerUserId, bidMatch.TransactionBuyID, bidMatch.TransactionSellID, bidMatch.OriginalBidUnits,
+		bidMatch.DeliveredBidUnits, bidMatch.CO2eKg, bidMatch.BidMatchTms, event.BlockNumber, event.TransactionID)
+	return err
+}
+
+func upsertPayment(tx *sql.Tx, value []byte, event *Event) error {
+	var payment chaincode.Payment
+	err := json.Unmarshal(value, &payment)
+	if err != nil {
+		return err
+	}
+	var bidMatchID sql.NullInt64
+	if payment.BidMatchID != 0 {
+		bidMatchID = sql.NullInt64{Int64: payment.BidMatchID, Valid: true}
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO payments
+		(id, user_id, payment_type, total_amount, bid_match_id, payment_detail_id, created_on, block_number, tx_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		payment.ID, payment.UserID, payment.PaymentType.String(), payment.TotalAmount, bidMatchID,
+		payment.PaymentDetailId, payment.CreatedOn, event.BlockNumber, event.TransactionID)
+	return err
+}
@@ -1,102 +0,0 @@
-/*
-Licensed to the Apache Software Foundation (ASF) under one
-or more contributor license agreements.  See the NOTICE file
-distributed with this work for additional information
-regarding copyright ownership.  The ASF licenses this file
-to you under the Apache License, Version 2.0 (the
-"License"); you may not use this file except in compliance
-with the License.  You may obtain a copy of the License at
-
-http://www.apache.org/licenses/LICENSE-2.0
-
-Unless required by applicable law or agreed to in writing,
-software distributed under the License is distributed on an
-"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
-KIND, either express or implied.  See the License for the
-specific language governing permissions and limitations
-under the License.
-*/
-
-package main
-
-import (
-	"errors"
-	"fmt"
-	"strconv"
-)
-
-// ==============================================================
-// Input Sanitation - dumb input checking, look for empty strings
-// ==============================================================
-func sanitize_arguments(strs []string) error {
-	for i, val := range strs {
-		if len(val) <= 0 {
-			return errors.New("Argument " + strconv.Itoa(i) + " must be a non-empty string")
-		}
-		if len(val) > 256 {
-			errMsg := "Argument " + strconv.Itoa(i) + " must be <= 256 characters"
-			return errors.New(errMsg)
-		}
-	}
-	return nil
-}
-
-// ==============================================================
-// Arithmetic functions to check for overflow and underflow
-// ==============================================================
-
-// add two number checking for overflow
-func add(b int, q int) (int, error) {
-
-	// Check overflow
-	var sum int
-	sum = q + b
-
-	if (sum < q) == (b >= 0 && q >= 0) {
-		return 0, fmt.Errorf("Math: addition overflow occurred %d + %d", b, q)
-	}
-
-	return sum, nil
-}
-
-// sub two number checking for overflow
-func sub(b int, q int) (int, error) {
-
-	// Check overflow
-	var diff int
-	diff = b - q
-
-	if (diff > b) == (b >= 0 && q >= 0) {
-		return 0, fmt.Errorf("Math: Subtraction overflow occurred  %d - %d", b, q)
-	}
-
-	return diff, nil
-}
-
-// add two float numbers checking for overflow
-func addFloat(b float32, q float32) (float32, error) {
-
-	// Check overflow
-	var sum float32
-	sum = q + b
-
-	if (sum < q) == (b >= 0 && q >= 0) {
-		return 0, fmt.Errorf("Math: addition overflow occurred %f + %f", b, q)
-	}
-
-	return sum, nil
-}
-
-// sub two float numbers checking for overflow
-func subFloat(b float32, q float32) (float32, error) {
-
-	// Check overflow
-	var diff float32
-	diff = b - q
-
-	if (diff > b) == (b >= 0 && q >= 0) {
-		return 0, fmt.Errorf("Math: Subtraction overflow occurred  %f - %f", b, q)
-	}
-
-	return diff, nil
-}